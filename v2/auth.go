@@ -1,8 +1,17 @@
 package twitter
 
-import "net/http"
+import (
+	"context"
+	"net/http"
+)
 
 // Authorizer will add the authorization to the HTTP request
 type Authorizer interface {
 	Add(req *http.Request)
 }
+
+// UnauthorizedRefresher is an Authorizer that can refresh its credentials when a callout is unauthorized
+type UnauthorizedRefresher interface {
+	// RefreshUnauthorized is called with the request that received a 401 and returns if it should be replayed
+	RefreshUnauthorized(ctx context.Context, req *http.Request) bool
+}
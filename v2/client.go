@@ -8,6 +8,7 @@ import (
 	"io"
 	"net/http"
 	"strings"
+	"unicode/utf8"
 )
 
 const (
@@ -53,10 +54,127 @@ const (
 // Client is the HTTP client to use for all requests
 //
 // Host is the base URL to use like, https://api.twitter.com
+//
+// RateLimiter is optional and will wait or fail fast before a callout that would exceed the rate limits
+//
+// Retry is optional and will retry the callouts that fail with a transient error
+//
+// Middleware is optional and will wrap each callout attempt, the first middleware is the outermost
+//
+// EnforceScopes will fail fast with a MissingScopeError when the authorizer reports its granted scopes and
+// the method requires a scope that has not been granted
+//
+// StreamOpts is optional and sets the buffer size, overflow policy and keep alive timeout of the tweet streams
 type Client struct {
-	Authorizer Authorizer
-	Client     *http.Client
-	Host       string
+	Authorizer    Authorizer
+	Client        *http.Client
+	Host          string
+	RateLimiter   *RateLimiter
+	Retry         *RetryPolicy
+	Middleware    []Middleware
+	EnforceScopes bool
+	StreamOpts    StreamOpts
+}
+
+// do will send the request for the operation and endpoint, retrying it with the retry policy if there is one
+func (c *Client) do(req *http.Request, op string, ep endpoint) (*http.Response, error) {
+	if err := c.checkScopes(op); err != nil {
+		return nil, err
+	}
+	if c.Retry == nil || !c.Retry.retryable(req.Method) {
+		return c.intercept(req, op, ep, 1)
+	}
+	return c.Retry.do(req, func(attempt *http.Request, n int) (*http.Response, error) {
+		return c.intercept(attempt, op, ep, n)
+	})
+}
+
+// intercept will pass the attempt through the middleware chain if there is one
+func (c *Client) intercept(req *http.Request, op string, ep endpoint, attempt int) (*http.Response, error) {
+	if len(c.Middleware) == 0 {
+		return c.send(req, ep)
+	}
+	handler := chain(c.Middleware, func(callout *Callout) *CalloutResult {
+		return calloutResult(c.send(callout.Request, ep))
+	})
+	result := handler(&Callout{
+		Operation: op,
+		Endpoint:  string(ep),
+		Request:   req,
+		Attempt:   attempt,
+	})
+	switch {
+	case result == nil:
+		return nil, fmt.Errorf("%s middleware did not return a result", op)
+	case result.Response != nil:
+		return result.Response, nil
+	default:
+		return nil, result.Err
+	}
+}
+
+// send will send a single attempt, if it is unauthorized and the authorizer can refresh its credentials
+// the attempt is replayed once
+func (c *Client) send(req *http.Request, ep endpoint) (*http.Response, error) {
+	refresher, ok := c.Authorizer.(UnauthorizedRefresher)
+	if !ok {
+		return c.sendAuthorized(req, ep)
+	}
+	replay, err := replayRequest(req)
+	if err != nil {
+		return c.sendAuthorized(req, ep)
+	}
+	resp, err := c.sendAuthorized(req, ep)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized || !refresher.RefreshUnauthorized(req.Context(), req) {
+		return resp, err
+	}
+	resp.Body.Close()
+	return c.sendAuthorized(replay, ep)
+}
+
+// sendAuthorized will authorize and send the request, scheduling it with the rate limiter if there is one
+func (c *Client) sendAuthorized(req *http.Request, ep endpoint) (*http.Response, error) {
+	c.Authorizer.Add(req)
+	if c.RateLimiter == nil {
+		return c.Client.Do(req)
+	}
+	key := c.RateLimiter.key(c.Authorizer, req.Method, ep)
+	if err := c.RateLimiter.acquire(req.Context(), key); err != nil {
+		return nil, err
+	}
+	resp, err := c.Client.Do(req)
+	var rl *RateLimit
+	if err == nil {
+		rl = rateFromHeader(resp.Header)
+	}
+	c.RateLimiter.release(key, rl)
+	return resp, err
+}
+
+// responseError will decode the error from a non successful response and close the body
+func responseError(resp *http.Response) error {
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	return decodeResponseError(resp, body)
+}
+
+// decodeResponseError will decode the error from the body of a non successful response
+func decodeResponseError(resp *http.Response, body []byte) error {
+	rl := rateFromHeader(resp.Header)
+
+	e := &ErrorResponse{}
+	if err := json.Unmarshal(body, e); err != nil {
+		return &HTTPError{
+			Status:     resp.Status,
+			StatusCode: resp.StatusCode,
+			URL:        resp.Request.URL.String(),
+			RateLimit:  rl,
+		}
+	}
+	e.StatusCode = resp.StatusCode
+	e.RateLimit = rl
+	return e
 }
 
 // CreateTweet will let a user post polls, quote tweets, tweet with reply setting, tweet with geo, attach
@@ -77,9 +195,8 @@ func (c *Client) CreateTweet(ctx context.Context, tweet CreateTweetRequest) (*Cr
 	}
 	req.Header.Add("Content-Type", "application/json")
 	req.Header.Add("Accept", "application/json")
-	c.Authorizer.Add(req)
 
-	resp, err := c.Client.Do(req)
+	resp, err := c.do(req, "CreateTweet", tweetCreateEndpoint)
 	if err != nil {
 		return nil, fmt.Errorf("create tweet response: %w", err)
 	}
@@ -128,9 +245,8 @@ func (c *Client) DeleteTweet(ctx context.Context, id string) (*DeleteTweetRespon
 		return nil, fmt.Errorf("delete tweet request: %w", err)
 	}
 	req.Header.Add("Accept", "application/json")
-	c.Authorizer.Add(req)
 
-	resp, err := c.Client.Do(req)
+	resp, err := c.do(req, "DeleteTweet", tweetDeleteEndpoint)
 	if err != nil {
 		return nil, fmt.Errorf("delete tweet response: %w", err)
 	}
@@ -185,7 +301,6 @@ func (c *Client) TweetLookup(ctx context.Context, ids []string, opts TweetLookup
 		return nil, fmt.Errorf("tweet lookup request: %w", err)
 	}
 	req.Header.Add("Accept", "application/json")
-	c.Authorizer.Add(req)
 	opts.addQuery(req)
 	if len(ids) > 1 {
 		q := req.URL.Query()
@@ -193,7 +308,7 @@ func (c *Client) TweetLookup(ctx context.Context, ids []string, opts TweetLookup
 		req.URL.RawQuery = q.Encode()
 	}
 
-	resp, err := c.Client.Do(req)
+	resp, err := c.do(req, "TweetLookup", tweetLookupEndpoint)
 	if err != nil {
 		return nil, fmt.Errorf("tweet lookup response: %w", err)
 	}
@@ -266,7 +381,6 @@ func (c *Client) UserLookup(ctx context.Context, ids []string, opts UserLookupOp
 		return nil, fmt.Errorf("user lookup request: %w", err)
 	}
 	req.Header.Add("Accept", "application/json")
-	c.Authorizer.Add(req)
 	opts.addQuery(req)
 	if len(ids) > 1 {
 		q := req.URL.Query()
@@ -274,7 +388,7 @@ func (c *Client) UserLookup(ctx context.Context, ids []string, opts UserLookupOp
 		req.URL.RawQuery = q.Encode()
 	}
 
-	resp, err := c.Client.Do(req)
+	resp, err := c.do(req, "UserLookup", userLookupEndpoint)
 	if err != nil {
 		return nil, fmt.Errorf("user lookup response: %w", err)
 	}
@@ -346,10 +460,9 @@ func (c *Client) UserRetweetLookup(ctx context.Context, tweetID string, opts Use
 		return nil, fmt.Errorf("user retweet lookup request: %w", err)
 	}
 	req.Header.Add("Accept", "application/json")
-	c.Authorizer.Add(req)
 	opts.addQuery(req)
 
-	resp, err := c.Client.Do(req)
+	resp, err := c.do(req, "UserRetweetLookup", userRetweetLookupEndpoint)
 	if err != nil {
 		return nil, fmt.Errorf("user retweet lookup response: %w", err)
 	}
@@ -410,7 +523,6 @@ func (c *Client) UserNameLookup(ctx context.Context, usernames []string, opts Us
 		return nil, fmt.Errorf("username lookup request: %w", err)
 	}
 	req.Header.Add("Accept", "application/json")
-	c.Authorizer.Add(req)
 	opts.addQuery(req)
 	if len(usernames) > 1 {
 		q := req.URL.Query()
@@ -418,7 +530,7 @@ func (c *Client) UserNameLookup(ctx context.Context, usernames []string, opts Us
 		req.URL.RawQuery = q.Encode()
 	}
 
-	resp, err := c.Client.Do(req)
+	resp, err := c.do(req, "UserNameLookup", userNameLookupEndpoint)
 	if err != nil {
 		return nil, fmt.Errorf("username lookup response: %w", err)
 	}
@@ -482,10 +594,9 @@ func (c *Client) AuthUserLookup(ctx context.Context, opts UserLookupOpts) (*User
 		return nil, fmt.Errorf("auth user lookup request: %w", err)
 	}
 	req.Header.Add("Accept", "application/json")
-	c.Authorizer.Add(req)
 	opts.addQuery(req)
 
-	resp, err := c.Client.Do(req)
+	resp, err := c.do(req, "AuthUserLookup", userAuthLookupEndpoint)
 	if err != nil {
 		return nil, fmt.Errorf("auth user lookup response: %w", err)
 	}
@@ -535,7 +646,7 @@ func (c *Client) TweetRecentSearch(ctx context.Context, query string, opts Tweet
 	switch {
 	case len(query) == 0:
 		return nil, fmt.Errorf("tweet recent search: a query is required: %w", ErrParameter)
-	case len(query) > tweetRecentSearchQueryLength:
+	case utf8.RuneCountInString(query) > tweetRecentSearchQueryLength:
 		return nil, fmt.Errorf("tweet recent search: the query over the length (%d): %w", tweetRecentSearchQueryLength, ErrParameter)
 	default:
 	}
@@ -545,13 +656,12 @@ func (c *Client) TweetRecentSearch(ctx context.Context, query string, opts Tweet
 		return nil, fmt.Errorf("tweet recent search request: %w", err)
 	}
 	req.Header.Add("Accept", "application/json")
-	c.Authorizer.Add(req)
 	opts.addQuery(req)
 	q := req.URL.Query()
 	q.Add("query", query)
 	req.URL.RawQuery = q.Encode()
 
-	resp, err := c.Client.Do(req)
+	resp, err := c.do(req, "TweetRecentSearch", tweetRecentSearchEndpoint)
 	if err != nil {
 		return nil, fmt.Errorf("tweet recent search response: %w", err)
 	}
@@ -611,7 +721,7 @@ func (c *Client) TweetSearch(ctx context.Context, query string, opts TweetSearch
 	switch {
 	case len(query) == 0:
 		return nil, fmt.Errorf("tweet search: a query is required: %w", ErrParameter)
-	case len(query) > tweetSearchQueryLength:
+	case utf8.RuneCountInString(query) > tweetSearchQueryLength:
 		return nil, fmt.Errorf("tweet search: the query over the length (%d): %w", tweetSearchQueryLength, ErrParameter)
 	default:
 	}
@@ -621,13 +731,12 @@ func (c *Client) TweetSearch(ctx context.Context, query string, opts TweetSearch
 		return nil, fmt.Errorf("tweet search request: %w", err)
 	}
 	req.Header.Add("Accept", "application/json")
-	c.Authorizer.Add(req)
 	opts.addQuery(req)
 	q := req.URL.Query()
 	q.Add("query", query)
 	req.URL.RawQuery = q.Encode()
 
-	resp, err := c.Client.Do(req)
+	resp, err := c.do(req, "TweetSearch", tweetSearchEndpoint)
 	if err != nil {
 		return nil, fmt.Errorf("tweet search response: %w", err)
 	}
@@ -695,14 +804,13 @@ func (c *Client) TweetSearchStreamAddRule(ctx context.Context, rules []TweetSear
 	}
 	req.Header.Add("Accept", "application/json")
 	req.Header.Add("Content-Type", "application/json")
-	c.Authorizer.Add(req)
 	if dryRun {
 		q := req.URL.Query()
 		q.Add("dry_run", "true")
 		req.URL.RawQuery = q.Encode()
 	}
 
-	resp, err := c.Client.Do(req)
+	resp, err := c.do(req, "TweetSearchStreamAddRule", tweetSearchStreamRulesEndpoint)
 	if err != nil {
 		return nil, fmt.Errorf("tweet search stream add rule http response %w", err)
 	}
@@ -769,14 +877,13 @@ func (c *Client) TweetSearchStreamDeleteRuleByID(ctx context.Context, ruleIDs []
 	}
 	req.Header.Add("Accept", "application/json")
 	req.Header.Add("Content-Type", "application/json")
-	c.Authorizer.Add(req)
 	if dryRun {
 		q := req.URL.Query()
 		q.Add("dry_run", "true")
 		req.URL.RawQuery = q.Encode()
 	}
 
-	resp, err := c.Client.Do(req)
+	resp, err := c.do(req, "TweetSearchStreamDeleteRuleByID", tweetSearchStreamRulesEndpoint)
 	if err != nil {
 		return nil, fmt.Errorf("tweet search stream delete rule http response %w", err)
 	}
@@ -839,14 +946,13 @@ func (c *Client) TweetSearchStreamDeleteRuleByValue(ctx context.Context, ruleVal
 	}
 	req.Header.Add("Accept", "application/json")
 	req.Header.Add("Content-Type", "application/json")
-	c.Authorizer.Add(req)
 	if dryRun {
 		q := req.URL.Query()
 		q.Add("dry_run", "true")
 		req.URL.RawQuery = q.Encode()
 	}
 
-	resp, err := c.Client.Do(req)
+	resp, err := c.do(req, "TweetSearchStreamDeleteRuleByValue", tweetSearchStreamRulesEndpoint)
 	if err != nil {
 		return nil, fmt.Errorf("tweet search stream delete rule http response %w", err)
 	}
@@ -890,7 +996,6 @@ func (c *Client) TweetSearchStreamRules(ctx context.Context, ruleIDs []TweetSear
 		return nil, fmt.Errorf("tweet search stream rules http request %w", err)
 	}
 	req.Header.Add("Accept", "application/json")
-	c.Authorizer.Add(req)
 	if len(ruleIDs) > 0 {
 		ruleArr := tweetSearchStreamRuleIDs(ruleIDs)
 		if err := ruleArr.validate(); err != nil {
@@ -901,7 +1006,7 @@ func (c *Client) TweetSearchStreamRules(ctx context.Context, ruleIDs []TweetSear
 		req.URL.RawQuery = q.Encode()
 	}
 
-	resp, err := c.Client.Do(req)
+	resp, err := c.do(req, "TweetSearchStreamRules", tweetSearchStreamRulesEndpoint)
 	if err != nil {
 		return nil, fmt.Errorf("tweet search stream rules http response %w", err)
 	}
@@ -940,24 +1045,46 @@ func (c *Client) TweetSearchStreamRules(ctx context.Context, ruleIDs []TweetSear
 
 // TweetSearchStream will stream in real-time based on a specific set of filter rules
 func (c *Client) TweetSearchStream(ctx context.Context, opts TweetSearchStreamOpts) (*TweetStream, error) {
+	body, rl, err := c.tweetSearchStreamConnect(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	stream, err := StartTweetStreamWithOpts(ctx, body, c.StreamOpts)
+	if err != nil {
+		return nil, err
+	}
+	stream.RateLimit = rl
+	return stream, nil
+}
+
+// tweetSearchStreamConnect will connect the tweet search stream and return the body of the stream
+func (c *Client) tweetSearchStreamConnect(ctx context.Context, opts TweetSearchStreamOpts) (io.ReadCloser, *RateLimit, error) {
+	if err := c.StreamOpts.validate(); err != nil {
+		return nil, nil, fmt.Errorf("tweet search stream: %w", err)
+	}
 	switch {
 	case opts.BackfillMinutes == 0:
 	case opts.BackfillMinutes > sampleStreamMaxBackOffMin:
-		return nil, fmt.Errorf("tweet search stream: a max back off minutes [%d] is [current: %d]: %w", sampleStreamMaxBackOffMin, opts.BackfillMinutes, ErrParameter)
+		return nil, nil, fmt.Errorf("tweet search stream: a max back off minutes [%d] is [current: %d]: %w", sampleStreamMaxBackOffMin, opts.BackfillMinutes, ErrParameter)
 	default:
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, tweetSearchStreamEndpoint.url(c.Host), nil)
+	return c.streamConnect(ctx, "tweet search stream", "TweetSearchStream", tweetSearchStreamEndpoint, opts.addQuery)
+}
+
+// streamConnect will connect a stream endpoint and return the body of the stream
+func (c *Client) streamConnect(ctx context.Context, name, op string, ep endpoint, addQuery func(req *http.Request)) (io.ReadCloser, *RateLimit, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ep.url(c.Host), nil)
 	if err != nil {
-		return nil, fmt.Errorf("tweet search stream request: %w", err)
+		return nil, nil, fmt.Errorf("%s request: %w", name, err)
 	}
 	req.Header.Add("Accept", "application/json")
-	c.Authorizer.Add(req)
-	opts.addQuery(req)
+	addQuery(req)
 
-	resp, err := c.Client.Do(req)
+	resp, err := c.do(req, op, ep)
 	if err != nil {
-		return nil, fmt.Errorf("tweet search stream response: %w", err)
+		return nil, nil, fmt.Errorf("%s response: %w", name, err)
 	}
 
 	rl := rateFromHeader(resp.Header)
@@ -966,7 +1093,7 @@ func (c *Client) TweetSearchStream(ctx context.Context, opts TweetSearchStreamOp
 		defer resp.Body.Close()
 		e := &ErrorResponse{}
 		if err := json.NewDecoder(resp.Body).Decode(e); err != nil {
-			return nil, &HTTPError{
+			return nil, nil, &HTTPError{
 				Status:     resp.Status,
 				StatusCode: resp.StatusCode,
 				URL:        resp.Request.URL.String(),
@@ -975,12 +1102,10 @@ func (c *Client) TweetSearchStream(ctx context.Context, opts TweetSearchStreamOp
 		}
 		e.StatusCode = resp.StatusCode
 		e.RateLimit = rl
-		return nil, e
+		return nil, nil, e
 	}
 
-	stream := StartTweetStream(resp.Body)
-	stream.RateLimit = rl
-	return stream, nil
+	return resp.Body, rl, nil
 }
 
 // TweetRecentCounts will return a recent tweet counts based of a query
@@ -988,7 +1113,7 @@ func (c *Client) TweetRecentCounts(ctx context.Context, query string, opts Tweet
 	switch {
 	case len(query) == 0:
 		return nil, fmt.Errorf("tweet recent counts: a query is required: %w", ErrParameter)
-	case len(query) > tweetRecentCountsQueryLength:
+	case utf8.RuneCountInString(query) > tweetRecentCountsQueryLength:
 		return nil, fmt.Errorf("tweet recent counts: the query over the length (%d): %w", tweetRecentCountsQueryLength, ErrParameter)
 	default:
 	}
@@ -998,13 +1123,12 @@ func (c *Client) TweetRecentCounts(ctx context.Context, query string, opts Tweet
 		return nil, fmt.Errorf("tweet recent counts request: %w", err)
 	}
 	req.Header.Add("Accept", "application/json")
-	c.Authorizer.Add(req)
 	opts.addQuery(req)
 	q := req.URL.Query()
 	q.Add("query", query)
 	req.URL.RawQuery = q.Encode()
 
-	resp, err := c.Client.Do(req)
+	resp, err := c.do(req, "TweetRecentCounts", tweetRecentCountsEndpoint)
 	if err != nil {
 		return nil, fmt.Errorf("tweet recent counts response: %w", err)
 	}
@@ -1052,7 +1176,7 @@ func (c *Client) TweetAllCounts(ctx context.Context, query string, opts TweetAll
 	switch {
 	case len(query) == 0:
 		return nil, fmt.Errorf("tweet all counts: a query is required: %w", ErrParameter)
-	case len(query) > tweetAllCountsQueryLength:
+	case utf8.RuneCountInString(query) > tweetAllCountsQueryLength:
 		return nil, fmt.Errorf("tweet all counts: the query over the length (%d): %w", tweetAllCountsQueryLength, ErrParameter)
 	default:
 	}
@@ -1062,13 +1186,12 @@ func (c *Client) TweetAllCounts(ctx context.Context, query string, opts TweetAll
 		return nil, fmt.Errorf("tweet all counts request: %w", err)
 	}
 	req.Header.Add("Accept", "application/json")
-	c.Authorizer.Add(req)
 	opts.addQuery(req)
 	q := req.URL.Query()
 	q.Add("query", query)
 	req.URL.RawQuery = q.Encode()
 
-	resp, err := c.Client.Do(req)
+	resp, err := c.do(req, "TweetAllCounts", tweetAllCountsEndpoint)
 	if err != nil {
 		return nil, fmt.Errorf("tweet all counts response: %w", err)
 	}
@@ -1120,12 +1243,11 @@ func (c *Client) UserFollowingLookup(ctx context.Context, id string, opts UserFo
 		return nil, fmt.Errorf("user following lookup request: %w", err)
 	}
 	req.Header.Add("Accept", "application/json")
-	c.Authorizer.Add(req)
 	opts.addQuery(req)
 	q := req.URL.Query()
 	req.URL.RawQuery = q.Encode()
 
-	resp, err := c.Client.Do(req)
+	resp, err := c.do(req, "UserFollowingLookup", userFollowingEndpoint)
 	if err != nil {
 		return nil, fmt.Errorf("user following lookup response: %w", err)
 	}
@@ -1201,9 +1323,8 @@ func (c *Client) UserFollows(ctx context.Context, userID, targetUserID string) (
 	}
 	req.Header.Add("Content-Type", "application/json")
 	req.Header.Add("Accept", "application/json")
-	c.Authorizer.Add(req)
 
-	resp, err := c.Client.Do(req)
+	resp, err := c.do(req, "UserFollows", userFollowingEndpoint)
 	if err != nil {
 		return nil, fmt.Errorf("user follows response: %w", err)
 	}
@@ -1256,9 +1377,8 @@ func (c *Client) DeleteUserFollows(ctx context.Context, userID, targetUserID str
 		return nil, fmt.Errorf("user delete follows request: %w", err)
 	}
 	req.Header.Add("Accept", "application/json")
-	c.Authorizer.Add(req)
 
-	resp, err := c.Client.Do(req)
+	resp, err := c.do(req, "DeleteUserFollows", userFollowingEndpoint)
 	if err != nil {
 		return nil, fmt.Errorf("user delete follows response: %w", err)
 	}
@@ -1306,12 +1426,11 @@ func (c *Client) UserFollowersLookup(ctx context.Context, id string, opts UserFo
 		return nil, fmt.Errorf("user followers lookup request: %w", err)
 	}
 	req.Header.Add("Accept", "application/json")
-	c.Authorizer.Add(req)
 	opts.addQuery(req)
 	q := req.URL.Query()
 	req.URL.RawQuery = q.Encode()
 
-	resp, err := c.Client.Do(req)
+	resp, err := c.do(req, "UserFollowersLookup", userFollowersEndpoint)
 	if err != nil {
 		return nil, fmt.Errorf("user followers lookup response: %w", err)
 	}
@@ -1380,10 +1499,9 @@ func (c *Client) UserTweetTimeline(ctx context.Context, userID string, opts User
 		return nil, fmt.Errorf("user tweet timeline request: %w", err)
 	}
 	req.Header.Add("Accept", "application/json")
-	c.Authorizer.Add(req)
 	opts.addQuery(req)
 
-	resp, err := c.Client.Do(req)
+	resp, err := c.do(req, "UserTweetTimeline", userTweetTimelineEndpoint)
 	if err != nil {
 		return nil, fmt.Errorf("user tweet timeline response: %w", err)
 	}
@@ -1452,10 +1570,9 @@ func (c *Client) UserMentionTimeline(ctx context.Context, userID string, opts Us
 		return nil, fmt.Errorf("user mention timeline request: %w", err)
 	}
 	req.Header.Add("Accept", "application/json")
-	c.Authorizer.Add(req)
 	opts.addQuery(req)
 
-	resp, err := c.Client.Do(req)
+	resp, err := c.do(req, "UserMentionTimeline", userMentionTimelineEndpoint)
 	if err != nil {
 		return nil, fmt.Errorf("user mention timeline response: %w", err)
 	}
@@ -1525,10 +1642,9 @@ func (c *Client) UserTweetReverseChronologicalTimeline(ctx context.Context, user
 		return nil, fmt.Errorf("user tweet reverse chronological timeline request: %w", err)
 	}
 	req.Header.Add("Accept", "application/json")
-	c.Authorizer.Add(req)
 	opts.addQuery(req)
 
-	resp, err := c.Client.Do(req)
+	resp, err := c.do(req, "UserTweetReverseChronologicalTimeline", userTweetReverseChronologicalTimelineEndpoint)
 	if err != nil {
 		return nil, fmt.Errorf("user tweet reverse chronological timeline response: %w", err)
 	}
@@ -1594,9 +1710,8 @@ func (c Client) TweetHideReplies(ctx context.Context, id string, hide bool) (*Tw
 	}
 	req.Header.Add("Accept", "application/json")
 	req.Header.Add("Content-Type", "application/json")
-	c.Authorizer.Add(req)
 
-	resp, err := c.Client.Do(req)
+	resp, err := c.do(req, "TweetHideReplies", tweetHideRepliesEndpoint)
 	if err != nil {
 		return nil, fmt.Errorf("tweet hide replies response: %w", err)
 	}
@@ -1658,9 +1773,8 @@ func (c *Client) UserRetweet(ctx context.Context, userID, tweetID string) (*User
 	}
 	req.Header.Add("Content-Type", "application/json")
 	req.Header.Add("Accept", "application/json")
-	c.Authorizer.Add(req)
 
-	resp, err := c.Client.Do(req)
+	resp, err := c.do(req, "UserRetweet", userManageRetweetEndpoint)
 	if err != nil {
 		return nil, fmt.Errorf("user retweet response: %w", err)
 	}
@@ -1713,9 +1827,8 @@ func (c *Client) DeleteUserRetweet(ctx context.Context, userID, tweetID string)
 		return nil, fmt.Errorf("user delete retweet request: %w", err)
 	}
 	req.Header.Add("Accept", "application/json")
-	c.Authorizer.Add(req)
 
-	resp, err := c.Client.Do(req)
+	resp, err := c.do(req, "DeleteUserRetweet", userManageRetweetEndpoint)
 	if err != nil {
 		return nil, fmt.Errorf("user delete retweet response: %w", err)
 	}
@@ -1767,12 +1880,11 @@ func (c *Client) UserBlocksLookup(ctx context.Context, userID string, opts UserB
 		return nil, fmt.Errorf("user blocked lookup request: %w", err)
 	}
 	req.Header.Add("Accept", "application/json")
-	c.Authorizer.Add(req)
 	opts.addQuery(req)
 	q := req.URL.Query()
 	req.URL.RawQuery = q.Encode()
 
-	resp, err := c.Client.Do(req)
+	resp, err := c.do(req, "UserBlocksLookup", userBlocksEndpoint)
 	if err != nil {
 		return nil, fmt.Errorf("user blocked lookup response: %w", err)
 	}
@@ -1848,9 +1960,8 @@ func (c *Client) UserBlocks(ctx context.Context, userID, targetUserID string) (*
 	}
 	req.Header.Add("Content-Type", "application/json")
 	req.Header.Add("Accept", "application/json")
-	c.Authorizer.Add(req)
 
-	resp, err := c.Client.Do(req)
+	resp, err := c.do(req, "UserBlocks", userBlocksEndpoint)
 	if err != nil {
 		return nil, fmt.Errorf("user blocks response: %w", err)
 	}
@@ -1903,9 +2014,8 @@ func (c *Client) DeleteUserBlocks(ctx context.Context, userID, targetUserID stri
 		return nil, fmt.Errorf("user delete blocks request: %w", err)
 	}
 	req.Header.Add("Accept", "application/json")
-	c.Authorizer.Add(req)
 
-	resp, err := c.Client.Do(req)
+	resp, err := c.do(req, "DeleteUserBlocks", userBlocksEndpoint)
 	if err != nil {
 		return nil, fmt.Errorf("user delete blocks response: %w", err)
 	}
@@ -1957,12 +2067,11 @@ func (c *Client) UserMutesLookup(ctx context.Context, userID string, opts UserMu
 		return nil, fmt.Errorf("user muted lookup request: %w", err)
 	}
 	req.Header.Add("Accept", "application/json")
-	c.Authorizer.Add(req)
 	opts.addQuery(req)
 	q := req.URL.Query()
 	req.URL.RawQuery = q.Encode()
 
-	resp, err := c.Client.Do(req)
+	resp, err := c.do(req, "UserMutesLookup", userMutesEndpoint)
 	if err != nil {
 		return nil, fmt.Errorf("user muted lookup response: %w", err)
 	}
@@ -2038,9 +2147,8 @@ func (c *Client) UserMutes(ctx context.Context, userID, targetUserID string) (*U
 	}
 	req.Header.Add("Content-Type", "application/json")
 	req.Header.Add("Accept", "application/json")
-	c.Authorizer.Add(req)
 
-	resp, err := c.Client.Do(req)
+	resp, err := c.do(req, "UserMutes", userMutesEndpoint)
 	if err != nil {
 		return nil, fmt.Errorf("user mutes response: %w", err)
 	}
@@ -2093,9 +2201,8 @@ func (c *Client) DeleteUserMutes(ctx context.Context, userID, targetUserID strin
 		return nil, fmt.Errorf("user delete mutes request: %w", err)
 	}
 	req.Header.Add("Accept", "application/json")
-	c.Authorizer.Add(req)
 
-	resp, err := c.Client.Do(req)
+	resp, err := c.do(req, "DeleteUserMutes", userMutesEndpoint)
 	if err != nil {
 		return nil, fmt.Errorf("user delete mutes response: %w", err)
 	}
@@ -2152,10 +2259,9 @@ func (c *Client) TweetLikesLookup(ctx context.Context, tweetID string, opts Twee
 		return nil, fmt.Errorf("user tweet likes lookup request: %w", err)
 	}
 	req.Header.Add("Accept", "application/json")
-	c.Authorizer.Add(req)
 	opts.addQuery(req)
 
-	resp, err := c.Client.Do(req)
+	resp, err := c.do(req, "TweetLikesLookup", tweetLikesEndpoint)
 	if err != nil {
 		return nil, fmt.Errorf("user tweet likes lookup response: %w", err)
 	}
@@ -2220,10 +2326,9 @@ func (c *Client) UserLikesLookup(ctx context.Context, userID string, opts UserLi
 		return nil, fmt.Errorf("tweet user likes lookup request: %w", err)
 	}
 	req.Header.Add("Accept", "application/json")
-	c.Authorizer.Add(req)
 	opts.addQuery(req)
 
-	resp, err := c.Client.Do(req)
+	resp, err := c.do(req, "UserLikesLookup", userLikedTweetEndpoint)
 	if err != nil {
 		return nil, fmt.Errorf("tweet user likes lookup response: %w", err)
 	}
@@ -2292,9 +2397,8 @@ func (c *Client) UserLikes(ctx context.Context, userID, tweetID string) (*UserLi
 	}
 	req.Header.Add("Content-Type", "application/json")
 	req.Header.Add("Accept", "application/json")
-	c.Authorizer.Add(req)
 
-	resp, err := c.Client.Do(req)
+	resp, err := c.do(req, "UserLikes", userLikesEndpoint)
 	if err != nil {
 		return nil, fmt.Errorf("user likes response: %w", err)
 	}
@@ -2347,9 +2451,8 @@ func (c *Client) DeleteUserLikes(ctx context.Context, userID, tweetID string) (*
 		return nil, fmt.Errorf("user delete likes request: %w", err)
 	}
 	req.Header.Add("Accept", "application/json")
-	c.Authorizer.Add(req)
 
-	resp, err := c.Client.Do(req)
+	resp, err := c.do(req, "DeleteUserLikes", userLikesEndpoint)
 	if err != nil {
 		return nil, fmt.Errorf("user delete likes response: %w", err)
 	}
@@ -2388,47 +2491,32 @@ func (c *Client) DeleteUserLikes(ctx context.Context, userID, tweetID string) (*
 
 // TweetSampleStream will return a streamer for streaming 1% of all tweets real-time
 func (c *Client) TweetSampleStream(ctx context.Context, opts TweetSampleStreamOpts) (*TweetStream, error) {
-	switch {
-	case opts.BackfillMinutes == 0:
-	case opts.BackfillMinutes > sampleStreamMaxBackOffMin:
-		return nil, fmt.Errorf("tweet sample stream: a max back off minutes [%d] is [current: %d]: %w", sampleStreamMaxBackOffMin, opts.BackfillMinutes, ErrParameter)
-	default:
-	}
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, tweetSampleStreamEndpoint.url(c.Host), nil)
+	body, rl, err := c.tweetSampleStreamConnect(ctx, opts)
 	if err != nil {
-		return nil, fmt.Errorf("tweet sample stream request: %w", err)
+		return nil, err
 	}
-	req.Header.Add("Accept", "application/json")
-	c.Authorizer.Add(req)
-	opts.addQuery(req)
 
-	resp, err := c.Client.Do(req)
+	stream, err := StartTweetStreamWithOpts(ctx, body, c.StreamOpts)
 	if err != nil {
-		return nil, fmt.Errorf("tweet sample stream response: %w", err)
+		return nil, err
 	}
+	stream.RateLimit = rl
+	return stream, nil
+}
 
-	rl := rateFromHeader(resp.Header)
-
-	if resp.StatusCode != http.StatusOK {
-		defer resp.Body.Close()
-		e := &ErrorResponse{}
-		if err := json.NewDecoder(resp.Body).Decode(e); err != nil {
-			return nil, &HTTPError{
-				Status:     resp.Status,
-				StatusCode: resp.StatusCode,
-				URL:        resp.Request.URL.String(),
-				RateLimit:  rl,
-			}
-		}
-		e.StatusCode = resp.StatusCode
-		e.RateLimit = rl
-		return nil, e
+// tweetSampleStreamConnect will connect the tweet sample stream and return the body of the stream
+func (c *Client) tweetSampleStreamConnect(ctx context.Context, opts TweetSampleStreamOpts) (io.ReadCloser, *RateLimit, error) {
+	if err := c.StreamOpts.validate(); err != nil {
+		return nil, nil, fmt.Errorf("tweet sample stream: %w", err)
+	}
+	switch {
+	case opts.BackfillMinutes == 0:
+	case opts.BackfillMinutes > sampleStreamMaxBackOffMin:
+		return nil, nil, fmt.Errorf("tweet sample stream: a max back off minutes [%d] is [current: %d]: %w", sampleStreamMaxBackOffMin, opts.BackfillMinutes, ErrParameter)
+	default:
 	}
 
-	stream := StartTweetStream(resp.Body)
-	stream.RateLimit = rl
-	return stream, nil
+	return c.streamConnect(ctx, "tweet sample stream", "TweetSampleStream", tweetSampleStreamEndpoint, opts.addQuery)
 }
 
 // ListLookup returns the details of a specified list
@@ -2446,10 +2534,9 @@ func (c *Client) ListLookup(ctx context.Context, listID string, opts ListLookupO
 		return nil, fmt.Errorf("list lookup request: %w", err)
 	}
 	req.Header.Add("Accept", "application/json")
-	c.Authorizer.Add(req)
 	opts.addQuery(req)
 
-	resp, err := c.Client.Do(req)
+	resp, err := c.do(req, "ListLookup", listLookupEndpoint)
 	if err != nil {
 		return nil, fmt.Errorf("list lookup response: %w", err)
 	}
@@ -2510,10 +2597,9 @@ func (c *Client) UserListLookup(ctx context.Context, userID string, opts UserLis
 		return nil, fmt.Errorf("user list lookup request: %w", err)
 	}
 	req.Header.Add("Accept", "application/json")
-	c.Authorizer.Add(req)
 	opts.addQuery(req)
 
-	resp, err := c.Client.Do(req)
+	resp, err := c.do(req, "UserListLookup", userListLookupEndpoint)
 	if err != nil {
 		return nil, fmt.Errorf("user list lookup response: %w", err)
 	}
@@ -2576,10 +2662,9 @@ func (c *Client) ListTweetLookup(ctx context.Context, listID string, opts ListTw
 		return nil, fmt.Errorf("list tweet lookup request: %w", err)
 	}
 	req.Header.Add("Accept", "application/json")
-	c.Authorizer.Add(req)
 	opts.addQuery(req)
 
-	resp, err := c.Client.Do(req)
+	resp, err := c.do(req, "ListTweetLookup", listTweetLookupEndpoint)
 	if err != nil {
 		return nil, fmt.Errorf("list tweet lookup response: %w", err)
 	}
@@ -2644,9 +2729,8 @@ func (c *Client) CreateList(ctx context.Context, list ListMetaData) (*ListCreate
 	}
 	req.Header.Add("Content-Type", "application/json")
 	req.Header.Add("Accept", "application/json")
-	c.Authorizer.Add(req)
 
-	resp, err := c.Client.Do(req)
+	resp, err := c.do(req, "CreateList", listCreateEndpoint)
 	if err != nil {
 		return nil, fmt.Errorf("create list response: %w", err)
 	}
@@ -2704,9 +2788,8 @@ func (c *Client) UpdateList(ctx context.Context, listID string, update ListMetaD
 	}
 	req.Header.Add("Content-Type", "application/json")
 	req.Header.Add("Accept", "application/json")
-	c.Authorizer.Add(req)
 
-	resp, err := c.Client.Do(req)
+	resp, err := c.do(req, "UpdateList", listUpdateEndpoint)
 	if err != nil {
 		return nil, fmt.Errorf("update list response: %w", err)
 	}
@@ -2758,9 +2841,8 @@ func (c *Client) DeleteList(ctx context.Context, listID string) (*ListDeleteResp
 		return nil, fmt.Errorf("delete list request: %w", err)
 	}
 	req.Header.Add("Accept", "application/json")
-	c.Authorizer.Add(req)
 
-	resp, err := c.Client.Do(req)
+	resp, err := c.do(req, "DeleteList", listDeleteEndpoint)
 	if err != nil {
 		return nil, fmt.Errorf("delete list response: %w", err)
 	}
@@ -2826,9 +2908,8 @@ func (c *Client) AddListMember(ctx context.Context, listID, userID string) (*Lis
 	}
 	req.Header.Add("Content-Type", "application/json")
 	req.Header.Add("Accept", "application/json")
-	c.Authorizer.Add(req)
 
-	resp, err := c.Client.Do(req)
+	resp, err := c.do(req, "AddListMember", listMemberEndpoint)
 	if err != nil {
 		return nil, fmt.Errorf("create list member response: %w", err)
 	}
@@ -2882,9 +2963,8 @@ func (c *Client) RemoveListMember(ctx context.Context, listID, userID string) (*
 		return nil, fmt.Errorf("remove list member request: %w", err)
 	}
 	req.Header.Add("Accept", "application/json")
-	c.Authorizer.Add(req)
 
-	resp, err := c.Client.Do(req)
+	resp, err := c.do(req, "RemoveListMember", listMemberEndpoint)
 	if err != nil {
 		return nil, fmt.Errorf("remove list member response: %w", err)
 	}
@@ -2940,10 +3020,9 @@ func (c *Client) ListUserMembers(ctx context.Context, listID string, opts ListUs
 		return nil, fmt.Errorf("list user members request: %w", err)
 	}
 	req.Header.Add("Accept", "application/json")
-	c.Authorizer.Add(req)
 	opts.addQuery(req)
 
-	resp, err := c.Client.Do(req)
+	resp, err := c.do(req, "ListUserMembers", listMemberEndpoint)
 	if err != nil {
 		return nil, fmt.Errorf("list user members response: %w", err)
 	}
@@ -3006,10 +3085,9 @@ func (c *Client) UserListMemberships(ctx context.Context, userID string, opts Us
 		return nil, fmt.Errorf("user list membership request: %w", err)
 	}
 	req.Header.Add("Accept", "application/json")
-	c.Authorizer.Add(req)
 	opts.addQuery(req)
 
-	resp, err := c.Client.Do(req)
+	resp, err := c.do(req, "UserListMemberships", userListMemberEndpoint)
 	if err != nil {
 		return nil, fmt.Errorf("user list membership response: %w", err)
 	}
@@ -3082,9 +3160,8 @@ func (c *Client) UserPinList(ctx context.Context, userID, listID string) (*UserP
 	}
 	req.Header.Add("Content-Type", "application/json")
 	req.Header.Add("Accept", "application/json")
-	c.Authorizer.Add(req)
 
-	resp, err := c.Client.Do(req)
+	resp, err := c.do(req, "UserPinList", userPinnedListEndpoint)
 	if err != nil {
 		return nil, fmt.Errorf("user pin list response: %w", err)
 	}
@@ -3138,9 +3215,8 @@ func (c *Client) UserUnpinList(ctx context.Context, userID, listID string) (*Use
 		return nil, fmt.Errorf("user unpin list request: %w", err)
 	}
 	req.Header.Add("Accept", "application/json")
-	c.Authorizer.Add(req)
 
-	resp, err := c.Client.Do(req)
+	resp, err := c.do(req, "UserUnpinList", userPinnedListEndpoint)
 	if err != nil {
 		return nil, fmt.Errorf("user unpin list response: %w", err)
 	}
@@ -3193,10 +3269,9 @@ func (c *Client) UserPinnedLists(ctx context.Context, userID string, opts UserPi
 		return nil, fmt.Errorf("user pinned list request: %w", err)
 	}
 	req.Header.Add("Accept", "application/json")
-	c.Authorizer.Add(req)
 	opts.addQuery(req)
 
-	resp, err := c.Client.Do(req)
+	resp, err := c.do(req, "UserPinnedLists", userPinnedListEndpoint)
 	if err != nil {
 		return nil, fmt.Errorf("user pinned list response: %w", err)
 	}
@@ -3269,9 +3344,8 @@ func (c *Client) UserFollowList(ctx context.Context, userID, listID string) (*Us
 	}
 	req.Header.Add("Content-Type", "application/json")
 	req.Header.Add("Accept", "application/json")
-	c.Authorizer.Add(req)
 
-	resp, err := c.Client.Do(req)
+	resp, err := c.do(req, "UserFollowList", userFollowedListEndpoint)
 	if err != nil {
 		return nil, fmt.Errorf("user follow list response: %w", err)
 	}
@@ -3325,9 +3399,8 @@ func (c *Client) UserUnfollowList(ctx context.Context, userID, listID string) (*
 		return nil, fmt.Errorf("user unfollow list request: %w", err)
 	}
 	req.Header.Add("Accept", "application/json")
-	c.Authorizer.Add(req)
 
-	resp, err := c.Client.Do(req)
+	resp, err := c.do(req, "UserUnfollowList", userFollowedListEndpoint)
 	if err != nil {
 		return nil, fmt.Errorf("user unfollow list response: %w", err)
 	}
@@ -3383,10 +3456,9 @@ func (c *Client) UserFollowedLists(ctx context.Context, userID string, opts User
 		return nil, fmt.Errorf("user followed list request: %w", err)
 	}
 	req.Header.Add("Accept", "application/json")
-	c.Authorizer.Add(req)
 	opts.addQuery(req)
 
-	resp, err := c.Client.Do(req)
+	resp, err := c.do(req, "UserFollowedLists", userFollowedListEndpoint)
 	if err != nil {
 		return nil, fmt.Errorf("user followed list response: %w", err)
 	}
@@ -3449,10 +3521,9 @@ func (c *Client) ListUserFollowers(ctx context.Context, listID string, opts List
 		return nil, fmt.Errorf("list user followers request: %w", err)
 	}
 	req.Header.Add("Accept", "application/json")
-	c.Authorizer.Add(req)
 	opts.addQuery(req)
 
-	resp, err := c.Client.Do(req)
+	resp, err := c.do(req, "ListUserFollowers", listUserFollowersEndpoint)
 	if err != nil {
 		return nil, fmt.Errorf("list user followers response: %w", err)
 	}
@@ -3515,7 +3586,6 @@ func (c *Client) SpacesLookup(ctx context.Context, ids []string, opts SpacesLook
 		return nil, fmt.Errorf("space lookup request: %w", err)
 	}
 	req.Header.Add("Accept", "application/json")
-	c.Authorizer.Add(req)
 	opts.addQuery(req)
 	if len(ids) > 1 {
 		q := req.URL.Query()
@@ -3523,7 +3593,7 @@ func (c *Client) SpacesLookup(ctx context.Context, ids []string, opts SpacesLook
 		req.URL.RawQuery = q.Encode()
 	}
 
-	resp, err := c.Client.Do(req)
+	resp, err := c.do(req, "SpacesLookup", spaceLookupEndpoint)
 	if err != nil {
 		return nil, fmt.Errorf("space lookup response: %w", err)
 	}
@@ -3593,13 +3663,12 @@ func (c *Client) SpacesByCreatorLookup(ctx context.Context, userIDs []string, op
 		return nil, fmt.Errorf("space by creator lookup request: %w", err)
 	}
 	req.Header.Add("Accept", "application/json")
-	c.Authorizer.Add(req)
 	opts.addQuery(req)
 	q := req.URL.Query()
 	q.Add("user_ids", strings.Join(userIDs, ","))
 	req.URL.RawQuery = q.Encode()
 
-	resp, err := c.Client.Do(req)
+	resp, err := c.do(req, "SpacesByCreatorLookup", spaceByCreatorLookupEndpoint)
 	if err != nil {
 		return nil, fmt.Errorf("space by creator lookup response: %w", err)
 	}
@@ -3656,10 +3725,9 @@ func (c *Client) SpaceBuyersLookup(ctx context.Context, spaceID string, opts Spa
 		return nil, fmt.Errorf("space buyers lookup request: %w", err)
 	}
 	req.Header.Add("Accept", "application/json")
-	c.Authorizer.Add(req)
 	opts.addQuery(req)
 
-	resp, err := c.Client.Do(req)
+	resp, err := c.do(req, "SpaceBuyersLookup", spaceBuyersLookupEndpoint)
 	if err != nil {
 		return nil, fmt.Errorf("space buyers lookup response: %w", err)
 	}
@@ -3712,10 +3780,9 @@ func (c *Client) SpaceTweetsLookup(ctx context.Context, spaceID string, opts Spa
 		return nil, fmt.Errorf("space tweets lookup request: %w", err)
 	}
 	req.Header.Add("Accept", "application/json")
-	c.Authorizer.Add(req)
 	opts.addQuery(req)
 
-	resp, err := c.Client.Do(req)
+	resp, err := c.do(req, "SpaceTweetsLookup", spaceTweetsLookupEndpoint)
 	if err != nil {
 		return nil, fmt.Errorf("space tweets lookup response: %w", err)
 	}
@@ -3772,13 +3839,12 @@ func (c *Client) SpacesSearch(ctx context.Context, query string, opts SpacesSear
 		return nil, fmt.Errorf("space search request: %w", err)
 	}
 	req.Header.Add("Accept", "application/json")
-	c.Authorizer.Add(req)
 	opts.addQuery(req)
 	q := req.URL.Query()
 	q.Add("query", query)
 	req.URL.RawQuery = q.Encode()
 
-	resp, err := c.Client.Do(req)
+	resp, err := c.do(req, "SpacesSearch", spaceSearchEndpoint)
 	if err != nil {
 		return nil, fmt.Errorf("space search response: %w", err)
 	}
@@ -3853,7 +3919,7 @@ func (c *Client) CreateComplianceBatchJob(ctx context.Context, jobType Complianc
 	req.Header.Add("Accept", "application/json")
 	req.Header.Add("Content-Type", "application/json")
 
-	resp, err := c.Client.Do(req)
+	resp, err := c.do(req, "CreateComplianceBatchJob", complianceJobsEndpoint)
 	if err != nil {
 		return nil, fmt.Errorf("create compliance batch job response: %w", err)
 	}
@@ -3910,7 +3976,7 @@ func (c *Client) ComplianceBatchJob(ctx context.Context, id string) (*Compliance
 	}
 	req.Header.Add("Accept", "application/json")
 
-	resp, err := c.Client.Do(req)
+	resp, err := c.do(req, "ComplianceBatchJob", complianceJobsEndpoint)
 	if err != nil {
 		return nil, fmt.Errorf("compliance batch job response: %w", err)
 	}
@@ -3972,7 +4038,7 @@ func (c *Client) ComplianceBatchJobLookup(ctx context.Context, jobType Complianc
 	q.Add("type", string(jobType))
 	req.URL.RawQuery = q.Encode()
 
-	resp, err := c.Client.Do(req)
+	resp, err := c.do(req, "ComplianceBatchJobLookup", complianceJobsEndpoint)
 	if err != nil {
 		return nil, fmt.Errorf("compliance batch job lookup response: %w", err)
 	}
@@ -4037,10 +4103,9 @@ func (c *Client) QuoteTweetsLookup(ctx context.Context, tweetID string, opts Quo
 		return nil, fmt.Errorf("quote tweets lookup request: %w", err)
 	}
 	req.Header.Add("Accept", "application/json")
-	c.Authorizer.Add(req)
 	opts.addQuery(req)
 
-	resp, err := c.Client.Do(req)
+	resp, err := c.do(req, "QuoteTweetsLookup", quoteTweetLookupEndpoint)
 	if err != nil {
 		return nil, fmt.Errorf("quote tweets lookup response: %w", err)
 	}
@@ -4103,10 +4168,9 @@ func (c *Client) TweetBookmarksLookup(ctx context.Context, userID string, opts T
 		return nil, fmt.Errorf("tweet bookmarks lookup request: %w", err)
 	}
 	req.Header.Add("Accept", "application/json")
-	c.Authorizer.Add(req)
 	opts.addQuery(req)
 
-	resp, err := c.Client.Do(req)
+	resp, err := c.do(req, "TweetBookmarksLookup", tweetBookmarksEndpoint)
 	if err != nil {
 		return nil, fmt.Errorf("tweet bookmarks lookup response: %w", err)
 	}
@@ -4179,9 +4243,8 @@ func (c *Client) AddTweetBookmark(ctx context.Context, userID, tweetID string) (
 	}
 	req.Header.Add("Accept", "application/json")
 	req.Header.Add("Content-Type", "application/json")
-	c.Authorizer.Add(req)
 
-	resp, err := c.Client.Do(req)
+	resp, err := c.do(req, "AddTweetBookmark", tweetBookmarksEndpoint)
 	if err != nil {
 		return nil, fmt.Errorf("tweet bookmarks add response: %w", err)
 	}
@@ -4238,9 +4301,8 @@ func (c *Client) RemoveTweetBookmark(ctx context.Context, userID, tweetID string
 		return nil, fmt.Errorf("tweet bookmarks remove request: %w", err)
 	}
 	req.Header.Add("Accept", "application/json")
-	c.Authorizer.Add(req)
 
-	resp, err := c.Client.Do(req)
+	resp, err := c.do(req, "RemoveTweetBookmark", tweetBookmarksEndpoint)
 	if err != nil {
 		return nil, fmt.Errorf("tweet bookmarks remove response: %w", err)
 	}
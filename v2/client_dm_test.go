@@ -542,7 +542,7 @@ func TestSendDMRequest_validate(t *testing.T) {
 		{
 			name: "Valid - With MediaID",
 			r: SendDMRequest{
-				MediaID: "media123",
+				Attachments: []*DMAttachment{{MediaID: "media123"}},
 			},
 			wantErr: false,
 		},
@@ -587,7 +587,7 @@ func TestClient_SendDMByParticipantID(t *testing.T) {
 					if req.Method != http.MethodPost {
 						log.Panicf("the method is not correct %s %s", req.Method, http.MethodPost)
 					}
-					if !strings.Contains(req.URL.String(), "dm_conversations/by/participant_id") {
+					if !strings.Contains(req.URL.String(), "dm_conversations/with/1697637602605010945/messages") {
 						log.Panicf("the url is not correct %s", req.URL.String())
 					}
 					body := `{
@@ -674,7 +674,7 @@ func TestSendDMByParticipantRequest_validate(t *testing.T) {
 		{
 			name: "Valid - With MediaID",
 			r: SendDMByParticipantRequest{
-				MediaID: "media123",
+				Attachments: []*DMAttachment{{MediaID: "media123"}},
 			},
 			wantErr: false,
 		},
@@ -0,0 +1,474 @@
+package twitter
+
+import "context"
+
+// UserTweetTimelinePaginator will walk all of the pages of the user tweet timeline
+func (c *Client) UserTweetTimelinePaginator(userID string, opts UserTweetTimelineOpts, pagination PaginationOpts) *TweetPaginator {
+	return newTweetPaginator(pagination, func(ctx context.Context, token string) (*TweetPage, error) {
+		opts.PaginationToken = token
+		resp, err := c.UserTweetTimeline(ctx, userID, opts)
+		if err != nil {
+			return nil, err
+		}
+		page := &TweetPage{
+			Raw:       resp.Raw,
+			RateLimit: resp.RateLimit,
+		}
+		if resp.Meta != nil {
+			page.NextToken = resp.Meta.NextToken
+		}
+		return page, nil
+	})
+}
+
+// UserMentionTimelinePaginator will walk all of the pages of the user mention timeline
+func (c *Client) UserMentionTimelinePaginator(userID string, opts UserMentionTimelineOpts, pagination PaginationOpts) *TweetPaginator {
+	return newTweetPaginator(pagination, func(ctx context.Context, token string) (*TweetPage, error) {
+		opts.PaginationToken = token
+		resp, err := c.UserMentionTimeline(ctx, userID, opts)
+		if err != nil {
+			return nil, err
+		}
+		page := &TweetPage{
+			Raw:       resp.Raw,
+			RateLimit: resp.RateLimit,
+		}
+		if resp.Meta != nil {
+			page.NextToken = resp.Meta.NextToken
+		}
+		return page, nil
+	})
+}
+
+// UserTweetReverseChronologicalTimelinePaginator will walk all of the pages of the user reverse chronological timeline
+func (c *Client) UserTweetReverseChronologicalTimelinePaginator(userID string, opts UserTweetReverseChronologicalTimelineOpts, pagination PaginationOpts) *TweetPaginator {
+	return newTweetPaginator(pagination, func(ctx context.Context, token string) (*TweetPage, error) {
+		opts.PaginationToken = token
+		resp, err := c.UserTweetReverseChronologicalTimeline(ctx, userID, opts)
+		if err != nil {
+			return nil, err
+		}
+		page := &TweetPage{
+			Raw:       resp.Raw,
+			RateLimit: resp.RateLimit,
+		}
+		if resp.Meta != nil {
+			page.NextToken = resp.Meta.NextToken
+		}
+		return page, nil
+	})
+}
+
+// TweetRecentSearchPaginator will walk all of the pages of the tweet recent search
+func (c *Client) TweetRecentSearchPaginator(query string, opts TweetRecentSearchOpts, pagination PaginationOpts) *TweetPaginator {
+	return newTweetPaginator(pagination, func(ctx context.Context, token string) (*TweetPage, error) {
+		opts.NextToken = token
+		resp, err := c.TweetRecentSearch(ctx, query, opts)
+		if err != nil {
+			return nil, err
+		}
+		page := &TweetPage{
+			Raw:       resp.Raw,
+			RateLimit: resp.RateLimit,
+		}
+		if resp.Meta != nil {
+			page.NextToken = resp.Meta.NextToken
+		}
+		return page, nil
+	})
+}
+
+// TweetSearchPaginator will walk all of the pages of the tweet full archive search
+func (c *Client) TweetSearchPaginator(query string, opts TweetSearchOpts, pagination PaginationOpts) *TweetPaginator {
+	return newTweetPaginator(pagination, func(ctx context.Context, token string) (*TweetPage, error) {
+		opts.NextToken = token
+		resp, err := c.TweetSearch(ctx, query, opts)
+		if err != nil {
+			return nil, err
+		}
+		page := &TweetPage{
+			Raw:       resp.Raw,
+			RateLimit: resp.RateLimit,
+		}
+		if resp.Meta != nil {
+			page.NextToken = resp.Meta.NextToken
+		}
+		return page, nil
+	})
+}
+
+// ListTweetLookupPaginator will walk all of the pages of the list tweet lookup
+func (c *Client) ListTweetLookupPaginator(listID string, opts ListTweetLookupOpts, pagination PaginationOpts) *TweetPaginator {
+	return newTweetPaginator(pagination, func(ctx context.Context, token string) (*TweetPage, error) {
+		opts.PaginationToken = token
+		resp, err := c.ListTweetLookup(ctx, listID, opts)
+		if err != nil {
+			return nil, err
+		}
+		page := &TweetPage{
+			Raw:       resp.Raw,
+			RateLimit: resp.RateLimit,
+		}
+		if resp.Meta != nil {
+			page.NextToken = resp.Meta.NextToken
+		}
+		return page, nil
+	})
+}
+
+// UserLikesLookupPaginator will walk all of the pages of the user liked tweets
+func (c *Client) UserLikesLookupPaginator(userID string, opts UserLikesLookupOpts, pagination PaginationOpts) *TweetPaginator {
+	return newTweetPaginator(pagination, func(ctx context.Context, token string) (*TweetPage, error) {
+		opts.PaginationToken = token
+		resp, err := c.UserLikesLookup(ctx, userID, opts)
+		if err != nil {
+			return nil, err
+		}
+		page := &TweetPage{
+			Raw:       resp.Raw,
+			RateLimit: resp.RateLimit,
+		}
+		if resp.Meta != nil {
+			page.NextToken = resp.Meta.NextToken
+		}
+		return page, nil
+	})
+}
+
+// QuoteTweetsLookupPaginator will walk all of the pages of the quote tweets lookup
+func (c *Client) QuoteTweetsLookupPaginator(tweetID string, opts QuoteTweetsLookupOpts, pagination PaginationOpts) *TweetPaginator {
+	return newTweetPaginator(pagination, func(ctx context.Context, token string) (*TweetPage, error) {
+		opts.PaginationToken = token
+		resp, err := c.QuoteTweetsLookup(ctx, tweetID, opts)
+		if err != nil {
+			return nil, err
+		}
+		page := &TweetPage{
+			Raw:       resp.Raw,
+			RateLimit: resp.RateLimit,
+		}
+		if resp.Meta != nil {
+			page.NextToken = resp.Meta.NextToken
+		}
+		return page, nil
+	})
+}
+
+// TweetBookmarksLookupPaginator will walk all of the pages of the tweet bookmarks lookup
+func (c *Client) TweetBookmarksLookupPaginator(userID string, opts TweetBookmarksLookupOpts, pagination PaginationOpts) *TweetPaginator {
+	return newTweetPaginator(pagination, func(ctx context.Context, token string) (*TweetPage, error) {
+		opts.PaginationToken = token
+		resp, err := c.TweetBookmarksLookup(ctx, userID, opts)
+		if err != nil {
+			return nil, err
+		}
+		page := &TweetPage{
+			Raw:       resp.Raw,
+			RateLimit: resp.RateLimit,
+		}
+		if resp.Meta != nil {
+			page.NextToken = resp.Meta.NextToken
+		}
+		return page, nil
+	})
+}
+
+// UserFollowingLookupPaginator will walk all of the pages of the user following lookup
+func (c *Client) UserFollowingLookupPaginator(id string, opts UserFollowingLookupOpts, pagination PaginationOpts) *UserPaginator {
+	return newUserPaginator(pagination, func(ctx context.Context, token string) (*UserPage, error) {
+		opts.PaginationToken = token
+		resp, err := c.UserFollowingLookup(ctx, id, opts)
+		if err != nil {
+			return nil, err
+		}
+		page := &UserPage{
+			Raw:       resp.Raw,
+			RateLimit: resp.RateLimit,
+		}
+		if resp.Meta != nil {
+			page.NextToken = resp.Meta.NextToken
+		}
+		return page, nil
+	})
+}
+
+// UserFollowersLookupPaginator will walk all of the pages of the user followers lookup
+func (c *Client) UserFollowersLookupPaginator(id string, opts UserFollowersLookupOpts, pagination PaginationOpts) *UserPaginator {
+	return newUserPaginator(pagination, func(ctx context.Context, token string) (*UserPage, error) {
+		opts.PaginationToken = token
+		resp, err := c.UserFollowersLookup(ctx, id, opts)
+		if err != nil {
+			return nil, err
+		}
+		page := &UserPage{
+			Raw:       resp.Raw,
+			RateLimit: resp.RateLimit,
+		}
+		if resp.Meta != nil {
+			page.NextToken = resp.Meta.NextToken
+		}
+		return page, nil
+	})
+}
+
+// UserBlocksLookupPaginator will walk all of the pages of the user blocks lookup
+func (c *Client) UserBlocksLookupPaginator(userID string, opts UserBlocksLookupOpts, pagination PaginationOpts) *UserPaginator {
+	return newUserPaginator(pagination, func(ctx context.Context, token string) (*UserPage, error) {
+		opts.PaginationToken = token
+		resp, err := c.UserBlocksLookup(ctx, userID, opts)
+		if err != nil {
+			return nil, err
+		}
+		page := &UserPage{
+			Raw:       resp.Raw,
+			RateLimit: resp.RateLimit,
+		}
+		if resp.Meta != nil {
+			page.NextToken = resp.Meta.NextToken
+		}
+		return page, nil
+	})
+}
+
+// UserMutesLookupPaginator will walk all of the pages of the user mutes lookup
+func (c *Client) UserMutesLookupPaginator(userID string, opts UserMutesLookupOpts, pagination PaginationOpts) *UserPaginator {
+	return newUserPaginator(pagination, func(ctx context.Context, token string) (*UserPage, error) {
+		opts.PaginationToken = token
+		resp, err := c.UserMutesLookup(ctx, userID, opts)
+		if err != nil {
+			return nil, err
+		}
+		page := &UserPage{
+			Raw:       resp.Raw,
+			RateLimit: resp.RateLimit,
+		}
+		if resp.Meta != nil {
+			page.NextToken = resp.Meta.NextToken
+		}
+		return page, nil
+	})
+}
+
+// TweetLikesLookupPaginator will walk all of the pages of the tweet liking users
+func (c *Client) TweetLikesLookupPaginator(tweetID string, opts TweetLikesLookupOpts, pagination PaginationOpts) *UserPaginator {
+	return newUserPaginator(pagination, func(ctx context.Context, token string) (*UserPage, error) {
+		opts.PaginationToken = token
+		resp, err := c.TweetLikesLookup(ctx, tweetID, opts)
+		if err != nil {
+			return nil, err
+		}
+		page := &UserPage{
+			Raw:       resp.Raw,
+			RateLimit: resp.RateLimit,
+		}
+		if resp.Meta != nil {
+			page.NextToken = resp.Meta.NextToken
+		}
+		return page, nil
+	})
+}
+
+// UserRetweetLookupPaginator will walk all of the pages of the users that have retweeted
+func (c *Client) UserRetweetLookupPaginator(tweetID string, opts UserRetweetLookupOpts, pagination PaginationOpts) *UserPaginator {
+	return newUserPaginator(pagination, func(ctx context.Context, token string) (*UserPage, error) {
+		opts.PaginationToken = token
+		resp, err := c.UserRetweetLookup(ctx, tweetID, opts)
+		if err != nil {
+			return nil, err
+		}
+		page := &UserPage{
+			RateLimit: resp.RateLimit,
+		}
+		if resp.Raw != nil {
+			page.Raw = &UserRaw{
+				Users:  resp.Raw.Users,
+				Errors: resp.Raw.Errors,
+			}
+			if resp.Raw.Includes != nil {
+				page.Raw.Includes = &UserRawIncludes{
+					Tweets: resp.Raw.Includes.Tweets,
+				}
+			}
+		}
+		if resp.Meta != nil {
+			page.NextToken = resp.Meta.NextToken
+		}
+		return page, nil
+	})
+}
+
+// ListUserMembersPaginator will walk all of the pages of the list members
+func (c *Client) ListUserMembersPaginator(listID string, opts ListUserMembersOpts, pagination PaginationOpts) *UserPaginator {
+	return newUserPaginator(pagination, func(ctx context.Context, token string) (*UserPage, error) {
+		opts.PaginationToken = token
+		resp, err := c.ListUserMembers(ctx, listID, opts)
+		if err != nil {
+			return nil, err
+		}
+		page := &UserPage{
+			Raw:       resp.Raw,
+			RateLimit: resp.RateLimit,
+		}
+		if resp.Meta != nil {
+			page.NextToken = resp.Meta.NextToken
+		}
+		return page, nil
+	})
+}
+
+// ListUserFollowersPaginator will walk all of the pages of the list followers
+func (c *Client) ListUserFollowersPaginator(listID string, opts ListUserFollowersOpts, pagination PaginationOpts) *UserPaginator {
+	return newUserPaginator(pagination, func(ctx context.Context, token string) (*UserPage, error) {
+		opts.PaginationToken = token
+		resp, err := c.ListUserFollowers(ctx, listID, opts)
+		if err != nil {
+			return nil, err
+		}
+		page := &UserPage{
+			Raw:       resp.Raw,
+			RateLimit: resp.RateLimit,
+		}
+		if resp.Meta != nil {
+			page.NextToken = resp.Meta.NextToken
+		}
+		return page, nil
+	})
+}
+
+// UserListLookupPaginator will walk all of the pages of the user owned lists
+func (c *Client) UserListLookupPaginator(userID string, opts UserListLookupOpts, pagination PaginationOpts) *ListPaginator {
+	return newListPaginator(pagination, func(ctx context.Context, token string) (*ListPage, error) {
+		opts.PaginationToken = token
+		resp, err := c.UserListLookup(ctx, userID, opts)
+		if err != nil {
+			return nil, err
+		}
+		page := &ListPage{
+			Raw:       resp.Raw,
+			RateLimit: resp.RateLimit,
+		}
+		if resp.Meta != nil {
+			page.NextToken = resp.Meta.NextToken
+		}
+		return page, nil
+	})
+}
+
+// UserListMembershipsPaginator will walk all of the pages of the user list memberships
+func (c *Client) UserListMembershipsPaginator(userID string, opts UserListMembershipsOpts, pagination PaginationOpts) *ListPaginator {
+	return newListPaginator(pagination, func(ctx context.Context, token string) (*ListPage, error) {
+		opts.PaginationToken = token
+		resp, err := c.UserListMemberships(ctx, userID, opts)
+		if err != nil {
+			return nil, err
+		}
+		page := &ListPage{
+			Raw:       (*UserListRaw)(resp.Raw),
+			RateLimit: resp.RateLimit,
+		}
+		if resp.Meta != nil {
+			page.NextToken = resp.Meta.NextToken
+		}
+		return page, nil
+	})
+}
+
+// UserFollowedListsPaginator will walk all of the pages of the user followed lists
+func (c *Client) UserFollowedListsPaginator(userID string, opts UserFollowedListsOpts, pagination PaginationOpts) *ListPaginator {
+	return newListPaginator(pagination, func(ctx context.Context, token string) (*ListPage, error) {
+		opts.PaginationToken = token
+		resp, err := c.UserFollowedLists(ctx, userID, opts)
+		if err != nil {
+			return nil, err
+		}
+		page := &ListPage{
+			Raw:       (*UserListRaw)(resp.Raw),
+			RateLimit: resp.RateLimit,
+		}
+		if resp.Meta != nil {
+			page.NextToken = resp.Meta.NextToken
+		}
+		return page, nil
+	})
+}
+
+// DMEventsPaginator will walk all of the pages of the direct message events
+func (c *Client) DMEventsPaginator(opts DMEventOpts, pagination PaginationOpts) *DMEventPaginator {
+	return newDMEventPaginator(pagination, func(ctx context.Context, token string) (*DMEventPage, error) {
+		opts.NextToken = token
+		resp, err := c.DMEvents(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+		page := &DMEventPage{
+			Events:    resp.Data,
+			Includes:  resp.Includes,
+			RateLimit: resp.RateLimit,
+		}
+		if resp.Meta != nil {
+			page.NextToken = resp.Meta.NextToken
+		}
+		return page, nil
+	})
+}
+
+// DMConversationEventsPaginator will walk all of the pages of the direct message conversation events
+func (c *Client) DMConversationEventsPaginator(conversationID string, opts DMEventOpts, pagination PaginationOpts) *DMEventPaginator {
+	return newDMEventPaginator(pagination, func(ctx context.Context, token string) (*DMEventPage, error) {
+		opts.NextToken = token
+		resp, err := c.DMConversationEvents(ctx, conversationID, opts)
+		if err != nil {
+			return nil, err
+		}
+		page := &DMEventPage{
+			Events:    resp.Data,
+			Includes:  resp.Includes,
+			RateLimit: resp.RateLimit,
+		}
+		if resp.Meta != nil {
+			page.NextToken = resp.Meta.NextToken
+		}
+		return page, nil
+	})
+}
+
+// DMConversationsPaginator will walk all of the pages of the direct message conversations
+func (c *Client) DMConversationsPaginator(opts DMConversationOpts, pagination PaginationOpts) *DMConversationPaginator {
+	return newDMConversationPaginator(pagination, func(ctx context.Context, token string) (*DMConversationPage, error) {
+		opts.NextToken = token
+		resp, err := c.DMConversations(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+		page := &DMConversationPage{
+			Conversations: resp.Data,
+			Includes:      resp.Includes,
+			RateLimit:     resp.RateLimit,
+		}
+		if resp.Meta != nil {
+			page.NextToken = resp.Meta.NextToken
+		}
+		return page, nil
+	})
+}
+
+// DMConversationsByParticipantPaginator will walk all of the pages of the direct message conversations with the
+// participant
+func (c *Client) DMConversationsByParticipantPaginator(participantID string, opts DMConversationOpts, pagination PaginationOpts) *DMConversationPaginator {
+	return newDMConversationPaginator(pagination, func(ctx context.Context, token string) (*DMConversationPage, error) {
+		opts.NextToken = token
+		resp, err := c.DMConversationsByParticipant(ctx, participantID, opts)
+		if err != nil {
+			return nil, err
+		}
+		page := &DMConversationPage{
+			Conversations: resp.Data,
+			Includes:      resp.Includes,
+			RateLimit:     resp.RateLimit,
+		}
+		if resp.Meta != nil {
+			page.NextToken = resp.Meta.NextToken
+		}
+		return page, nil
+	})
+}
@@ -0,0 +1,300 @@
+package twitter
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func paginatorTestClient(pages map[string]string) *Client {
+	return &Client{
+		Authorizer: &mockAuth{},
+		Host:       "https://www.test.com",
+		Client: mockHTTPClient(func(req *http.Request) *http.Response {
+			body, has := pages[req.URL.Query().Get("pagination_token")]
+			if !has {
+				return &http.Response{
+					StatusCode: http.StatusBadRequest,
+					Body:       io.NopCloser(strings.NewReader(`{"title":"Invalid Request","detail":"bad token"}`)),
+					Header:     http.Header{},
+				}
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(strings.NewReader(body)),
+				Header: func() http.Header {
+					h := http.Header{}
+					h.Add(rateLimit, "15")
+					h.Add(rateRemaining, "12")
+					h.Add(rateReset, "1644461060")
+					return h
+				}(),
+			}
+		}),
+	}
+}
+
+var paginatorTestPages = map[string]string{
+	"": `{
+		"data": [{"id":"1","text":"one","author_id":"10"},{"id":"2","text":"two","author_id":"11"}],
+		"includes": {"users":[{"id":"10","username":"ten"},{"id":"11","username":"eleven"}]},
+		"meta": {"result_count":2,"next_token":"page2"}
+	}`,
+	"page2": `{
+		"data": [{"id":"3","text":"three","author_id":"10"},{"id":"4","text":"four","author_id":"12"}],
+		"includes": {"users":[{"id":"10","username":"ten"},{"id":"12","username":"twelve"}]},
+		"meta": {"result_count":2,"next_token":"page3"}
+	}`,
+	"page3": `{
+		"data": [{"id":"5","text":"five","author_id":"10"}],
+		"meta": {"result_count":1}
+	}`,
+}
+
+func TestClient_UserTweetTimelinePaginator(t *testing.T) {
+	type args struct {
+		pagination PaginationOpts
+	}
+	tests := []struct {
+		name      string
+		pages     map[string]string
+		args      args
+		wantIDs   []string
+		wantUsers []string
+		wantToken string
+		wantPages int
+		wantErr   bool
+	}{
+		{
+			name:  "all pages",
+			pages: paginatorTestPages,
+			args: args{
+				pagination: PaginationOpts{},
+			},
+			wantIDs:   []string{"1", "2", "3", "4", "5"},
+			wantUsers: []string{"10", "11", "12"},
+			wantToken: "",
+			wantPages: 3,
+		},
+		{
+			name:  "max items",
+			pages: paginatorTestPages,
+			args: args{
+				pagination: PaginationOpts{
+					MaxItems: 3,
+				},
+			},
+			wantIDs:   []string{"1", "2", "3"},
+			wantUsers: []string{"10", "11", "12"},
+			wantToken: "page2",
+			wantPages: 2,
+		},
+		{
+			name:  "resume from token",
+			pages: paginatorTestPages,
+			args: args{
+				pagination: PaginationOpts{
+					Token: "page3",
+				},
+			},
+			wantIDs:   []string{"5"},
+			wantUsers: nil,
+			wantToken: "",
+			wantPages: 1,
+		},
+		{
+			name: "error stops the pages",
+			pages: map[string]string{
+				"": paginatorTestPages[""],
+			},
+			args: args{
+				pagination: PaginationOpts{},
+			},
+			wantIDs:   []string{"1", "2"},
+			wantUsers: []string{"10", "11"},
+			wantToken: "page2",
+			wantPages: 1,
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := paginatorTestClient(tt.pages)
+			p := c.UserTweetTimelinePaginator("2244994945", UserTweetTimelineOpts{}, tt.args.pagination)
+
+			var ids []string
+			for p.Next(context.Background()) {
+				if p.RateLimit() == nil || p.Page().RateLimit == nil {
+					t.Errorf("UserTweetTimelinePaginator() page rate limit missing")
+				}
+				for _, tweet := range p.Page().Raw.Tweets {
+					ids = append(ids, tweet.ID)
+				}
+			}
+			if (p.Err() != nil) != tt.wantErr {
+				t.Errorf("UserTweetTimelinePaginator() error = %v, wantErr %v", p.Err(), tt.wantErr)
+				return
+			}
+			var users []string
+			for _, user := range p.Includes().Users {
+				users = append(users, user.ID)
+			}
+			if !reflect.DeepEqual(ids, tt.wantIDs) {
+				t.Errorf("UserTweetTimelinePaginator() ids = %v, want %v", ids, tt.wantIDs)
+			}
+			if !reflect.DeepEqual(users, tt.wantUsers) {
+				t.Errorf("UserTweetTimelinePaginator() users = %v, want %v", users, tt.wantUsers)
+			}
+			if p.NextToken() != tt.wantToken {
+				t.Errorf("UserTweetTimelinePaginator() next token = %v, want %v", p.NextToken(), tt.wantToken)
+			}
+			if p.Pages() != tt.wantPages {
+				t.Errorf("UserTweetTimelinePaginator() pages = %v, want %v", p.Pages(), tt.wantPages)
+			}
+			if p.Items() != len(tt.wantIDs) {
+				t.Errorf("UserTweetTimelinePaginator() items = %v, want %v", p.Items(), len(tt.wantIDs))
+			}
+		})
+	}
+}
+
+func TestClient_UserTweetTimelinePaginator_Budget(t *testing.T) {
+	c := paginatorTestClient(paginatorTestPages)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p := c.UserTweetTimelinePaginator("2244994945", UserTweetTimelineOpts{}, PaginationOpts{})
+	if !p.Next(ctx) {
+		t.Fatalf("UserTweetTimelinePaginator() first page error = %v", p.Err())
+	}
+	cancel()
+	if p.Next(ctx) {
+		t.Errorf("UserTweetTimelinePaginator() next page after cancel")
+	}
+	if !errors.Is(p.Err(), context.Canceled) {
+		t.Errorf("UserTweetTimelinePaginator() error = %v, want %v", p.Err(), context.Canceled)
+	}
+
+	p = c.UserTweetTimelinePaginator("2244994945", UserTweetTimelineOpts{}, PaginationOpts{MaxDuration: time.Nanosecond})
+	if !p.Next(context.Background()) {
+		t.Fatalf("UserTweetTimelinePaginator() first page error = %v", p.Err())
+	}
+	time.Sleep(time.Millisecond)
+	if p.Next(context.Background()) {
+		t.Errorf("UserTweetTimelinePaginator() next page after the duration")
+	}
+	if p.Err() != nil {
+		t.Errorf("UserTweetTimelinePaginator() error = %v", p.Err())
+	}
+}
+
+func TestClient_UserTweetTimelinePaginator_Resume(t *testing.T) {
+	c := paginatorTestClient(paginatorTestPages)
+
+	pagination := PaginationOpts{MaxItems: 3}
+	ids := []string{}
+	for run := 0; run < 3; run++ {
+		p := c.UserTweetTimelinePaginator("2244994945", UserTweetTimelineOpts{}, pagination)
+		for p.Next(context.Background()) {
+			for _, tweet := range p.Page().Raw.Tweets {
+				ids = append(ids, tweet.ID)
+			}
+		}
+		if p.Err() != nil {
+			t.Fatalf("UserTweetTimelinePaginator() run %d error = %v", run, p.Err())
+		}
+		if run == 0 && (p.NextToken() != "page2" || p.Offset() != 1) {
+			t.Errorf("UserTweetTimelinePaginator() cut page token = %v, offset = %d", p.NextToken(), p.Offset())
+		}
+		pagination = PaginationOpts{
+			Token:    p.NextToken(),
+			Offset:   p.Offset(),
+			MaxItems: 1,
+		}
+		if len(pagination.Token) == 0 {
+			break
+		}
+	}
+	if want := []string{"1", "2", "3", "4", "5"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("UserTweetTimelinePaginator() resumed ids = %v, want %v", ids, want)
+	}
+}
+
+func TestClient_UserFollowersLookupPaginator(t *testing.T) {
+	c := paginatorTestClient(map[string]string{
+		"": `{
+			"data": [{"id":"1","username":"one","pinned_tweet_id":"100"}],
+			"includes": {"tweets":[{"id":"100","text":"pinned"}]},
+			"meta": {"result_count":1,"next_token":"next"}
+		}`,
+		"next": `{
+			"data": [{"id":"2","username":"two","pinned_tweet_id":"100"}],
+			"includes": {"tweets":[{"id":"100","text":"pinned"}]},
+			"meta": {"result_count":1}
+		}`,
+	})
+	p := c.UserFollowersLookupPaginator("2244994945", UserFollowersLookupOpts{}, PaginationOpts{})
+
+	var ids []string
+	for p.Next(context.Background()) {
+		for _, user := range p.Page().Raw.Users {
+			ids = append(ids, user.ID)
+		}
+	}
+	if p.Err() != nil {
+		t.Fatalf("UserFollowersLookupPaginator() error = %v", p.Err())
+	}
+	if want := []string{"1", "2"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("UserFollowersLookupPaginator() ids = %v, want %v", ids, want)
+	}
+	if len(p.Includes().Tweets) != 1 {
+		t.Errorf("UserFollowersLookupPaginator() includes tweets = %d, want 1", len(p.Includes().Tweets))
+	}
+}
+
+func TestClient_DMConversationsPaginator(t *testing.T) {
+	pages := map[string]string{
+		"": `{
+			"data": [{"id":"1","participant_ids":["10","11"]}],
+			"includes": {"users":[{"id":"10","username":"ten"},{"id":"11","username":"eleven"}]},
+			"meta": {"result_count":1,"next_token":"next"}
+		}`,
+		"next": `{
+			"data": [{"id":"2","participant_ids":["10","12"]}],
+			"includes": {"users":[{"id":"10","username":"ten"},{"id":"12","username":"twelve"}]},
+			"meta": {"result_count":1}
+		}`,
+	}
+	c := &Client{
+		Authorizer: &mockAuth{},
+		Host:       "https://www.test.com",
+		Client: mockHTTPClient(func(req *http.Request) *http.Response {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(strings.NewReader(pages[req.URL.Query().Get("next_token")])),
+				Header:     http.Header{},
+			}
+		}),
+	}
+	p := c.DMConversationsPaginator(DMConversationOpts{}, PaginationOpts{})
+
+	var ids []string
+	for p.Next(context.Background()) {
+		for _, conversation := range p.Page().Conversations {
+			ids = append(ids, conversation.ID)
+		}
+	}
+	if p.Err() != nil {
+		t.Fatalf("DMConversationsPaginator() error = %v", p.Err())
+	}
+	if want := []string{"1", "2"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("DMConversationsPaginator() ids = %v, want %v", ids, want)
+	}
+	if len(p.Includes().Users) != 3 {
+		t.Errorf("DMConversationsPaginator() includes users = %d, want 3", len(p.Includes().Users))
+	}
+}
@@ -0,0 +1,215 @@
+package twitter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	complianceStreamMaxPartition = 4
+)
+
+// ComplianceEventType is the type of a compliance stream event
+type ComplianceEventType string
+
+const (
+	// ComplianceDelete is a deleted tweet
+	ComplianceDelete ComplianceEventType = "delete"
+	// ComplianceWithheld is a tweet withheld in countries
+	ComplianceWithheld ComplianceEventType = "withheld"
+	// ComplianceDrop is a tweet that is no longer available, like the tweets of a deleted user
+	ComplianceDrop ComplianceEventType = "drop"
+	// ComplianceUndrop is a tweet that is available again
+	ComplianceUndrop ComplianceEventType = "undrop"
+	// ComplianceScrubGeo is the geo of the user tweets up to a tweet that has been removed
+	ComplianceScrubGeo ComplianceEventType = "scrub_geo"
+	// ComplianceUserDelete is a deleted user
+	ComplianceUserDelete ComplianceEventType = "user_delete"
+	// ComplianceUserUndelete is a deleted user that has been restored
+	ComplianceUserUndelete ComplianceEventType = "user_undelete"
+	// ComplianceUserWithheld is a user withheld in countries
+	ComplianceUserWithheld ComplianceEventType = "user_withheld"
+	// ComplianceUserSuspend is a suspended user
+	ComplianceUserSuspend ComplianceEventType = "user_suspend"
+	// ComplianceUserUnsuspend is a suspended user that has been restored
+	ComplianceUserUnsuspend ComplianceEventType = "user_unsuspend"
+	// ComplianceUserProtect is a user that has protected their tweets
+	ComplianceUserProtect ComplianceEventType = "user_protect"
+	// ComplianceUserUnprotect is a user that has made their tweets public
+	ComplianceUserUnprotect ComplianceEventType = "user_unprotect"
+	// ComplianceUserDeactivate is a user that has deactivated their account
+	ComplianceUserDeactivate ComplianceEventType = "user_deactivate"
+	// ComplianceUserReactivate is a deactivated user that has reactivated their account
+	ComplianceUserReactivate ComplianceEventType = "user_reactivate"
+	// ComplianceUserProfileModification is a user that has changed their profile
+	ComplianceUserProfileModification ComplianceEventType = "user_profile_modification"
+)
+
+var complianceEventTypes = map[ComplianceEventType]bool{
+	ComplianceDelete:                  true,
+	ComplianceWithheld:                true,
+	ComplianceDrop:                    true,
+	ComplianceUndrop:                  true,
+	ComplianceScrubGeo:                true,
+	ComplianceUserDelete:              true,
+	ComplianceUserUndelete:            true,
+	ComplianceUserWithheld:            true,
+	ComplianceUserSuspend:             true,
+	ComplianceUserUnsuspend:           true,
+	ComplianceUserProtect:             true,
+	ComplianceUserUnprotect:           true,
+	ComplianceUserDeactivate:          true,
+	ComplianceUserReactivate:          true,
+	ComplianceUserProfileModification: true,
+}
+
+// ComplianceEventTweet is the tweet of a compliance event
+type ComplianceEventTweet struct {
+	ID       string `json:"id"`
+	AuthorID string `json:"author_id"`
+}
+
+// ComplianceEventUser is the user of a compliance event
+type ComplianceEventUser struct {
+	ID string `json:"id"`
+}
+
+// ComplianceEvent is an event of the tweet or user compliance streams
+type ComplianceEvent struct {
+	Type ComplianceEventType `json:"type"`
+	// Tweet is the tweet of the tweet events
+	Tweet *ComplianceEventTweet `json:"tweet,omitempty"`
+	// User is the user of the user events and scrub geo
+	User                *ComplianceEventUser `json:"user,omitempty"`
+	EventAt             string               `json:"event_at"`
+	WithheldInCountries []string             `json:"withheld_in_countries,omitempty"`
+	// UpToTweetID is the newest tweet of a scrub geo
+	UpToTweetID string `json:"up_to_tweet_id,omitempty"`
+}
+
+// decodeComplianceEvent will decode the data of a compliance stream message, nil if it is not a compliance event
+func decodeComplianceEvent(reader *bytes.Reader) *ComplianceEvent {
+	if _, err := reader.Seek(0, io.SeekStart); err != nil {
+		return nil
+	}
+	msg := struct {
+		Data map[ComplianceEventType]*ComplianceEvent `json:"data"`
+	}{}
+	if err := json.NewDecoder(reader).Decode(&msg); err != nil || len(msg.Data) != 1 {
+		return nil
+	}
+	for eventType, event := range msg.Data {
+		if !complianceEventTypes[eventType] || event == nil {
+			return nil
+		}
+		event.Type = eventType
+		return event
+	}
+	return nil
+}
+
+// ComplianceHandler is implemented by a stream handler that handles the compliance events, RunStream skips the
+// events when the handler does not implement it
+type ComplianceHandler interface {
+	// OnCompliance is called with the events of the compliance streams
+	OnCompliance(ctx context.Context, event *ComplianceEvent) error
+}
+
+// MatchComplianceEvents will match the compliance stream events of any of the types, or all of the events when no
+// types are given
+func MatchComplianceEvents(types ...ComplianceEventType) StreamFilter {
+	wanted := map[ComplianceEventType]bool{}
+	for _, eventType := range types {
+		wanted[eventType] = true
+	}
+	return func(msg *TweetMessage) bool {
+		if msg == nil || msg.Compliance == nil {
+			return false
+		}
+		return len(wanted) == 0 || wanted[msg.Compliance.Type]
+	}
+}
+
+// ComplianceStreamOpts are the options of the tweet and user compliance streams
+type ComplianceStreamOpts struct {
+	// Partition is the partition of the stream, from 1 to 4, and is required
+	Partition       int
+	BackfillMinutes int
+	StartTime       time.Time
+	EndTime         time.Time
+}
+
+func (c ComplianceStreamOpts) validate(name string) error {
+	switch {
+	case c.Partition < 1 || c.Partition > complianceStreamMaxPartition:
+		return fmt.Errorf("%s: partition [%d] is not between 1 and %d: %w", name, c.Partition, complianceStreamMaxPartition, ErrParameter)
+	case c.BackfillMinutes < 0 || c.BackfillMinutes > sampleStreamMaxBackOffMin:
+		return fmt.Errorf("%s: a max back off minutes [%d] is [current: %d]: %w", name, sampleStreamMaxBackOffMin, c.BackfillMinutes, ErrParameter)
+	default:
+		return nil
+	}
+}
+
+func (c ComplianceStreamOpts) addQuery(req *http.Request) {
+	q := req.URL.Query()
+	q.Add("partition", strconv.Itoa(c.Partition))
+	if c.BackfillMinutes > 0 {
+		q.Add("backfill_minutes", strconv.Itoa(c.BackfillMinutes))
+	}
+	if !c.StartTime.IsZero() {
+		q.Add("start_time", c.StartTime.Format(time.RFC3339))
+	}
+	if !c.EndTime.IsZero() {
+		q.Add("end_time", c.EndTime.Format(time.RFC3339))
+	}
+	req.URL.RawQuery = q.Encode()
+}
+
+// TweetComplianceStream will stream the compliance events of the tweets for a partition.  The events are delivered
+// to the tweets channel with the message Compliance set.
+func (c *Client) TweetComplianceStream(ctx context.Context, opts ComplianceStreamOpts) (*TweetStream, error) {
+	return c.partitionStream(ctx, func(ctx context.Context) (io.ReadCloser, *RateLimit, error) {
+		return c.complianceStreamConnect(ctx, "tweet compliance stream", "TweetComplianceStream", tweetComplianceStreamEndpoint, opts)
+	})
+}
+
+// UserComplianceStream will stream the compliance events of the users for a partition.  The events are delivered
+// to the tweets channel with the message Compliance set.
+func (c *Client) UserComplianceStream(ctx context.Context, opts ComplianceStreamOpts) (*TweetStream, error) {
+	return c.partitionStream(ctx, func(ctx context.Context) (io.ReadCloser, *RateLimit, error) {
+		return c.complianceStreamConnect(ctx, "user compliance stream", "UserComplianceStream", userComplianceStreamEndpoint, opts)
+	})
+}
+
+// ManagedTweetComplianceStream will start a tweet compliance stream that reconnects, the first connection is made
+// before returning
+func (c *Client) ManagedTweetComplianceStream(ctx context.Context, opts ComplianceStreamOpts, managed ManagedStreamOpts) (*ManagedTweetStream, error) {
+	return startManagedTweetStream(ctx, opts.BackfillMinutes, managed, func(ctx context.Context, backfillMinutes int) (*TweetStream, error) {
+		connectOpts := opts
+		connectOpts.BackfillMinutes = backfillMinutes
+		return c.TweetComplianceStream(ctx, connectOpts)
+	})
+}
+
+// ManagedUserComplianceStream will start a user compliance stream that reconnects, the first connection is made
+// before returning
+func (c *Client) ManagedUserComplianceStream(ctx context.Context, opts ComplianceStreamOpts, managed ManagedStreamOpts) (*ManagedTweetStream, error) {
+	return startManagedTweetStream(ctx, opts.BackfillMinutes, managed, func(ctx context.Context, backfillMinutes int) (*TweetStream, error) {
+		connectOpts := opts
+		connectOpts.BackfillMinutes = backfillMinutes
+		return c.UserComplianceStream(ctx, connectOpts)
+	})
+}
+
+func (c *Client) complianceStreamConnect(ctx context.Context, name, op string, ep endpoint, opts ComplianceStreamOpts) (io.ReadCloser, *RateLimit, error) {
+	if err := opts.validate(name); err != nil {
+		return nil, nil, err
+	}
+	return c.streamConnect(ctx, name, op, ep, opts.addQuery)
+}
@@ -0,0 +1,174 @@
+package twitter
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func complianceStreamClient(t *testing.T, path string, body string, queries *[]string) *Client {
+	return &Client{
+		Authorizer: &mockAuth{},
+		Host:       "https://www.test.com",
+		Client: mockHTTPClient(func(req *http.Request) *http.Response {
+			if req.URL.Path != path {
+				t.Errorf("compliance stream path = %v, want %v", req.URL.Path, path)
+			}
+			*queries = append(*queries, req.URL.RawQuery)
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(strings.NewReader(body)),
+				Header:     http.Header{},
+				Request:    req,
+			}
+		}),
+	}
+}
+
+func complianceStreamEvents(t *testing.T, stream *TweetStream) []*ComplianceEvent {
+	events := []*ComplianceEvent{}
+	msgs, _, _, _ := tweetStreamReceive(t, stream)
+	for _, msg := range msgs {
+		events = append(events, msg.Compliance)
+	}
+	return events
+}
+
+func TestClient_TweetComplianceStream(t *testing.T) {
+	body := `{"data":{"delete":{"tweet":{"id":"1","author_id":"10"},"event_at":"2021-07-06T18:40:40.000Z"}}}` + "\r\n"
+	body += "\r\n"
+	body += `{"data":{"withheld":{"tweet":{"id":"2","author_id":"10"},"withheld_in_countries":["DE","FR"],"event_at":"2021-07-06T18:40:41.000Z"}}}` + "\r\n"
+	body += `{"data":{"scrub_geo":{"user":{"id":"10"},"up_to_tweet_id":"3","event_at":"2021-07-06T18:40:42.000Z"}}}` + "\r\n"
+
+	queries := []string{}
+	c := complianceStreamClient(t, "/2/tweets/compliance/stream", body, &queries)
+	if _, err := c.TweetComplianceStream(context.Background(), ComplianceStreamOpts{Partition: 5}); !errors.Is(err, ErrParameter) {
+		t.Errorf("TweetComplianceStream() partition error = %v", err)
+	}
+	stream, err := c.TweetComplianceStream(context.Background(), ComplianceStreamOpts{
+		Partition:       2,
+		BackfillMinutes: 3,
+	})
+	if err != nil {
+		t.Fatalf("TweetComplianceStream() error = %v", err)
+	}
+	want := []*ComplianceEvent{
+		{
+			Type:    ComplianceDelete,
+			Tweet:   &ComplianceEventTweet{ID: "1", AuthorID: "10"},
+			EventAt: "2021-07-06T18:40:40.000Z",
+		},
+		{
+			Type:                ComplianceWithheld,
+			Tweet:               &ComplianceEventTweet{ID: "2", AuthorID: "10"},
+			WithheldInCountries: []string{"DE", "FR"},
+			EventAt:             "2021-07-06T18:40:41.000Z",
+		},
+		{
+			Type:        ComplianceScrubGeo,
+			User:        &ComplianceEventUser{ID: "10"},
+			UpToTweetID: "3",
+			EventAt:     "2021-07-06T18:40:42.000Z",
+		},
+	}
+	if got := complianceStreamEvents(t, stream); !reflect.DeepEqual(got, want) {
+		t.Errorf("TweetComplianceStream() = %v, want %v", got, want)
+	}
+	if !reflect.DeepEqual(queries, []string{"backfill_minutes=3&partition=2"}) {
+		t.Errorf("TweetComplianceStream() queries = %v", queries)
+	}
+}
+
+func TestClient_ManagedUserComplianceStream(t *testing.T) {
+	body := `{"data":{"user_suspend":{"user":{"id":"10"},"event_at":"2021-07-06T18:40:40.000Z"}}}` + "\r\n"
+	body += `{"data":{"user_protect":{"user":{"id":"11"},"event_at":"2021-07-06T18:40:41.000Z"}}}` + "\r\n"
+	body += `{"data":{"user_deactivate":{"user":{"id":"12"},"event_at":"2021-07-06T18:40:42.000Z"}}}` + "\r\n"
+
+	queries := []string{}
+	c := complianceStreamClient(t, "/2/users/compliance/stream", body, &queries)
+	stream, err := c.ManagedUserComplianceStream(context.Background(), ComplianceStreamOpts{Partition: 1}, ManagedStreamOpts{})
+	if err != nil {
+		t.Fatalf("ManagedUserComplianceStream() error = %v", err)
+	}
+	defer stream.Close()
+
+	got := []string{}
+	for len(got) < 3 {
+		msg := <-stream.Tweets()
+		got = append(got, string(msg.Compliance.Type)+" "+msg.Compliance.User.ID)
+	}
+	if want := []string{"user_suspend 10", "user_protect 11", "user_deactivate 12"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ManagedUserComplianceStream() = %v, want %v", got, want)
+	}
+	if queries[0] != "partition=1" {
+		t.Errorf("ManagedUserComplianceStream() query = %v", queries[0])
+	}
+}
+
+func TestMatchComplianceEvents(t *testing.T) {
+	source := newMockTweetStreamSource()
+	hub := NewStreamHub(source)
+
+	opts := StreamSubscriptionOpts{BufferSize: 10}
+	deletes, err := hub.Subscribe(MatchComplianceEvents(ComplianceDelete), opts)
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	events, err := hub.Subscribe(MatchComplianceEvents(), opts)
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+
+	source.tweets <- &TweetMessage{Compliance: &ComplianceEvent{Type: ComplianceDelete, Tweet: &ComplianceEventTweet{ID: "1"}}}
+	source.tweets <- streamHubTweet("2")
+	source.tweets <- &TweetMessage{Compliance: &ComplianceEvent{Type: ComplianceScrubGeo, User: &ComplianceEventUser{ID: "10"}}}
+	hub.Close()
+
+	receive := func(sub *StreamSubscription) []ComplianceEventType {
+		types := []ComplianceEventType{}
+		for msg := range sub.Tweets() {
+			types = append(types, msg.Compliance.Type)
+		}
+		return types
+	}
+	if got, want := receive(deletes), []ComplianceEventType{ComplianceDelete}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Tweets() deletes = %v, want %v", got, want)
+	}
+	if got, want := receive(events), []ComplianceEventType{ComplianceDelete, ComplianceScrubGeo}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Tweets() events = %v, want %v", got, want)
+	}
+}
+
+func TestRunStream_Compliance(t *testing.T) {
+	body := `{"data":{"delete":{"tweet":{"id":"1","author_id":"10"},"event_at":"2021-07-06T18:40:40.000Z"}}}` + "\r\n"
+	body += `{"data":{"withheld":{"tweet":{"id":"2","author_id":"10"},"withheld_in_countries":["DE"],"event_at":"2021-07-06T18:40:41.000Z"}}}` + "\r\n"
+	body += `{"data":{"scrub_geo":{"user":{"id":"10"},"up_to_tweet_id":"3","event_at":"2021-07-06T18:40:42.000Z"}}}` + "\r\n"
+
+	queries := []string{}
+	c := complianceStreamClient(t, "/2/tweets/compliance/stream", body, &queries)
+	stream, err := c.TweetComplianceStream(context.Background(), ComplianceStreamOpts{Partition: 1})
+	if err != nil {
+		t.Fatalf("TweetComplianceStream() error = %v", err)
+	}
+	events := []ComplianceEventType{}
+	err = stream.Run(context.Background(), StreamHandlerFuncs{
+		Tweet: func(ctx context.Context, tweet *TweetDictionary, msg *TweetMessage) error {
+			t.Errorf("Run() compliance event delivered as a tweet %v", msg)
+			return nil
+		},
+		Compliance: func(ctx context.Context, event *ComplianceEvent) error {
+			events = append(events, event.Type)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if want := []ComplianceEventType{ComplianceDelete, ComplianceWithheld, ComplianceScrubGeo}; !reflect.DeepEqual(events, want) {
+		t.Errorf("Run() compliance events = %v, want %v", events, want)
+	}
+}
@@ -22,7 +22,6 @@ func (c *Client) DMEvents(ctx context.Context, opts DMEventOpts) (*DMEventRespon
 	}
 	
 	req.Header.Set("Accept", "application/json")
-	c.Authorizer.Add(req)
 	
 	u, err := url.Parse(req.URL.String())
 	if err != nil {
@@ -31,7 +30,7 @@ func (c *Client) DMEvents(ctx context.Context, opts DMEventOpts) (*DMEventRespon
 	opts.addQuery(u)
 	req.URL = u
 	
-	resp, err := c.Client.Do(req)
+	resp, err := c.do(req, "DMEvents", dmEventsEndpoint)
 	if err != nil {
 		return nil, fmt.Errorf("dm events response: %w", err)
 	}
@@ -77,7 +76,6 @@ func (c *Client) DMConversationEvents(ctx context.Context, conversationID string
 	}
 	
 	req.Header.Set("Accept", "application/json")
-	c.Authorizer.Add(req)
 	
 	u, err := url.Parse(req.URL.String())
 	if err != nil {
@@ -86,7 +84,7 @@ func (c *Client) DMConversationEvents(ctx context.Context, conversationID string
 	opts.addQuery(u)
 	req.URL = u
 	
-	resp, err := c.Client.Do(req)
+	resp, err := c.do(req, "DMConversationEvents", dmConversationEventsEndpoint)
 	if err != nil {
 		return nil, fmt.Errorf("dm conversation events response: %w", err)
 	}
@@ -132,7 +130,6 @@ func (c *Client) DMConversations(ctx context.Context, opts DMConversationOpts) (
 	}
 	
 	req.Header.Set("Accept", "application/json")
-	c.Authorizer.Add(req)
 	
 	u, err := url.Parse(req.URL.String())
 	if err != nil {
@@ -141,7 +138,7 @@ func (c *Client) DMConversations(ctx context.Context, opts DMConversationOpts) (
 	opts.addQuery(u)
 	req.URL = u
 	
-	resp, err := c.Client.Do(req)
+	resp, err := c.do(req, "DMConversations", dmConversationsEndpoint)
 	if err != nil {
 		return nil, fmt.Errorf("dm conversations response: %w", err)
 	}
@@ -187,7 +184,6 @@ func (c *Client) DMConversationsByParticipant(ctx context.Context, participantID
 	}
 	
 	req.Header.Set("Accept", "application/json")
-	c.Authorizer.Add(req)
 	
 	u, err := url.Parse(req.URL.String())
 	if err != nil {
@@ -196,7 +192,7 @@ func (c *Client) DMConversationsByParticipant(ctx context.Context, participantID
 	opts.addQuery(u)
 	req.URL = u
 	
-	resp, err := c.Client.Do(req)
+	resp, err := c.do(req, "DMConversationsByParticipant", dmConversationsByParticipantEndpoint)
 	if err != nil {
 		return nil, fmt.Errorf("dm conversations by participant response: %w", err)
 	}
@@ -248,9 +244,8 @@ func (c *Client) CreateDMConversation(ctx context.Context, req CreateDMConversat
 	
 	httpReq.Header.Set("Content-Type", "application/json")
 	httpReq.Header.Set("Accept", "application/json")
-	c.Authorizer.Add(httpReq)
 	
-	resp, err := c.Client.Do(httpReq)
+	resp, err := c.do(httpReq, "CreateDMConversation", dmConversationCreateEndpoint)
 	if err != nil {
 		return nil, fmt.Errorf("create dm conversation response: %w", err)
 	}
@@ -302,9 +297,8 @@ func (c *Client) SendDM(ctx context.Context, conversationID string, req SendDMRe
 	
 	httpReq.Header.Set("Content-Type", "application/json")
 	httpReq.Header.Set("Accept", "application/json")
-	c.Authorizer.Add(httpReq)
 	
-	resp, err := c.Client.Do(httpReq)
+	resp, err := c.do(httpReq, "SendDM", dmMessageByConversationEndpoint)
 	if err != nil {
 		return nil, fmt.Errorf("send dm response: %w", err)
 	}
@@ -356,9 +350,8 @@ func (c *Client) SendDMByParticipantID(ctx context.Context, participantID string
 	
 	httpReq.Header.Set("Content-Type", "application/json")
 	httpReq.Header.Set("Accept", "application/json")
-	c.Authorizer.Add(httpReq)
 	
-	resp, err := c.Client.Do(httpReq)
+	resp, err := c.do(httpReq, "SendDMByParticipantID", dmMessageByParticipantEndpoint)
 	if err != nil {
 		return nil, fmt.Errorf("send dm by participant response: %w", err)
 	}
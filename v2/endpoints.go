@@ -34,6 +34,10 @@ const (
 	tweetSampleStreamEndpoint                     endpoint = "2/tweets/sample/stream"
 	tweetSearchStreamRulesEndpoint                endpoint = "2/tweets/search/stream/rules"
 	tweetSearchStreamEndpoint                     endpoint = "2/tweets/search/stream"
+	tweetFirehoseStreamEndpoint                   endpoint = "2/tweets/firehose/stream"
+	tweetSample10StreamEndpoint                   endpoint = "2/tweets/sample10/stream"
+	tweetComplianceStreamEndpoint                 endpoint = "2/tweets/compliance/stream"
+	userComplianceStreamEndpoint                  endpoint = "2/users/compliance/stream"
 	listLookupEndpoint                            endpoint = "2/lists/{id}"
 	userListLookupEndpoint                        endpoint = "2/users/{id}/owned_lists"
 	listTweetLookupEndpoint                       endpoint = "2/lists/{id}/tweets"
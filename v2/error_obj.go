@@ -1,6 +1,9 @@
 package twitter
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+)
 
 // ResponseDecodeError is an error when a response has a decoding error, JSON.
 type ResponseDecodeError struct {
@@ -59,3 +62,86 @@ type ErrorResponse struct {
 func (e ErrorResponse) Error() string {
 	return fmt.Sprintf("twitter callout status %d %s:%s", e.StatusCode, e.Title, e.Detail)
 }
+
+// RateLimitError is returned by the rate limiter when a callout would exceed the remaining rate limit
+type RateLimitError struct {
+	Method    string
+	Endpoint  string
+	RateLimit *RateLimit
+}
+
+func (r *RateLimitError) Error() string {
+	return fmt.Sprintf("twitter rate limit [%s %s] exhausted until %v", r.Method, r.Endpoint, r.RateLimit.Reset.Time())
+}
+
+// RetryError is returned when a callout has been retried, it contains the number of attempts and the final cause
+type RetryError struct {
+	Attempts int
+	Err      error
+}
+
+func (r *RetryError) Error() string {
+	return fmt.Sprintf("twitter callout failed after %d attempts: %v", r.Attempts, r.Err)
+}
+
+// Unwrap will return the final cause
+func (r *RetryError) Unwrap() error {
+	return r.Err
+}
+
+// OAuth2Error is returned when an OAuth2 endpoint responds with an error
+type OAuth2Error struct {
+	StatusCode  int    `json:"-"`
+	Code        string `json:"error"`
+	Description string `json:"error_description"`
+}
+
+func (o *OAuth2Error) Error() string {
+	return fmt.Sprintf("twitter oauth2 status %d %s:%s", o.StatusCode, o.Code, o.Description)
+}
+
+// MissingScopeError is returned before a callout when the granted scopes do not include the scopes the method requires
+type MissingScopeError struct {
+	Method  string
+	Missing []string
+	Granted []string
+}
+
+func (m *MissingScopeError) Error() string {
+	return fmt.Sprintf("twitter %s is missing the scopes %s", m.Method, ScopeString(m.Missing))
+}
+
+// PartialError is returned when a callout has succeeded but some of its parts have failed, it contains the partial
+// errors of the response
+type PartialError struct {
+	Name   string
+	Errors []*ErrorObj
+}
+
+func (p *PartialError) Error() string {
+	if len(p.Errors) == 0 {
+		return fmt.Sprintf("twitter %s partial errors", p.Name)
+	}
+	return fmt.Sprintf("twitter %s has %d partial errors, first %s:%s", p.Name, len(p.Errors), p.Errors[0].Title, p.Errors[0].Detail)
+}
+
+// StreamGapError is sent to the errors of a resumed stream when the tweets since its checkpoint can not all be
+// recovered, the tweets between From and To are lost.  Err is the search error that stopped the recovery, if any.
+type StreamGapError struct {
+	From time.Time
+	To   time.Time
+	Err  error
+}
+
+func (s *StreamGapError) Error() string {
+	msg := fmt.Sprintf("twitter stream tweets from %s to %s are lost", s.From.Format(time.RFC3339), s.To.Format(time.RFC3339))
+	if s.Err != nil {
+		return msg + ": " + s.Err.Error()
+	}
+	return msg
+}
+
+// Unwrap returns the search error that stopped the recovery
+func (s *StreamGapError) Unwrap() error {
+	return s.Err
+}
@@ -4,3 +4,15 @@ import "errors"
 
 // ErrParameter will indicate that the error is from an invalid input parameter
 var ErrParameter = errors.New("twitter input parameter error")
+
+// ErrOAuth2State will indicate that the state of the OAuth2 callback does not match the authorize request
+var ErrOAuth2State = errors.New("twitter oauth2 state mismatch")
+
+// ErrStreamStall will indicate that the stream has not received a message or keep alive within the keep alive timeout
+var ErrStreamStall = errors.New("twitter stream stalled")
+
+// ErrStreamClosed will indicate that the stream has been closed
+var ErrStreamClosed = errors.New("twitter stream closed")
+
+// ErrStopStream can be returned by a stream handler to stop the stream without an error
+var ErrStopStream = errors.New("twitter stream stop")
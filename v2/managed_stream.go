@@ -0,0 +1,490 @@
+package twitter
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	managedStreamDedupSize = 10000
+)
+
+type streamBackoffClass int
+
+const (
+	streamBackoffNetwork streamBackoffClass = iota
+	streamBackoffHTTP
+	streamBackoffRateLimit
+)
+
+// streamBackoff is a reconnect schedule from the twitter streaming documentation
+type streamBackoff struct {
+	base        time.Duration
+	max         time.Duration
+	exponential bool
+}
+
+// streamBackoffs are linear for network errors and stalls, exponential for HTTP errors and exponential
+// from a minute for rate limits
+var streamBackoffs = map[streamBackoffClass]streamBackoff{
+	streamBackoffNetwork: {
+		base: 250 * time.Millisecond,
+		max:  16 * time.Second,
+	},
+	streamBackoffHTTP: {
+		base:        5 * time.Second,
+		max:         320 * time.Second,
+		exponential: true,
+	},
+	streamBackoffRateLimit: {
+		base:        time.Minute,
+		max:         16 * time.Minute,
+		exponential: true,
+	},
+}
+
+func (b streamBackoff) delay(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	// the base is compared to the max divided by the attempts, multiplying the base can overflow
+	if b.exponential {
+		if shift := attempt - 1; shift < 32 && b.base <= b.max>>shift {
+			return b.base << shift
+		}
+		return b.max
+	}
+	if b.base <= b.max/time.Duration(attempt) {
+		return b.base * time.Duration(attempt)
+	}
+	return b.max
+}
+
+// streamErrorClass returns the back off class of a connect error and if the stream should reconnect
+func streamErrorClass(err error) (streamBackoffClass, bool) {
+	if errors.Is(err, ErrParameter) || errors.Is(err, ErrStreamClosed) || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return streamBackoffNetwork, false
+	}
+	status := 0
+	errResp := &ErrorResponse{}
+	httpErr := &HTTPError{}
+	rlErr := &RateLimitError{}
+	switch {
+	case errors.As(err, &rlErr):
+		return streamBackoffRateLimit, true
+	case errors.As(err, &errResp):
+		status = errResp.StatusCode
+	case errors.As(err, &httpErr):
+		status = httpErr.StatusCode
+	default:
+		return streamBackoffNetwork, true
+	}
+	switch {
+	case status == http.StatusTooManyRequests || status == 420:
+		return streamBackoffRateLimit, true
+	case status >= http.StatusInternalServerError:
+		return streamBackoffHTTP, true
+	default:
+		return streamBackoffHTTP, false
+	}
+}
+
+// ManagedStreamOpts are the options of the managed stream reconnects
+type ManagedStreamOpts struct {
+	// MaxAttempts is the number of failed reconnects in a row before giving up, zero will never give up
+	MaxAttempts int
+	// DisableBackfill will not request the backfill minutes covering the gap when reconnecting
+	DisableBackfill bool
+	// DedupSize is the number of recent tweet ids used to drop the duplicates of a backfill, defaults to 10000
+	DedupSize int
+	// Notify is optional and called before each reconnect
+	Notify RetryNotify
+}
+
+// ManagedTweetStream is a tweet stream that will reconnect when the connection ends, stalls or is disconnected.
+//
+// The reconnects back off with the schedule of the error class, linear for network errors and exponential
+// for HTTP errors and rate limits.  The back off is only reset once a connection has received a message, so
+// a connection that ends right away keeps backing off in the class of the last error.  When reconnecting,
+// the backfill minutes covering the gap are requested and the duplicate tweets of the backfill are dropped.
+// The errors are buffered and dropped when the buffer is full, so the stream keeps running when only the
+// tweets are read, and the final error of a stream that gives up is always kept.  The channels are closed
+// when the managed stream is closed, the context is done or the stream gives up.
+type ManagedTweetStream struct {
+	tweets        chan *TweetMessage
+	system        chan map[SystemMessageType]SystemMessage
+	disconnection chan *DisconnectionError
+	err           chan error
+	connect       func(ctx context.Context, backfillMinutes int) (*TweetStream, error)
+	opts          ManagedStreamOpts
+	seen          *tweetIDSet
+	cancel        context.CancelFunc
+	done          chan struct{}
+	closeOnce     sync.Once
+	current       *TweetStream
+	dropped       StreamDrops
+	mutex         sync.Mutex
+}
+
+// ManagedTweetSearchStream will start a filtered stream that reconnects, the first connection is made before returning
+func (c *Client) ManagedTweetSearchStream(ctx context.Context, opts TweetSearchStreamOpts, managed ManagedStreamOpts) (*ManagedTweetStream, error) {
+	return startManagedTweetStream(ctx, opts.BackfillMinutes, managed, func(ctx context.Context, backfillMinutes int) (*TweetStream, error) {
+		connectOpts := opts
+		connectOpts.BackfillMinutes = backfillMinutes
+		return c.TweetSearchStream(ctx, connectOpts)
+	})
+}
+
+// ManagedTweetSampleStream will start a sample stream that reconnects, the first connection is made before returning
+func (c *Client) ManagedTweetSampleStream(ctx context.Context, opts TweetSampleStreamOpts, managed ManagedStreamOpts) (*ManagedTweetStream, error) {
+	return startManagedTweetStream(ctx, opts.BackfillMinutes, managed, func(ctx context.Context, backfillMinutes int) (*TweetStream, error) {
+		connectOpts := opts
+		connectOpts.BackfillMinutes = backfillMinutes
+		return c.TweetSampleStream(ctx, connectOpts)
+	})
+}
+
+func startManagedTweetStream(ctx context.Context, backfillMinutes int, opts ManagedStreamOpts, connect func(context.Context, int) (*TweetStream, error)) (*ManagedTweetStream, error) {
+	dedupSize := opts.DedupSize
+	if dedupSize <= 0 {
+		dedupSize = managedStreamDedupSize
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	connCtx, connCancel := context.WithCancel(ctx)
+	stream, err := connect(connCtx, backfillMinutes)
+	if err != nil {
+		connCancel()
+		cancel()
+		return nil, err
+	}
+
+	// the channels are buffered the same as the connections, which have the buffer size of the client stream options
+	size := cap(stream.tweets)
+	m := &ManagedTweetStream{
+		tweets:        make(chan *TweetMessage, size),
+		system:        make(chan map[SystemMessageType]SystemMessage, size),
+		disconnection: make(chan *DisconnectionError, size),
+		err:           make(chan error, size),
+		connect:       connect,
+		opts:          opts,
+		seen:          newTweetIDSet(dedupSize),
+		cancel:        cancel,
+		done:          make(chan struct{}),
+	}
+
+	go m.run(ctx, stream, connCancel)
+
+	return m, nil
+}
+
+func (m *ManagedTweetStream) run(ctx context.Context, stream *TweetStream, connCancel context.CancelFunc) {
+	defer close(m.done)
+	defer close(m.err)
+	defer close(m.disconnection)
+	defer close(m.system)
+	defer close(m.tweets)
+
+	attempts := map[streamBackoffClass]int{}
+	failures := 0
+	class := streamBackoffNetwork
+	for {
+		started := m.connected(stream)
+		cause := m.forward(ctx, stream, connCancel)
+		m.disconnected(stream)
+		lastBeat := stream.lastHeartbeat()
+		if ctx.Err() != nil {
+			return
+		}
+		// a connection that ended before any message keeps backing off in the class of the last error
+		if lastBeat.After(started) {
+			attempts = map[streamBackoffClass]int{}
+			failures = 0
+			class = streamBackoffNetwork
+		}
+
+		for {
+			attempts[class]++
+			failures++
+			if m.opts.MaxAttempts > 0 && failures > m.opts.MaxAttempts {
+				m.sendFinalErr(&RetryError{
+					Attempts: failures,
+					Err:      cause,
+				})
+				return
+			}
+			delay := streamBackoffs[class].delay(attempts[class])
+			if m.opts.Notify != nil {
+				m.opts.Notify(failures, delay, cause)
+			}
+			if !sleepContext(ctx, delay) {
+				return
+			}
+
+			var connCtx context.Context
+			connCtx, connCancel = context.WithCancel(ctx)
+			var err error
+			stream, err = m.connect(connCtx, m.backfillMinutes(lastBeat))
+			if err == nil {
+				break
+			}
+			connCancel()
+			if ctx.Err() != nil {
+				return
+			}
+			var retry bool
+			if class, retry = streamErrorClass(err); !retry {
+				m.sendFinalErr(err)
+				return
+			}
+			cause = err
+		}
+	}
+}
+
+// connected will keep the connection and return when it started
+func (m *ManagedTweetStream) connected(stream *TweetStream) time.Time {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.current = stream
+	return stream.lastHeartbeat()
+}
+
+// disconnected will keep the drops of the connection after it has ended
+func (m *ManagedTweetStream) disconnected(stream *TweetStream) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.current = nil
+	m.dropped = m.dropped.add(stream.Dropped())
+}
+
+// Dropped returns the number of messages of each type dropped by the overflow policy over all of the connections
+func (m *ManagedTweetStream) Dropped() StreamDrops {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.current == nil {
+		return m.dropped
+	}
+	return m.dropped.add(m.current.Dropped())
+}
+
+// forward will send the messages of the connection until it ends and return the cause
+func (m *ManagedTweetStream) forward(ctx context.Context, stream *TweetStream, connCancel context.CancelFunc) error {
+	defer func() {
+		connCancel()
+		stream.Close()
+	}()
+
+	var cause error
+	ended := streamReceiver{
+		tweet: func(msg *TweetMessage) bool {
+			m.sendTweet(ctx, msg)
+			return true
+		},
+		system: func(msgs map[SystemMessageType]SystemMessage) bool {
+			m.sendSystem(ctx, msgs)
+			return true
+		},
+		disconnect: func(disconnect *DisconnectionError) bool {
+			m.sendDisconnection(ctx, disconnect)
+			cause = &StreamError{
+				Type: DisconnectErrorType,
+				Msg:  "stream disconnected",
+			}
+			return false
+		},
+		err: func(err error) bool {
+			if errors.Is(err, ErrStreamStall) {
+				cause = ErrStreamStall
+				return false
+			}
+			m.sendErr(err)
+			return true
+		},
+	}.receive(stream, ctx.Done())
+	switch {
+	case ctx.Err() != nil:
+		return ctx.Err()
+	case ended:
+		return stream.endErr()
+	default:
+		return cause
+	}
+}
+
+// backfillMinutes returns the minutes covering the gap since the last message, capped at the max backfill
+func (m *ManagedTweetStream) backfillMinutes(lastBeat time.Time) int {
+	if m.opts.DisableBackfill || lastBeat.IsZero() {
+		return 0
+	}
+	minutes := int(math.Ceil(time.Since(lastBeat).Minutes()))
+	switch {
+	case minutes < 1:
+		return 1
+	case minutes > sampleStreamMaxBackOffMin:
+		return sampleStreamMaxBackOffMin
+	default:
+		return minutes
+	}
+}
+
+func (m *ManagedTweetStream) sendTweet(ctx context.Context, msg *TweetMessage) {
+	if msg == nil {
+		return
+	}
+	if id := streamMessageID(msg); len(id) > 0 && !m.seen.add(id) {
+		return
+	}
+	select {
+	case m.tweets <- msg:
+	case <-ctx.Done():
+	}
+}
+
+// streamMessageID returns the id of a tweet message used to drop the duplicates, the compliance events are
+// identified by their type, tweet or user and time
+func streamMessageID(msg *TweetMessage) string {
+	switch {
+	case msg.Compliance != nil:
+		event := msg.Compliance
+		id := ""
+		switch {
+		case event.Tweet != nil:
+			id = event.Tweet.ID
+		case event.User != nil:
+			id = event.User.ID
+		default:
+		}
+		return string(event.Type) + "/" + id + "/" + event.EventAt
+	case msg.Raw != nil && len(msg.Raw.Tweets) > 0 && msg.Raw.Tweets[0] != nil:
+		return msg.Raw.Tweets[0].ID
+	default:
+		return ""
+	}
+}
+
+func (m *ManagedTweetStream) sendSystem(ctx context.Context, msg map[SystemMessageType]SystemMessage) {
+	if msg == nil {
+		return
+	}
+	select {
+	case m.system <- msg:
+	case <-ctx.Done():
+	}
+}
+
+func (m *ManagedTweetStream) sendDisconnection(ctx context.Context, msg *DisconnectionError) {
+	select {
+	case m.disconnection <- msg:
+	case <-ctx.Done():
+	}
+}
+
+// sendErr will buffer the error, it is dropped when the buffer is full
+func (m *ManagedTweetStream) sendErr(err error) {
+	if err == nil {
+		return
+	}
+	select {
+	case m.err <- err:
+	default:
+		m.mutex.Lock()
+		m.dropped.Errors++
+		m.mutex.Unlock()
+	}
+}
+
+// sendFinalErr will buffer the error the stream has stopped with, the oldest error is dropped when the buffer is full
+func (m *ManagedTweetStream) sendFinalErr(err error) {
+	for {
+		select {
+		case m.err <- err:
+			return
+		default:
+		}
+		select {
+		case <-m.err:
+			m.mutex.Lock()
+			m.dropped.Errors++
+			m.mutex.Unlock()
+		default:
+		}
+	}
+}
+
+// Tweets will return the channel to receive tweet stream messages
+func (m *ManagedTweetStream) Tweets() <-chan *TweetMessage {
+	return m.tweets
+}
+
+// SystemMessages will return the channel to receive system stream messages
+func (m *ManagedTweetStream) SystemMessages() <-chan map[SystemMessageType]SystemMessage {
+	return m.system
+}
+
+// DisconnectionError will return the channel to receive disconnect error messages, the stream will reconnect after them
+func (m *ManagedTweetStream) DisconnectionError() <-chan *DisconnectionError {
+	return m.disconnection
+}
+
+// Err will return the channel to receive any stream errors, the stream has given up when the channel is closed
+func (m *ManagedTweetStream) Err() <-chan error {
+	return m.err
+}
+
+// Done will return a channel that is closed when the managed stream has stopped
+func (m *ManagedTweetStream) Done() <-chan struct{} {
+	return m.done
+}
+
+// Close will close the connection and all of the channels
+func (m *ManagedTweetStream) Close() {
+	m.closeOnce.Do(m.cancel)
+	<-m.done
+}
+
+// tweetIDSet is a set of the most recent tweet ids
+type tweetIDSet struct {
+	ids   map[string]struct{}
+	order []string
+	next  int
+}
+
+func newTweetIDSet(size int) *tweetIDSet {
+	return &tweetIDSet{
+		ids:   make(map[string]struct{}, size),
+		order: make([]string, size),
+	}
+}
+
+// add will add the id and return false if it was already in the set
+func (s *tweetIDSet) add(id string) bool {
+	if id == "" {
+		return true
+	}
+	if _, has := s.ids[id]; has {
+		return false
+	}
+	if old := s.order[s.next]; old != "" {
+		delete(s.ids, old)
+	}
+	s.order[s.next] = id
+	s.next = (s.next + 1) % len(s.order)
+	s.ids[id] = struct{}{}
+	return true
+}
+
+// sleepContext will sleep for the delay and return false if the context is done first
+func sleepContext(ctx context.Context, delay time.Duration) bool {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
@@ -0,0 +1,509 @@
+package twitter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func managedStreamTestBackoffs(t *testing.T) {
+	saved := streamBackoffs
+	streamBackoffs = map[streamBackoffClass]streamBackoff{
+		streamBackoffNetwork:   {base: time.Millisecond, max: 5 * time.Millisecond},
+		streamBackoffHTTP:      {base: time.Millisecond, max: 5 * time.Millisecond, exponential: true},
+		streamBackoffRateLimit: {base: time.Millisecond, max: 5 * time.Millisecond, exponential: true},
+	}
+	t.Cleanup(func() {
+		streamBackoffs = saved
+	})
+}
+
+func managedStreamTestResponse(req *http.Request, code int, body string) *http.Response {
+	return &http.Response{
+		StatusCode: code,
+		Status:     http.StatusText(code),
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Header:     http.Header{},
+		Request:    req,
+	}
+}
+
+func managedStreamTweets(ids ...string) string {
+	lines := make([]string, len(ids))
+	for i, id := range ids {
+		lines[i] = fmt.Sprintf(`{"data":{"id":"%s","text":"tweet %s"}}`, id, id)
+	}
+	return strings.Join(lines, "\r\n") + "\r\n"
+}
+
+func managedStreamReceive(t *testing.T, stream *ManagedTweetStream, count int) ([]string, []error) {
+	ids := []string{}
+	errs := []error{}
+	timer := time.NewTimer(5 * time.Second)
+	defer timer.Stop()
+	for len(ids) < count {
+		select {
+		case msg, ok := <-stream.Tweets():
+			if !ok {
+				return ids, errs
+			}
+			ids = append(ids, msg.Raw.Tweets[0].ID)
+		case <-stream.SystemMessages():
+		case <-stream.DisconnectionError():
+		case err, ok := <-stream.Err():
+			if !ok {
+				return ids, errs
+			}
+			errs = append(errs, err)
+		case <-timer.C:
+			t.Errorf("ManagedTweetStream timeout with %v", ids)
+			return ids, errs
+		}
+	}
+	return ids, errs
+}
+
+func TestClient_ManagedTweetSearchStream(t *testing.T) {
+	managedStreamTestBackoffs(t)
+
+	tests := []struct {
+		name         string
+		responses    []func(req *http.Request) *http.Response
+		count        int
+		want         []string
+		wantBackfill []string
+		wantErr      bool
+		wantConnects int
+	}{
+		{
+			name: "reconnect after end of stream",
+			responses: []func(req *http.Request) *http.Response{
+				func(req *http.Request) *http.Response {
+					return managedStreamTestResponse(req, http.StatusOK, managedStreamTweets("1", "2"))
+				},
+				func(req *http.Request) *http.Response {
+					return managedStreamTestResponse(req, http.StatusOK, managedStreamTweets("2", "3"))
+				},
+			},
+			count:        3,
+			want:         []string{"1", "2", "3"},
+			wantBackfill: []string{"", "1"},
+			wantConnects: 2,
+		},
+		{
+			name: "reconnect after disconnect",
+			responses: []func(req *http.Request) *http.Response{
+				func(req *http.Request) *http.Response {
+					body := managedStreamTweets("1")
+					body += `{"errors":[{"title":"operational-disconnect","disconnect_type":"UpstreamOperationalDisconnect","detail":"This stream has been disconnected upstream for operational reasons.","type":"https://api.twitter.com/2/problems/operational-disconnect"}]}` + "\r\n"
+					return managedStreamTestResponse(req, http.StatusOK, body)
+				},
+				func(req *http.Request) *http.Response {
+					return managedStreamTestResponse(req, http.StatusServiceUnavailable, `{"title":"Service Unavailable"}`)
+				},
+				func(req *http.Request) *http.Response {
+					return managedStreamTestResponse(req, http.StatusOK, managedStreamTweets("1", "2"))
+				},
+			},
+			count:        2,
+			want:         []string{"1", "2"},
+			wantBackfill: []string{"", "1", "1"},
+			wantConnects: 3,
+		},
+		{
+			name: "fatal reconnect error",
+			responses: []func(req *http.Request) *http.Response{
+				func(req *http.Request) *http.Response {
+					return managedStreamTestResponse(req, http.StatusOK, managedStreamTweets("1"))
+				},
+				func(req *http.Request) *http.Response {
+					return managedStreamTestResponse(req, http.StatusUnauthorized, `{"title":"Unauthorized","type":"about:blank","status":401,"detail":"Unauthorized"}`)
+				},
+			},
+			count:        2,
+			want:         []string{"1"},
+			wantBackfill: []string{"", "1"},
+			wantErr:      true,
+			wantConnects: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backfill := []string{}
+			c := &Client{
+				Authorizer: &mockAuth{},
+				Host:       "https://www.test.com",
+				Client: mockHTTPClient(func(req *http.Request) *http.Response {
+					idx := len(backfill)
+					backfill = append(backfill, req.URL.Query().Get("backfill_minutes"))
+					if idx >= len(tt.responses) {
+						return managedStreamTestResponse(req, http.StatusUnauthorized, `{"title":"Unauthorized"}`)
+					}
+					return tt.responses[idx](req)
+				}),
+			}
+			stream, err := c.ManagedTweetSearchStream(context.Background(), TweetSearchStreamOpts{}, ManagedStreamOpts{})
+			if err != nil {
+				t.Fatalf("ManagedTweetSearchStream() error = %v", err)
+			}
+			got, errs := managedStreamReceive(t, stream, tt.count)
+			stream.Close()
+
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("ManagedTweetSearchStream() tweets = %v, want %v", got, tt.want)
+			}
+			errResp := &ErrorResponse{}
+			if (len(errs) > 0 && errors.As(errs[0], &errResp)) != tt.wantErr {
+				t.Errorf("ManagedTweetSearchStream() errors = %v, wantErr %v", errs, tt.wantErr)
+			}
+			if len(backfill) < tt.wantConnects || strings.Join(backfill[:tt.wantConnects], ",") != strings.Join(tt.wantBackfill, ",") {
+				t.Errorf("ManagedTweetSearchStream() backfill = %v, want %v", backfill, tt.wantBackfill)
+			}
+		})
+	}
+}
+
+func TestClient_ManagedTweetSampleStream(t *testing.T) {
+	managedStreamTestBackoffs(t)
+
+	t.Run("first connect error", func(t *testing.T) {
+		c := &Client{
+			Authorizer: &mockAuth{},
+			Host:       "https://www.test.com",
+			Client: mockHTTPClient(func(req *http.Request) *http.Response {
+				return managedStreamTestResponse(req, http.StatusForbidden, `{"title":"Forbidden"}`)
+			}),
+		}
+		_, err := c.ManagedTweetSampleStream(context.Background(), TweetSampleStreamOpts{}, ManagedStreamOpts{})
+		errResp := &ErrorResponse{}
+		if !errors.As(err, &errResp) || errResp.StatusCode != http.StatusForbidden {
+			t.Errorf("ManagedTweetSampleStream() error = %v", err)
+		}
+	})
+
+	t.Run("buffer size", func(t *testing.T) {
+		c := &Client{
+			Authorizer: &mockAuth{},
+			Host:       "https://www.test.com",
+			Client: mockHTTPClient(func(req *http.Request) *http.Response {
+				return managedStreamTestResponse(req, http.StatusOK, managedStreamTweets("1"))
+			}),
+			StreamOpts: StreamOpts{
+				BufferSize: 25,
+			},
+		}
+		stream, err := c.ManagedTweetSampleStream(context.Background(), TweetSampleStreamOpts{}, ManagedStreamOpts{})
+		if err != nil {
+			t.Fatalf("ManagedTweetSampleStream() error = %v", err)
+		}
+		defer stream.Close()
+		if cap(stream.Tweets()) != 25 || cap(stream.SystemMessages()) != 25 || cap(stream.DisconnectionError()) != 25 || cap(stream.Err()) != 25 {
+			t.Errorf("ManagedTweetSampleStream() buffer = %d, want 25", cap(stream.Tweets()))
+		}
+	})
+
+	t.Run("max attempts", func(t *testing.T) {
+		connects := 0
+		notified := 0
+		c := &Client{
+			Authorizer: &mockAuth{},
+			Host:       "https://www.test.com",
+			Client: mockHTTPClient(func(req *http.Request) *http.Response {
+				connects++
+				if connects == 1 {
+					return managedStreamTestResponse(req, http.StatusOK, managedStreamTweets("1"))
+				}
+				return managedStreamTestResponse(req, http.StatusServiceUnavailable, `{"title":"Service Unavailable"}`)
+			}),
+		}
+		stream, err := c.ManagedTweetSampleStream(context.Background(), TweetSampleStreamOpts{}, ManagedStreamOpts{
+			MaxAttempts: 3,
+			Notify: func(attempt int, delay time.Duration, cause error) {
+				notified++
+			},
+		})
+		if err != nil {
+			t.Fatalf("ManagedTweetSampleStream() error = %v", err)
+		}
+		got, errs := managedStreamReceive(t, stream, 2)
+		stream.Close()
+
+		retryErr := &RetryError{}
+		if len(got) != 1 || len(errs) != 1 || !errors.As(errs[0], &retryErr) {
+			t.Fatalf("ManagedTweetSampleStream() tweets = %v, errors = %v", got, errs)
+		}
+		if connects != 4 || notified != 3 {
+			t.Errorf("ManagedTweetSampleStream() connects = %d, notified = %d", connects, notified)
+		}
+	})
+}
+
+func TestClient_ManagedTweetSampleStream_Backoff(t *testing.T) {
+	saved := streamBackoffs
+	streamBackoffs = map[streamBackoffClass]streamBackoff{
+		streamBackoffNetwork:   {base: time.Millisecond, max: 5 * time.Millisecond},
+		streamBackoffHTTP:      {base: 2 * time.Millisecond, max: 20 * time.Millisecond, exponential: true},
+		streamBackoffRateLimit: {base: 3 * time.Millisecond, max: 30 * time.Millisecond, exponential: true},
+	}
+	t.Cleanup(func() {
+		streamBackoffs = saved
+	})
+
+	connects := 0
+	delays := []time.Duration{}
+	c := &Client{
+		Authorizer: &mockAuth{},
+		Host:       "https://www.test.com",
+		Client: mockHTTPClient(func(req *http.Request) *http.Response {
+			connects++
+			switch connects {
+			case 1:
+				return managedStreamTestResponse(req, http.StatusOK, managedStreamTweets("1"))
+			case 2:
+				return managedStreamTestResponse(req, http.StatusTooManyRequests, `{"title":"Too Many Requests"}`)
+			case 3:
+				// the connection ends before any message
+				return managedStreamTestResponse(req, http.StatusOK, "")
+			default:
+				return managedStreamTestResponse(req, http.StatusOK, managedStreamTweets("2"))
+			}
+		}),
+	}
+	stream, err := c.ManagedTweetSampleStream(context.Background(), TweetSampleStreamOpts{}, ManagedStreamOpts{
+		Notify: func(attempt int, delay time.Duration, cause error) {
+			delays = append(delays, delay)
+		},
+	})
+	if err != nil {
+		t.Fatalf("ManagedTweetSampleStream() error = %v", err)
+	}
+	got, _ := managedStreamReceive(t, stream, 2)
+	stream.Close()
+
+	if strings.Join(got, ",") != "1,2" {
+		t.Errorf("ManagedTweetSampleStream() tweets = %v", got)
+	}
+	want := []time.Duration{time.Millisecond, 3 * time.Millisecond, 6 * time.Millisecond}
+	if len(delays) < len(want) || fmt.Sprint(delays[:len(want)]) != fmt.Sprint(want) {
+		t.Errorf("ManagedTweetSampleStream() delays = %v, want %v", delays, want)
+	}
+}
+
+func TestClient_ManagedTweetSampleStream_UnreadErrors(t *testing.T) {
+	managedStreamTestBackoffs(t)
+
+	connects := 0
+	c := &Client{
+		Authorizer: &mockAuth{},
+		Host:       "https://www.test.com",
+		Client: mockHTTPClient(func(req *http.Request) *http.Response {
+			connects++
+			body := strings.Repeat(`{"data":"invalid"}`+"\r\n", 15)
+			return managedStreamTestResponse(req, http.StatusOK, body+managedStreamTweets(strconv.Itoa(connects)))
+		}),
+	}
+	stream, err := c.ManagedTweetSampleStream(context.Background(), TweetSampleStreamOpts{}, ManagedStreamOpts{})
+	if err != nil {
+		t.Fatalf("ManagedTweetSampleStream() error = %v", err)
+	}
+	defer stream.Close()
+
+	// only the tweets are read, the errors must not block the reconnects
+	got := []string{}
+	timer := time.NewTimer(5 * time.Second)
+	defer timer.Stop()
+	for len(got) < 3 {
+		select {
+		case msg := <-stream.Tweets():
+			got = append(got, msg.Raw.Tweets[0].ID)
+		case <-timer.C:
+			t.Fatalf("ManagedTweetSampleStream() timeout with %v", got)
+		}
+	}
+	if strings.Join(got, ",") != "1,2,3" {
+		t.Errorf("ManagedTweetSampleStream() tweets = %v", got)
+	}
+	if stream.Dropped().Errors == 0 {
+		t.Errorf("ManagedTweetSampleStream() no errors dropped")
+	}
+}
+
+func TestStreamBackoff_delay(t *testing.T) {
+	tests := []struct {
+		class   streamBackoffClass
+		attempt int
+		want    time.Duration
+	}{
+		{class: streamBackoffNetwork, attempt: 1, want: 250 * time.Millisecond},
+		{class: streamBackoffNetwork, attempt: 4, want: time.Second},
+		{class: streamBackoffNetwork, attempt: 100, want: 16 * time.Second},
+		{class: streamBackoffHTTP, attempt: 1, want: 5 * time.Second},
+		{class: streamBackoffHTTP, attempt: 3, want: 20 * time.Second},
+		{class: streamBackoffHTTP, attempt: 10, want: 320 * time.Second},
+		{class: streamBackoffRateLimit, attempt: 1, want: time.Minute},
+		{class: streamBackoffRateLimit, attempt: 2, want: 2 * time.Minute},
+		{class: streamBackoffRateLimit, attempt: 64, want: 16 * time.Minute},
+	}
+	for _, tt := range tests {
+		if got := streamBackoffs[tt.class].delay(tt.attempt); got != tt.want {
+			t.Errorf("streamBackoff.delay(%d, %d) = %v, want %v", tt.class, tt.attempt, got, tt.want)
+		}
+	}
+
+	long := streamBackoff{base: time.Hour, max: math.MaxInt64}
+	if got := long.delay(1 << 40); got != math.MaxInt64 {
+		t.Errorf("streamBackoff.delay() linear = %v, want the max", got)
+	}
+	long.exponential = true
+	if got := long.delay(23); got != math.MaxInt64 {
+		t.Errorf("streamBackoff.delay() exponential = %v, want the max", got)
+	}
+}
+
+func TestStreamErrorClass(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		wantClass streamBackoffClass
+		wantRetry bool
+	}{
+		{
+			name:      "network",
+			err:       io.ErrUnexpectedEOF,
+			wantClass: streamBackoffNetwork,
+			wantRetry: true,
+		},
+		{
+			name:      "server error",
+			err:       fmt.Errorf("tweet search stream response: %w", &HTTPError{StatusCode: http.StatusBadGateway}),
+			wantClass: streamBackoffHTTP,
+			wantRetry: true,
+		},
+		{
+			name:      "too many connections",
+			err:       &ErrorResponse{StatusCode: http.StatusTooManyRequests},
+			wantClass: streamBackoffRateLimit,
+			wantRetry: true,
+		},
+		{
+			name:      "unauthorized",
+			err:       &ErrorResponse{StatusCode: http.StatusUnauthorized},
+			wantClass: streamBackoffHTTP,
+			wantRetry: false,
+		},
+		{
+			name:      "parameter",
+			err:       fmt.Errorf("backfill: %w", ErrParameter),
+			wantRetry: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			class, retry := streamErrorClass(tt.err)
+			if retry != tt.wantRetry || (retry && class != tt.wantClass) {
+				t.Errorf("streamErrorClass() = %v, %v, want %v, %v", class, retry, tt.wantClass, tt.wantRetry)
+			}
+		})
+	}
+}
+
+func TestManagedTweetStream_backfillMinutes(t *testing.T) {
+	m := &ManagedTweetStream{}
+	tests := []struct {
+		gap  time.Duration
+		want int
+	}{
+		{gap: time.Second, want: 1},
+		{gap: 90 * time.Second, want: 2},
+		{gap: time.Hour, want: sampleStreamMaxBackOffMin},
+	}
+	for _, tt := range tests {
+		if got := m.backfillMinutes(time.Now().Add(-tt.gap)); got != tt.want {
+			t.Errorf("backfillMinutes(%v) = %d, want %d", tt.gap, got, tt.want)
+		}
+	}
+	m.opts.DisableBackfill = true
+	if got := m.backfillMinutes(time.Now().Add(-time.Minute)); got != 0 {
+		t.Errorf("backfillMinutes() disabled = %d", got)
+	}
+}
+
+func TestTweetIDSet(t *testing.T) {
+	set := newTweetIDSet(2)
+	if !set.add("1") || !set.add("2") || set.add("1") {
+		t.Fatalf("tweetIDSet.add() did not detect the duplicate")
+	}
+	set.add("3")
+	if !set.add("1") {
+		t.Errorf("tweetIDSet.add() did not evict the oldest id")
+	}
+}
+
+func TestStreamMessageID(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  *TweetMessage
+		want string
+	}{
+		{name: "tweet", msg: streamHubTweet("1"), want: "1"},
+		{name: "tweet event", msg: &TweetMessage{Compliance: &ComplianceEvent{Type: ComplianceDelete, Tweet: &ComplianceEventTweet{ID: "1"}, EventAt: "2021-07-06T18:40:40.000Z"}}, want: "delete/1/2021-07-06T18:40:40.000Z"},
+		{name: "user event", msg: &TweetMessage{Compliance: &ComplianceEvent{Type: ComplianceUserSuspend, User: &ComplianceEventUser{ID: "10"}, EventAt: "2021-07-06T18:40:40.000Z"}}, want: "user_suspend/10/2021-07-06T18:40:40.000Z"},
+		{name: "empty", msg: &TweetMessage{}, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := streamMessageID(tt.msg); got != tt.want {
+				t.Errorf("streamMessageID() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+type contextReader struct {
+	ctx context.Context
+}
+
+func (c *contextReader) Read(p []byte) (int, error) {
+	<-c.ctx.Done()
+	return 0, c.ctx.Err()
+}
+
+func TestClient_ManagedTweetSearchStream_Stall(t *testing.T) {
+	managedStreamTestBackoffs(t)
+
+	connects := 0
+	c := &Client{
+		Authorizer: &mockAuth{},
+		Host:       "https://www.test.com",
+		StreamOpts: StreamOpts{KeepAlive: 50 * time.Millisecond},
+		Client: mockHTTPClient(func(req *http.Request) *http.Response {
+			connects++
+			if connects == 1 {
+				resp := managedStreamTestResponse(req, http.StatusOK, "")
+				resp.Body = io.NopCloser(io.MultiReader(strings.NewReader(managedStreamTweets("1")), &contextReader{ctx: req.Context()}))
+				return resp
+			}
+			return managedStreamTestResponse(req, http.StatusOK, managedStreamTweets("1", "2"))
+		}),
+	}
+	stream, err := c.ManagedTweetSearchStream(context.Background(), TweetSearchStreamOpts{}, ManagedStreamOpts{})
+	if err != nil {
+		t.Fatalf("ManagedTweetSearchStream() error = %v", err)
+	}
+	got, errs := managedStreamReceive(t, stream, 2)
+	stream.Close()
+
+	if strings.Join(got, ",") != "1,2" || len(errs) != 0 {
+		t.Errorf("ManagedTweetSearchStream() tweets = %v, errors = %v", got, errs)
+	}
+	if connects < 2 {
+		t.Errorf("ManagedTweetSearchStream() did not reconnect after the stall")
+	}
+}
@@ -122,9 +122,8 @@ func (c *Client) UploadMedia(ctx context.Context, req MediaUploadRequest) (*Medi
 
 	httpReq.Header.Set("Content-Type", contentType)
 	httpReq.Header.Set("Accept", "application/json")
-	c.Authorizer.Add(httpReq)
 
-	resp, err := c.Client.Do(httpReq)
+	resp, err := c.do(httpReq, "UploadMedia", mediaUploadEndpoint)
 	if err != nil {
 		return nil, fmt.Errorf("media upload response: %w", err)
 	}
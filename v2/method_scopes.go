@@ -0,0 +1,164 @@
+package twitter
+
+import (
+	"fmt"
+	"sort"
+)
+
+var (
+	tweetUserScopes = []string{ScopeTweetRead, ScopeUsersRead}
+)
+
+func withTweetUserScopes(scopes ...string) []string {
+	return append(append([]string{}, tweetUserScopes...), scopes...)
+}
+
+// MethodScopes maps the Client methods to the OAuth2 user context scopes that they require.
+//
+// The methods that only support app-only auth, like the full archive search, streams and compliance
+// jobs, are not in the table.
+var MethodScopes = map[string][]string{
+	// Tweets
+	"CreateTweet":          withTweetUserScopes(ScopeTweetWrite),
+	"DeleteTweet":          withTweetUserScopes(ScopeTweetWrite),
+	"TweetLookup":          withTweetUserScopes(),
+	"TweetRecentSearch":    withTweetUserScopes(),
+	"TweetHideReplies":     withTweetUserScopes(ScopeTweetModerate),
+	"QuoteTweetsLookup":    withTweetUserScopes(),
+	"UserRetweetLookup":    withTweetUserScopes(),
+	"UserRetweet":          withTweetUserScopes(ScopeRetweetWrite),
+	"DeleteUserRetweet":    withTweetUserScopes(ScopeRetweetWrite),
+	"TweetLikesLookup":     withTweetUserScopes(ScopeLikeRead),
+	"UserLikesLookup":      withTweetUserScopes(ScopeLikeRead),
+	"UserLikes":            withTweetUserScopes(ScopeLikeWrite),
+	"DeleteUserLikes":      withTweetUserScopes(ScopeLikeWrite),
+	"TweetBookmarksLookup": withTweetUserScopes(ScopeBookmarkRead),
+	"AddTweetBookmark":     withTweetUserScopes(ScopeBookmarkWrite),
+	"RemoveTweetBookmark":  withTweetUserScopes(ScopeBookmarkWrite),
+
+	// Timelines
+	"UserTweetTimeline":                     withTweetUserScopes(),
+	"UserMentionTimeline":                   withTweetUserScopes(),
+	"UserTweetReverseChronologicalTimeline": withTweetUserScopes(),
+
+	// Users
+	"UserLookup":          withTweetUserScopes(),
+	"UserNameLookup":      withTweetUserScopes(),
+	"AuthUserLookup":      withTweetUserScopes(),
+	"UserFollowingLookup": withTweetUserScopes(ScopeFollowsRead),
+	"UserFollowersLookup": withTweetUserScopes(ScopeFollowsRead),
+	"UserFollows":         withTweetUserScopes(ScopeFollowsWrite),
+	"DeleteUserFollows":   withTweetUserScopes(ScopeFollowsWrite),
+	"UserBlocksLookup":    withTweetUserScopes(ScopeBlockRead),
+	"UserBlocks":          withTweetUserScopes(ScopeBlockWrite),
+	"DeleteUserBlocks":    withTweetUserScopes(ScopeBlockWrite),
+	"UserMutesLookup":     withTweetUserScopes(ScopeMuteRead),
+	"UserMutes":           withTweetUserScopes(ScopeMuteWrite),
+	"DeleteUserMutes":     withTweetUserScopes(ScopeMuteWrite),
+
+	// Spaces
+	"SpacesLookup":          withTweetUserScopes(ScopeSpaceRead),
+	"SpacesByCreatorLookup": withTweetUserScopes(ScopeSpaceRead),
+	"SpacesSearch":          withTweetUserScopes(ScopeSpaceRead),
+	"SpaceBuyersLookup":     withTweetUserScopes(ScopeSpaceRead),
+	"SpaceTweetsLookup":     withTweetUserScopes(ScopeSpaceRead),
+
+	// Lists
+	"ListLookup":          withTweetUserScopes(ScopeListRead),
+	"UserListLookup":      withTweetUserScopes(ScopeListRead),
+	"ListTweetLookup":     withTweetUserScopes(ScopeListRead),
+	"ListUserMembers":     withTweetUserScopes(ScopeListRead),
+	"UserListMemberships": withTweetUserScopes(ScopeListRead),
+	"ListUserFollowers":   withTweetUserScopes(ScopeListRead),
+	"UserFollowedLists":   withTweetUserScopes(ScopeListRead),
+	"UserPinnedLists":     withTweetUserScopes(ScopeListRead),
+	"CreateList":          withTweetUserScopes(ScopeListRead, ScopeListWrite),
+	"UpdateList":          withTweetUserScopes(ScopeListRead, ScopeListWrite),
+	"DeleteList":          withTweetUserScopes(ScopeListRead, ScopeListWrite),
+	"AddListMember":       withTweetUserScopes(ScopeListRead, ScopeListWrite),
+	"RemoveListMember":    withTweetUserScopes(ScopeListRead, ScopeListWrite),
+	"UserFollowList":      withTweetUserScopes(ScopeListRead, ScopeListWrite),
+	"UserUnfollowList":    withTweetUserScopes(ScopeListRead, ScopeListWrite),
+	"UserPinList":         withTweetUserScopes(ScopeListRead, ScopeListWrite),
+	"UserUnpinList":       withTweetUserScopes(ScopeListRead, ScopeListWrite),
+
+	// Direct Messages
+	"DMEvents":                     withTweetUserScopes(ScopeDMRead),
+	"DMConversationEvents":         withTweetUserScopes(ScopeDMRead),
+	"DMConversations":              withTweetUserScopes(ScopeDMRead),
+	"DMConversationsByParticipant": withTweetUserScopes(ScopeDMRead),
+	"SendDM":                       withTweetUserScopes(ScopeDMRead, ScopeDMWrite),
+	"SendDMByParticipantID":        withTweetUserScopes(ScopeDMRead, ScopeDMWrite),
+	"CreateDMConversation":         withTweetUserScopes(ScopeDMRead, ScopeDMWrite),
+
+	// Media
+	"UploadMedia": {ScopeMediaWrite},
+}
+
+// ScopedAuthorizer is an Authorizer that reports the scopes that have been granted, like AuthorizerV2
+type ScopedAuthorizer interface {
+	// Scope returns the space separated scopes, empty if they are not known
+	Scope() string
+}
+
+// MissingScopes returns the scopes required by the method that are not in the scope string
+func MissingScopes(method, scopeString string) []string {
+	missing := []string{}
+	for _, scope := range MethodScopes[method] {
+		if !HasScope(scopeString, scope) {
+			missing = append(missing, scope)
+		}
+	}
+	return missing
+}
+
+// MinimalScopes returns the least privilege scopes that are required to call all of the methods
+func MinimalScopes(methods []string) ([]string, error) {
+	required := map[string]bool{}
+	for _, method := range methods {
+		scopes, has := MethodScopes[method]
+		if !has {
+			return nil, fmt.Errorf("minimal scopes: method %s is not in the method scopes: %w", method, ErrParameter)
+		}
+		for _, scope := range scopes {
+			required[scope] = true
+		}
+	}
+
+	// keep the order of all scopes so the scope string is stable
+	order := map[string]int{}
+	for i, scope := range AllScopes {
+		order[scope] = i
+	}
+	scopes := make([]string, 0, len(required))
+	for scope := range required {
+		scopes = append(scopes, scope)
+	}
+	sort.Slice(scopes, func(i, j int) bool {
+		return order[scopes[i]] < order[scopes[j]]
+	})
+	return scopes, nil
+}
+
+// checkScopes will fail fast if the authorizer reports its scopes and the method requires a missing scope
+func (c *Client) checkScopes(method string) error {
+	if !c.EnforceScopes {
+		return nil
+	}
+	scoped, ok := c.Authorizer.(ScopedAuthorizer)
+	if !ok {
+		return nil
+	}
+	granted := scoped.Scope()
+	if granted == "" {
+		return nil
+	}
+	if missing := MissingScopes(method, granted); len(missing) > 0 {
+		return &MissingScopeError{
+			Method:  method,
+			Missing: missing,
+			Granted: SplitScopes(granted),
+		}
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package twitter
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMethodScopes(t *testing.T) {
+	client := reflect.TypeOf(&Client{})
+	for method, scopes := range MethodScopes {
+		if _, has := client.MethodByName(method); !has {
+			t.Errorf("MethodScopes %s is not a Client method", method)
+		}
+		if len(scopes) == 0 {
+			t.Errorf("MethodScopes %s has no scopes", method)
+		}
+		if invalid := ValidateScopes(scopes); len(invalid) > 0 {
+			t.Errorf("MethodScopes %s has invalid scopes %v", method, invalid)
+		}
+	}
+}
+
+func TestMinimalScopes(t *testing.T) {
+	tests := []struct {
+		name    string
+		methods []string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:    "read",
+			methods: []string{"UserTweetTimeline", "UserLookup"},
+			want:    []string{ScopeTweetRead, ScopeUsersRead},
+		},
+		{
+			name:    "bot",
+			methods: []string{"CreateTweet", "UserLikes", "UserFollowersLookup"},
+			want:    []string{ScopeTweetRead, ScopeTweetWrite, ScopeUsersRead, ScopeFollowsRead, ScopeLikeWrite},
+		},
+		{
+			name:    "unknown method",
+			methods: []string{"CreateTweet", "TweetSearchStream"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MinimalScopes(tt.methods)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("MinimalScopes() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) && !tt.wantErr {
+				t.Errorf("MinimalScopes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClient_EnforceScopes(t *testing.T) {
+	tests := []struct {
+		name        string
+		enforce     bool
+		scope       string
+		wantMissing []string
+		wantCallout bool
+	}{
+		{
+			name:        "missing scopes",
+			enforce:     true,
+			scope:       "tweet.read users.read offline.access",
+			wantMissing: []string{ScopeTweetWrite},
+		},
+		{
+			name:        "granted scopes",
+			enforce:     true,
+			scope:       "tweet.read tweet.write users.read",
+			wantCallout: true,
+		},
+		{
+			name:        "unknown scopes",
+			enforce:     true,
+			scope:       "",
+			wantCallout: true,
+		},
+		{
+			name:        "not enforced",
+			enforce:     false,
+			scope:       "tweet.read users.read",
+			wantCallout: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auth := NewAuthorizerV2("access_token", "", "", "", nil)
+			auth.scope = tt.scope
+			callout := false
+			c := &Client{
+				Authorizer:    auth,
+				Host:          "https://www.test.com",
+				EnforceScopes: tt.enforce,
+				Client: mockHTTPClient(func(req *http.Request) *http.Response {
+					callout = true
+					return &http.Response{
+						StatusCode: http.StatusCreated,
+						Body:       io.NopCloser(strings.NewReader(`{"data":{"id":"1445880548472328192","text":"Hello World!"}}`)),
+					}
+				}),
+			}
+			_, err := c.CreateTweet(context.Background(), CreateTweetRequest{Text: "Hello World!"})
+			if callout != tt.wantCallout {
+				t.Errorf("CreateTweet() callout = %v, want %v", callout, tt.wantCallout)
+			}
+			scopeErr := &MissingScopeError{}
+			if errors.As(err, &scopeErr) != (tt.wantMissing != nil) {
+				t.Fatalf("CreateTweet() error = %v", err)
+			}
+			if tt.wantMissing != nil && (!reflect.DeepEqual(scopeErr.Missing, tt.wantMissing) || scopeErr.Method != "CreateTweet") {
+				t.Errorf("CreateTweet() missing scope error = %+v", scopeErr)
+			}
+		})
+	}
+}
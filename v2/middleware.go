@@ -0,0 +1,74 @@
+package twitter
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+)
+
+// Callout is the API callout that is passed through the middleware
+type Callout struct {
+	// Operation is the client method that is making the callout, like UserTweetTimeline
+	Operation string
+	// Endpoint is the endpoint template, like 2/users/{id}/tweets
+	Endpoint string
+	// Request is the request for this attempt.  The authorization is added after the middleware, when the request is
+	// sent, so the middleware does not see the credentials and a refreshed token is used for a replayed request.
+	Request *http.Request
+	// Attempt is the attempt number, starting at 1, when the callout is retried
+	Attempt int
+}
+
+// CalloutResult is the result of the callout that is passed back through the middleware
+type CalloutResult struct {
+	// Response is the HTTP response, the body can be read by the client method
+	Response *http.Response
+	// RateLimit is decoded from the response headers
+	RateLimit *RateLimit
+	// Err is the typed error of the callout, an ErrorResponse or HTTPError for a non successful response
+	Err error
+}
+
+// CalloutHandler will send the callout and return the result
+type CalloutHandler func(callout *Callout) *CalloutResult
+
+// Middleware will wrap the next handler in the chain.  The middleware can modify the callout before
+// calling next, inspect the result after, or return a result without calling next at all.
+//
+// A result with an error and no response will be returned by the client method as the error.
+type Middleware func(next CalloutHandler) CalloutHandler
+
+// chain will wrap the handler with the middleware, the first middleware is the outermost
+func chain(middleware []Middleware, handler CalloutHandler) CalloutHandler {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		handler = middleware[i](handler)
+	}
+	return handler
+}
+
+// calloutResult will create the result of the response, the body of a non successful response is
+// buffered so the error can be decoded and the body can still be read by the client method
+func calloutResult(resp *http.Response, err error) *CalloutResult {
+	if err != nil {
+		return &CalloutResult{
+			Err: err,
+		}
+	}
+	result := &CalloutResult{
+		Response:  resp,
+		RateLimit: rateFromHeader(resp.Header),
+	}
+	if resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusMultipleChoices {
+		return result
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		result.Response = nil
+		result.Err = err
+		return result
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	result.Err = decodeResponseError(resp, body)
+	return result
+}
@@ -0,0 +1,206 @@
+package twitter
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClient_Middleware(t *testing.T) {
+	tests := []struct {
+		name          string
+		code          int
+		body          string
+		wantErr       bool
+		wantResultErr bool
+	}{
+		{
+			name: "success",
+			code: http.StatusOK,
+			body: `{"data":{"id":"2244994945","name":"TwitterDev","username":"TwitterDev"}}`,
+		},
+		{
+			name:          "error response",
+			code:          http.StatusUnauthorized,
+			body:          `{"title":"Unauthorized","type":"about:blank","status":401,"detail":"Unauthorized"}`,
+			wantErr:       true,
+			wantResultErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var order []string
+			var callout *Callout
+			var result *CalloutResult
+			record := func(name string) Middleware {
+				return func(next CalloutHandler) CalloutHandler {
+					return func(c *Callout) *CalloutResult {
+						order = append(order, name+" before")
+						r := next(c)
+						order = append(order, name+" after")
+						return r
+					}
+				}
+			}
+			c := &Client{
+				Authorizer: &mockAuth{},
+				Host:       "https://www.test.com",
+				Middleware: []Middleware{
+					record("outer"),
+					func(next CalloutHandler) CalloutHandler {
+						return func(c *Callout) *CalloutResult {
+							c.Request.Header.Set("X-Test", "injected")
+							callout = c
+							result = next(c)
+							return result
+						}
+					},
+					record("inner"),
+				},
+				Client: mockHTTPClient(func(req *http.Request) *http.Response {
+					if req.Header.Get("X-Test") != "injected" {
+						t.Errorf("Middleware header not injected %v", req.Header)
+					}
+					h := http.Header{}
+					h.Add(rateLimit, "15")
+					h.Add(rateRemaining, "12")
+					h.Add(rateReset, strconv.FormatInt(time.Now().Add(time.Minute).Unix(), 10))
+					return &http.Response{
+						StatusCode: tt.code,
+						Status:     http.StatusText(tt.code),
+						Body:       io.NopCloser(strings.NewReader(tt.body)),
+						Header:     h,
+						Request:    req,
+					}
+				}),
+			}
+			_, err := c.UserLookup(context.Background(), []string{"2244994945"}, UserLookupOpts{})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("UserLookup() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			wantOrder := []string{"outer before", "inner before", "inner after", "outer after"}
+			if strings.Join(order, ",") != strings.Join(wantOrder, ",") {
+				t.Errorf("Middleware order = %v, want %v", order, wantOrder)
+			}
+			if callout.Operation != "UserLookup" || callout.Endpoint != string(userLookupEndpoint) || callout.Attempt != 1 {
+				t.Errorf("Middleware callout = %+v", callout)
+			}
+			if result.RateLimit == nil || result.RateLimit.Remaining != 12 {
+				t.Errorf("Middleware rate limit = %v", result.RateLimit)
+			}
+			errResp := &ErrorResponse{}
+			if errors.As(result.Err, &errResp) != tt.wantResultErr {
+				t.Errorf("Middleware result error = %v, want %v", result.Err, tt.wantResultErr)
+			}
+			if tt.wantResultErr {
+				// the body is still decoded by the client method
+				if !errors.As(err, &errResp) || errResp.StatusCode != tt.code || errResp.Title != "Unauthorized" {
+					t.Errorf("UserLookup() error = %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestClient_MiddlewareShortCircuit(t *testing.T) {
+	open := errors.New("circuit open")
+	sent := false
+	c := &Client{
+		Authorizer: &mockAuth{},
+		Host:       "https://www.test.com",
+		Middleware: []Middleware{
+			func(next CalloutHandler) CalloutHandler {
+				return func(c *Callout) *CalloutResult {
+					return &CalloutResult{
+						Err: open,
+					}
+				}
+			},
+		},
+		Client: mockHTTPClient(func(req *http.Request) *http.Response {
+			sent = true
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(strings.NewReader(`{}`)),
+			}
+		}),
+	}
+	_, err := c.AuthUserLookup(context.Background(), UserLookupOpts{})
+	if !errors.Is(err, open) {
+		t.Errorf("AuthUserLookup() error = %v, want %v", err, open)
+	}
+	if sent {
+		t.Errorf("AuthUserLookup() callout was sent")
+	}
+}
+
+func TestClient_MiddlewareAuthorization(t *testing.T) {
+	c := &Client{
+		Authorizer: retryTestAuth{},
+		Host:       "https://www.test.com",
+		Middleware: []Middleware{
+			func(next CalloutHandler) CalloutHandler {
+				return func(c *Callout) *CalloutResult {
+					if auth := c.Request.Header.Get("Authorization"); len(auth) != 0 {
+						t.Errorf("Middleware request authorization = %v, want none", auth)
+					}
+					return next(c)
+				}
+			},
+		},
+		Client: mockHTTPClient(func(req *http.Request) *http.Response {
+			if auth := req.Header.Values("Authorization"); len(auth) != 1 || auth[0] != "Bearer token" {
+				t.Errorf("Sent request authorization = %v", auth)
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(strings.NewReader(`{"data":{"id":"2244994945","name":"TwitterDev","username":"TwitterDev"}}`)),
+				Header:     http.Header{},
+				Request:    req,
+			}
+		}),
+	}
+	if _, err := c.AuthUserLookup(context.Background(), UserLookupOpts{}); err != nil {
+		t.Errorf("AuthUserLookup() error = %v", err)
+	}
+}
+
+func TestClient_MiddlewareRetry(t *testing.T) {
+	var attempts []int
+	calls := 0
+	c := &Client{
+		Authorizer: &mockAuth{},
+		Host:       "https://www.test.com",
+		Retry: &RetryPolicy{
+			BaseDelay: time.Millisecond,
+		},
+		Middleware: []Middleware{
+			func(next CalloutHandler) CalloutHandler {
+				return func(c *Callout) *CalloutResult {
+					attempts = append(attempts, c.Attempt)
+					return next(c)
+				}
+			},
+		},
+		Client: mockHTTPClient(func(req *http.Request) *http.Response {
+			calls++
+			resp := retryTestResponse(http.StatusServiceUnavailable, `{"title":"Service Unavailable"}`, nil)
+			if calls > 1 {
+				resp = retryTestResponse(http.StatusOK, retryTestUser, nil)
+			}
+			resp.Request = req
+			return resp
+		}),
+	}
+	if _, err := c.AuthUserLookup(context.Background(), UserLookupOpts{}); err != nil {
+		t.Fatalf("AuthUserLookup() error = %v", err)
+	}
+	if len(attempts) != 2 || attempts[0] != 1 || attempts[1] != 2 {
+		t.Errorf("Middleware attempts = %v, want [1 2]", attempts)
+	}
+}
@@ -0,0 +1,183 @@
+package twitter
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	oauth1SignatureMethod = "HMAC-SHA1"
+	oauth1Version         = "1.0"
+	oauth1FormContentType = "application/x-www-form-urlencoded"
+)
+
+// OAuth1Authorizer implements the Authorizer interface with OAuth 1.0a user context, each request is
+// signed with HMAC-SHA1 using the consumer key and secret and the access token and secret.
+//
+// The query parameters and form bodies are included in the signature, multipart bodies like the media
+// upload are not.
+type OAuth1Authorizer struct {
+	consumerKey    string
+	consumerSecret string
+	token          string
+	tokenSecret    string
+	nonce          func() string
+	now            func() time.Time
+}
+
+// NewOAuth1Authorizer creates a new OAuth 1.0a authorizer
+func NewOAuth1Authorizer(consumerKey, consumerSecret, accessToken, accessTokenSecret string) *OAuth1Authorizer {
+	return &OAuth1Authorizer{
+		consumerKey:    consumerKey,
+		consumerSecret: consumerSecret,
+		token:          accessToken,
+		tokenSecret:    accessTokenSecret,
+		nonce:          oauth1Nonce,
+		now:            time.Now,
+	}
+}
+
+// Add implements the Authorizer interface and signs the request
+func (o *OAuth1Authorizer) Add(req *http.Request) {
+	params := url.Values{}
+	params.Set("oauth_consumer_key", o.consumerKey)
+	params.Set("oauth_nonce", o.nonce())
+	params.Set("oauth_signature_method", oauth1SignatureMethod)
+	params.Set("oauth_timestamp", strconv.FormatInt(o.now().Unix(), 10))
+	if o.token != "" {
+		params.Set("oauth_token", o.token)
+	}
+	params.Set("oauth_version", oauth1Version)
+	params.Set("oauth_signature", o.sign(oauth1SignatureBase(req, params)))
+
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, oauth1Escape(key), oauth1Escape(params.Get(key))))
+	}
+	req.Header.Set("Authorization", "OAuth "+strings.Join(pairs, ", "))
+}
+
+func (o *OAuth1Authorizer) sign(base string) string {
+	key := oauth1Escape(o.consumerSecret) + "&" + oauth1Escape(o.tokenSecret)
+	mac := hmac.New(sha1.New, []byte(key))
+	mac.Write([]byte(base))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// oauth1SignatureBase will create the signature base string from the request and the oauth parameters
+func oauth1SignatureBase(req *http.Request, oauthParams url.Values) string {
+	params := url.Values{}
+	for key, values := range oauthParams {
+		if key == "oauth_signature" {
+			continue
+		}
+		params[key] = append(params[key], values...)
+	}
+	for key, values := range req.URL.Query() {
+		params[key] = append(params[key], values...)
+	}
+	for key, values := range oauth1FormParams(req) {
+		params[key] = append(params[key], values...)
+	}
+
+	// the pairs are sorted by the encoded key and then the encoded value, sorting the joined pairs would put a=b
+	// after a1=b since = sorts after 1
+	pairs := make([][2]string, 0, len(params))
+	for key, values := range params {
+		for _, value := range values {
+			pairs = append(pairs, [2]string{oauth1Escape(key), oauth1Escape(value)})
+		}
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i][0] != pairs[j][0] {
+			return pairs[i][0] < pairs[j][0]
+		}
+		return pairs[i][1] < pairs[j][1]
+	})
+	joined := make([]string, len(pairs))
+	for i, pair := range pairs {
+		joined[i] = pair[0] + "=" + pair[1]
+	}
+
+	return strings.ToUpper(req.Method) + "&" + oauth1Escape(oauth1BaseURL(req.URL)) + "&" + oauth1Escape(strings.Join(joined, "&"))
+}
+
+// oauth1BaseURL is the URL without the query, with a lower case scheme and host and no default port
+func oauth1BaseURL(u *url.URL) string {
+	scheme := strings.ToLower(u.Scheme)
+	host := strings.ToLower(u.Hostname())
+	if port := u.Port(); port != "" && !(scheme == "http" && port == "80") && !(scheme == "https" && port == "443") {
+		host += ":" + port
+	}
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	return scheme + "://" + host + path
+}
+
+// oauth1FormParams will read the parameters of a form body, the body is restored so it can still be sent
+func oauth1FormParams(req *http.Request) url.Values {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil
+	}
+	mediaType, _, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if err != nil || mediaType != oauth1FormContentType {
+		return nil
+	}
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return nil
+	}
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	params, err := url.ParseQuery(string(body))
+	if err != nil {
+		return nil
+	}
+	return params
+}
+
+// oauth1Escape will percent encode everything except the unreserved characters
+func oauth1Escape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z', c >= '0' && c <= '9', c == '-', c == '.', c == '_', c == '~':
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func oauth1Nonce() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 10)
+	}
+	return hex.EncodeToString(b)
+}
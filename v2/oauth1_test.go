@@ -0,0 +1,156 @@
+package twitter
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func oauth1TestAuthorizer() *OAuth1Authorizer {
+	auth := NewOAuth1Authorizer("xvz1evFS4wEEPTGEFPHBog", "kAcSOqF21Fu85e7zjz7ZN2U4ZRhfV3WpwPAoE3Z7kBw",
+		"370773112-GmHxMAgYyLbNEtIKZeRNFsMKPR9EyMZeS9weJAEb", "LswwdoUaIvS8ltyTt5jkRh4J50vUPVVHtR2YPi5kE")
+	auth.nonce = func() string { return "kYjzVBB8Y0ZFabxSWbWovY3uYSQ2pTgmZeNu2VS4cg" }
+	auth.now = func() time.Time { return time.Unix(1318622958, 0) }
+	return auth
+}
+
+func TestOAuth1Authorizer_Add(t *testing.T) {
+	// twitter's published signature example
+	body := "status=Hello%20Ladies%20%2b%20Gentlemen%2c%20a%20signed%20OAuth%20request%21"
+	req, err := http.NewRequest(http.MethodPost, "https://api.twitter.com/1.1/statuses/update.json?include_entities=true", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("request error %v", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	oauth1TestAuthorizer().Add(req)
+
+	header := req.Header.Get("Authorization")
+	want := []string{
+		`oauth_consumer_key="xvz1evFS4wEEPTGEFPHBog"`,
+		`oauth_nonce="kYjzVBB8Y0ZFabxSWbWovY3uYSQ2pTgmZeNu2VS4cg"`,
+		`oauth_signature="hCtSmYh%2BiHYCEqBWrE7C7hYmtUk%3D"`,
+		`oauth_signature_method="HMAC-SHA1"`,
+		`oauth_timestamp="1318622958"`,
+		`oauth_token="370773112-GmHxMAgYyLbNEtIKZeRNFsMKPR9EyMZeS9weJAEb"`,
+		`oauth_version="1.0"`,
+	}
+	if header != "OAuth "+strings.Join(want, ", ") {
+		t.Errorf("OAuth1Authorizer.Add() = %v", header)
+	}
+	got, err := io.ReadAll(req.Body)
+	if err != nil || string(got) != body {
+		t.Errorf("OAuth1Authorizer.Add() body = %s, %v", string(got), err)
+	}
+}
+
+func TestOAuth1SignatureBase(t *testing.T) {
+	tests := []struct {
+		name        string
+		method      string
+		url         string
+		contentType string
+		body        string
+		oauth       url.Values
+		want        string
+	}{
+		{
+			name:        "rfc 5849 example",
+			method:      http.MethodPost,
+			url:         "http://example.com/request?b5=%3D%253D&a3=a&c%40=&a2=r%20b",
+			contentType: "application/x-www-form-urlencoded",
+			body:        "c2&a3=2+q",
+			oauth: url.Values{
+				"oauth_consumer_key":     {"9djdj82h48djs9d2"},
+				"oauth_token":            {"kkk9d7dh3k39sjv7"},
+				"oauth_signature_method": {"HMAC-SHA1"},
+				"oauth_timestamp":        {"137131201"},
+				"oauth_nonce":            {"7d8f3e4a"},
+				"oauth_signature":        {"djosJKDKJSD8743243%2Fjdk33klY%3D"},
+			},
+			want: "POST&http%3A%2F%2Fexample.com%2Frequest&a2%3Dr%2520b%26a3%3D2%2520q%26a3%3Da%26b5%3D%253D%25253D%26c%2540%3D%26c2%3D%26oauth_consumer_key%3D9djdj82h48djs9d2%26oauth_nonce%3D7d8f3e4a%26oauth_signature_method%3DHMAC-SHA1%26oauth_timestamp%3D137131201%26oauth_token%3Dkkk9d7dh3k39sjv7",
+		},
+		{
+			name:   "default port",
+			method: http.MethodGet,
+			url:    "HTTPS://API.Twitter.com:443/2/users/me?user.fields=created_at,description",
+			oauth: url.Values{
+				"oauth_nonce": {"abc"},
+			},
+			want: "GET&https%3A%2F%2Fapi.twitter.com%2F2%2Fusers%2Fme&oauth_nonce%3Dabc%26user.fields%3Dcreated_at%252Cdescription",
+		},
+		{
+			name:   "prefix keys",
+			method: http.MethodGet,
+			url:    "https://api.twitter.com/2/tweets?a1=x&a=z&a=y",
+			oauth: url.Values{
+				"oauth_nonce": {"abc"},
+			},
+			want: "GET&https%3A%2F%2Fapi.twitter.com%2F2%2Ftweets&a%3Dy%26a%3Dz%26a1%3Dx%26oauth_nonce%3Dabc",
+		},
+		{
+			name:        "multipart body",
+			method:      http.MethodPost,
+			url:         "https://upload.twitter.com/2/media/upload",
+			contentType: "multipart/form-data; boundary=abc",
+			body:        "--abc\r\nContent-Disposition: form-data; name=\"media_category\"\r\n\r\ntweet_image\r\n--abc--\r\n",
+			oauth: url.Values{
+				"oauth_nonce": {"abc"},
+			},
+			want: "POST&https%3A%2F%2Fupload.twitter.com%2F2%2Fmedia%2Fupload&oauth_nonce%3Dabc",
+		},
+		{
+			name:        "json body",
+			method:      http.MethodPost,
+			url:         "https://api.twitter.com/2/tweets",
+			contentType: "application/json",
+			body:        `{"text":"Hello World!"}`,
+			oauth: url.Values{
+				"oauth_nonce": {"abc"},
+			},
+			want: "POST&https%3A%2F%2Fapi.twitter.com%2F2%2Ftweets&oauth_nonce%3Dabc",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body io.Reader
+			if tt.body != "" {
+				body = strings.NewReader(tt.body)
+			}
+			req, err := http.NewRequest(tt.method, tt.url, body)
+			if err != nil {
+				t.Fatalf("request error %v", err)
+			}
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			if got := oauth1SignatureBase(req, tt.oauth); got != tt.want {
+				t.Errorf("oauth1SignatureBase() = %v, want %v", got, tt.want)
+			}
+			if tt.body != "" {
+				got, _ := io.ReadAll(req.Body)
+				if string(got) != tt.body {
+					t.Errorf("oauth1SignatureBase() body = %v, want %v", string(got), tt.body)
+				}
+			}
+		})
+	}
+}
+
+func TestOAuth1Escape(t *testing.T) {
+	tests := map[string]string{
+		"Ladies + Gentlemen": "Ladies%20%2B%20Gentlemen",
+		"An encoded string!": "An%20encoded%20string%21",
+		"Dogs, Cats & Mice":  "Dogs%2C%20Cats%20%26%20Mice",
+		"☃":                  "%E2%98%83",
+		"-._~":               "-._~",
+	}
+	for in, want := range tests {
+		if got := oauth1Escape(in); got != want {
+			t.Errorf("oauth1Escape(%q) = %v, want %v", in, got, want)
+		}
+	}
+}
@@ -34,9 +34,20 @@ type AuthorizerV2 struct {
 	clientID     string
 	clientSecret string
 	tokenURL     string
+	revokeURL    string
+	scope        string
 	client       *http.Client
 	callback     TokenRefreshCallback
 	expiresAt    time.Time
+	store        TokenStore
+	refreshing   *refreshCall
+}
+
+// refreshCall is the refresh in flight, the other callers wait for it instead of using the refresh token again
+type refreshCall struct {
+	done  chan struct{}
+	token string
+	err   error
 }
 
 // NewAuthorizerV2 creates a new authorizer with smart auth detection
@@ -49,6 +60,7 @@ func NewAuthorizerV2(accessToken, refreshToken, clientID, clientSecret string, c
 		clientID:     clientID,
 		clientSecret: clientSecret,
 		tokenURL:     "https://api.twitter.com/2/oauth2/token",
+		revokeURL:    "https://api.twitter.com/2/oauth2/revoke",
 		client:       http.DefaultClient,
 		callback:     callback,
 		expiresAt:    time.Now().Add(2 * time.Hour),
@@ -64,6 +76,27 @@ func NewAuthorizerV2(accessToken, refreshToken, clientID, clientSecret string, c
 	return auth
 }
 
+// NewAuthorizerV2WithStore creates a new authorizer with the token loaded from the store
+// The refreshed tokens are compared and swapped into the store, so processes sharing the store
+// will only refresh a refresh token once and pick up the tokens refreshed by the others
+func NewAuthorizerV2WithStore(ctx context.Context, store TokenStore, clientID, clientSecret string, callback TokenRefreshCallback) (*AuthorizerV2, error) {
+	token, err := store.Load(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load token: %w", err)
+	}
+	if token.empty() {
+		return nil, fmt.Errorf("token store is empty: %w", ErrParameter)
+	}
+	
+	auth := NewAuthorizerV2(token.AccessToken, token.RefreshToken, clientID, clientSecret, callback)
+	auth.store = store
+	auth.scope = token.Scope
+	if !token.ExpiresAt.IsZero() {
+		auth.expiresAt = token.ExpiresAt
+	}
+	return auth, nil
+}
+
 // Add implements the Authorizer interface and automatically refreshes tokens when needed
 // If no refresh token is available, works like simple bearer auth
 func (o *AuthorizerV2) Add(req *http.Request) {
@@ -108,11 +141,108 @@ func (o *AuthorizerV2) getValidToken(ctx context.Context) (string, error) {
 }
 
 // refreshAccessToken refreshes the access token using the refresh token
+// Only one refresh is in flight at a time, concurrent callers wait for its result
 func (o *AuthorizerV2) refreshAccessToken(ctx context.Context, refreshToken string) (string, error) {
 	if refreshToken == "" {
 		return "", fmt.Errorf("no refresh token available")
 	}
 	
+	o.mu.Lock()
+	
+	// The refresh token has already been used by another caller
+	if o.refreshToken != refreshToken {
+		token := o.accessToken
+		o.mu.Unlock()
+		return token, nil
+	}
+	
+	if call := o.refreshing; call != nil {
+		o.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-call.done:
+			return call.token, call.err
+		}
+	}
+	
+	call := &refreshCall{
+		done: make(chan struct{}),
+	}
+	o.refreshing = call
+	o.mu.Unlock()
+	
+	call.token, call.err = o.refresh(ctx, refreshToken)
+	
+	o.mu.Lock()
+	o.refreshing = nil
+	o.mu.Unlock()
+	close(call.done)
+	
+	return call.token, call.err
+}
+
+// refresh refreshes the access token, with the token store if there is one
+func (o *AuthorizerV2) refresh(ctx context.Context, refreshToken string) (string, error) {
+	if o.store != nil {
+		return o.refreshWithStore(ctx, refreshToken)
+	}
+	
+	token, err := o.requestRefresh(ctx, refreshToken)
+	if err != nil {
+		return "", err
+	}
+	o.setToken(token)
+	
+	return token.AccessToken, nil
+}
+
+// refreshWithStore refreshes the access token while holding the store lock
+// If another process has already used the refresh token, the stored token is used instead
+func (o *AuthorizerV2) refreshWithStore(ctx context.Context, refreshToken string) (string, error) {
+	unlock, err := o.store.Lock(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to lock token store: %w", err)
+	}
+	defer unlock()
+	
+	stored, err := o.store.Load(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to load token: %w", err)
+	}
+	if stored != nil && stored.RefreshToken != "" && stored.RefreshToken != refreshToken {
+		o.loadToken(stored)
+		return stored.AccessToken, nil
+	}
+	
+	token, err := o.requestRefresh(ctx, refreshToken)
+	if err != nil {
+		return "", err
+	}
+	
+	swapped, err := o.store.CompareAndSwap(ctx, refreshToken, token)
+	if err != nil {
+		return "", fmt.Errorf("failed to store token: %w", err)
+	}
+	if !swapped {
+		// the store does not lock across processes and another one has won the refresh
+		stored, err := o.store.Load(ctx)
+		if err != nil {
+			return "", fmt.Errorf("failed to load token: %w", err)
+		}
+		if stored.empty() {
+			return "", fmt.Errorf("token store is empty")
+		}
+		o.loadToken(stored)
+		return stored.AccessToken, nil
+	}
+	o.setToken(token)
+	
+	return token.AccessToken, nil
+}
+
+// requestRefresh calls the token endpoint with the refresh token
+func (o *AuthorizerV2) requestRefresh(ctx context.Context, refreshToken string) (*OAuth2Token, error) {
 	// Prepare refresh request
 	data := url.Values{}
 	data.Set("grant_type", "refresh_token")
@@ -121,7 +251,7 @@ func (o *AuthorizerV2) refreshAccessToken(ctx context.Context, refreshToken stri
 	
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.tokenURL, strings.NewReader(data.Encode()))
 	if err != nil {
-		return "", fmt.Errorf("failed to create refresh request: %w", err)
+		return nil, fmt.Errorf("failed to create refresh request: %w", err)
 	}
 	
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
@@ -129,46 +259,67 @@ func (o *AuthorizerV2) refreshAccessToken(ctx context.Context, refreshToken stri
 	
 	resp, err := o.client.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("failed to refresh token: %w", err)
+		return nil, fmt.Errorf("failed to refresh token: %w", err)
 	}
 	defer resp.Body.Close()
 	
 	if resp.StatusCode != http.StatusOK {
 		var buf bytes.Buffer
 		buf.ReadFrom(resp.Body)
-		return "", fmt.Errorf("token refresh failed with status %d: %s", resp.StatusCode, buf.String())
+		return nil, fmt.Errorf("token refresh failed with status %d: %s", resp.StatusCode, buf.String())
 	}
 	
 	var tokenResp OAuth2TokenResponse
 	if err := json.NewDecoder(resp.Body).Decode(&tokenResp); err != nil {
-		return "", fmt.Errorf("failed to decode token response: %w", err)
+		return nil, fmt.Errorf("failed to decode token response: %w", err)
 	}
 	
-	// Update tokens with lock
-	o.mu.Lock()
-	o.accessToken = tokenResp.AccessToken
-	if tokenResp.RefreshToken != "" {
-		o.refreshToken = tokenResp.RefreshToken
+	token := &OAuth2Token{
+		AccessToken:  tokenResp.AccessToken,
+		RefreshToken: tokenResp.RefreshToken,
+		Scope:        tokenResp.Scope,
+	}
+	if token.RefreshToken == "" {
+		token.RefreshToken = refreshToken
 	}
 	
 	// Calculate expiry time
 	if tokenResp.ExpiresIn > 0 {
-		o.expiresAt = time.Now().Add(time.Duration(tokenResp.ExpiresIn) * time.Second)
+		token.ExpiresAt = time.Now().Add(time.Duration(tokenResp.ExpiresIn) * time.Second)
 	} else {
 		// Default to 2 hours if no expiry provided
-		o.expiresAt = time.Now().Add(2 * time.Hour)
+		token.ExpiresAt = time.Now().Add(2 * time.Hour)
 	}
-	
-	newAccessToken := o.accessToken
-	newRefreshToken := o.refreshToken
-	o.mu.Unlock()
+	return token, nil
+}
+
+// setToken updates the tokens after this authorizer has refreshed them and calls the callback
+func (o *AuthorizerV2) setToken(token *OAuth2Token) {
+	o.loadToken(token)
 	
 	// Call callback if provided
 	if o.callback != nil {
-		go o.callback(newAccessToken, newRefreshToken)
+		go o.callback(token.AccessToken, token.RefreshToken)
 	}
+}
+
+// loadToken updates the tokens with lock
+func (o *AuthorizerV2) loadToken(token *OAuth2Token) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
 	
-	return newAccessToken, nil
+	o.accessToken = token.AccessToken
+	if token.RefreshToken != "" {
+		o.refreshToken = token.RefreshToken
+	}
+	if token.Scope != "" {
+		o.scope = token.Scope
+	}
+	if !token.ExpiresAt.IsZero() {
+		o.expiresAt = token.ExpiresAt
+	} else {
+		o.expiresAt = time.Now().Add(2 * time.Hour)
+	}
 }
 
 // UpdateTokens allows manual updating of tokens
@@ -191,6 +342,90 @@ func (o *AuthorizerV2) GetTokens() (accessToken, refreshToken string) {
 	return o.accessToken, o.refreshToken
 }
 
+// Scope returns the scopes granted to the tokens, it is empty if the token response did not include them
+func (o *AuthorizerV2) Scope() string {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return o.scope
+}
+
+// HasScope checks if the scope has been granted to the tokens
+func (o *AuthorizerV2) HasScope(scope string) bool {
+	return HasScope(o.Scope(), scope)
+}
+
+// Revoke revokes the refresh and access tokens, the authorizer can not be used afterwards
+func (o *AuthorizerV2) Revoke(ctx context.Context) error {
+	o.mu.RLock()
+	accessToken := o.accessToken
+	refreshToken := o.refreshToken
+	o.mu.RUnlock()
+	
+	if o.clientID == "" {
+		return fmt.Errorf("no client id available to revoke: %w", ErrParameter)
+	}
+	
+	if refreshToken != "" {
+		if err := o.revokeToken(ctx, refreshToken, "refresh_token"); err != nil {
+			return err
+		}
+	}
+	if accessToken != "" {
+		if err := o.revokeToken(ctx, accessToken, "access_token"); err != nil {
+			return err
+		}
+	}
+	
+	o.mu.Lock()
+	o.accessToken = ""
+	o.refreshToken = ""
+	o.scope = ""
+	o.expiresAt = time.Time{}
+	o.mu.Unlock()
+	
+	// Let the other processes know the tokens have been revoked
+	if o.store != nil {
+		if _, err := o.store.CompareAndSwap(ctx, refreshToken, &OAuth2Token{}); err != nil {
+			return fmt.Errorf("failed to store revoked token: %w", err)
+		}
+	}
+	return nil
+}
+
+// revokeToken calls the revoke endpoint for the token
+func (o *AuthorizerV2) revokeToken(ctx context.Context, token, tokenTypeHint string) error {
+	data := url.Values{}
+	data.Set("token", token)
+	data.Set("token_type_hint", tokenTypeHint)
+	data.Set("client_id", o.clientID)
+	
+	if err := oauth2Post(ctx, o.client, o.revokeURL, data, o.clientID, o.clientSecret, nil); err != nil {
+		return fmt.Errorf("failed to revoke %s: %w", tokenTypeHint, err)
+	}
+	return nil
+}
+
+// RefreshUnauthorized implements the UnauthorizedRefresher interface
+// The tokens are refreshed unless the request was sent with an old access token
+func (o *AuthorizerV2) RefreshUnauthorized(ctx context.Context, req *http.Request) bool {
+	o.mu.RLock()
+	accessToken := o.accessToken
+	refreshToken := o.refreshToken
+	o.mu.RUnlock()
+	
+	if refreshToken == "" {
+		return false
+	}
+	
+	// The tokens have been refreshed since the request was sent
+	if req.Header.Get("Authorization") != fmt.Sprintf("Bearer %s", accessToken) {
+		return true
+	}
+	
+	_, err := o.refreshAccessToken(ctx, refreshToken)
+	return err == nil
+}
+
 // ForceRefresh forces a token refresh regardless of expiry time
 func (o *AuthorizerV2) ForceRefresh(ctx context.Context) error {
 	o.mu.RLock()
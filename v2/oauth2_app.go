@@ -0,0 +1,103 @@
+package twitter
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	appOnlyTokenPath      = "oauth2/token"
+	appOnlyInvalidatePath = "oauth2/invalidate_token"
+	appOnlyHost           = "https://api.twitter.com"
+)
+
+// AppOnlyConfig is used to obtain and invalidate app-only bearer tokens with the consumer key and secret
+type AppOnlyConfig struct {
+	// ConsumerKey is the API key of the app
+	ConsumerKey string
+	// ConsumerSecret is the API secret of the app
+	ConsumerSecret string
+	// Host is optional and defaults to https://api.twitter.com
+	Host string
+	// Client is optional and defaults to http.DefaultClient
+	Client *http.Client
+}
+
+func (c *AppOnlyConfig) url(path string) string {
+	host := c.Host
+	if host == "" {
+		host = appOnlyHost
+	}
+	return strings.TrimSuffix(host, "/") + "/" + path
+}
+
+func (c *AppOnlyConfig) client() *http.Client {
+	if c.Client != nil {
+		return c.Client
+	}
+	return http.DefaultClient
+}
+
+func (c *AppOnlyConfig) validate() error {
+	if len(c.ConsumerKey) == 0 || len(c.ConsumerSecret) == 0 {
+		return fmt.Errorf("app only: consumer key and secret are required: %w", ErrParameter)
+	}
+	return nil
+}
+
+// Token will obtain the app-only bearer token with the client credentials grant
+func (c *AppOnlyConfig) Token(ctx context.Context) (string, error) {
+	if err := c.validate(); err != nil {
+		return "", err
+	}
+	data := url.Values{}
+	data.Set("grant_type", "client_credentials")
+
+	token := &OAuth2TokenResponse{}
+	if err := oauth2Post(ctx, c.client(), c.url(appOnlyTokenPath), data, url.QueryEscape(c.ConsumerKey), url.QueryEscape(c.ConsumerSecret), token); err != nil {
+		return "", fmt.Errorf("app only token: %w", err)
+	}
+	if !strings.EqualFold(token.TokenType, "bearer") || len(token.AccessToken) == 0 {
+		return "", fmt.Errorf("app only token: unexpected token type %s", token.TokenType)
+	}
+	return token.AccessToken, nil
+}
+
+// Invalidate will invalidate the app-only bearer token
+func (c *AppOnlyConfig) Invalidate(ctx context.Context, token string) error {
+	if err := c.validate(); err != nil {
+		return err
+	}
+	if len(token) == 0 {
+		return fmt.Errorf("app only invalidate: a token is required: %w", ErrParameter)
+	}
+	data := url.Values{}
+	data.Set("access_token", token)
+
+	if err := oauth2Post(ctx, c.client(), c.url(appOnlyInvalidatePath), data, url.QueryEscape(c.ConsumerKey), url.QueryEscape(c.ConsumerSecret), nil); err != nil {
+		return fmt.Errorf("app only invalidate: %w", err)
+	}
+	return nil
+}
+
+// Authorizer will obtain the app-only bearer token and create a bearer only authorizer with it
+func (c *AppOnlyConfig) Authorizer(ctx context.Context) (*AuthorizerV2, error) {
+	token, err := c.Token(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return NewAuthorizerV2(token, "", "", "", nil), nil
+}
+
+// NewAppOnlyAuthorizer will obtain an app-only bearer token with the consumer key and secret and create
+// a bearer only authorizer with it
+func NewAppOnlyAuthorizer(ctx context.Context, consumerKey, consumerSecret string) (*AuthorizerV2, error) {
+	config := &AppOnlyConfig{
+		ConsumerKey:    consumerKey,
+		ConsumerSecret: consumerSecret,
+	}
+	return config.Authorizer(ctx)
+}
@@ -0,0 +1,131 @@
+package twitter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAppOnlyConfig_Token(t *testing.T) {
+	tests := []struct {
+		name       string
+		code       int
+		body       string
+		want       string
+		wantErr    bool
+		wantOAuth2 bool
+	}{
+		{
+			name: "success",
+			code: http.StatusOK,
+			body: `{"token_type":"bearer","access_token":"AAAA%2FAAA%3DAAAAAAAA"}`,
+			want: "AAAA%2FAAA%3DAAAAAAAA",
+		},
+		{
+			name:    "not a bearer token",
+			code:    http.StatusOK,
+			body:    `{"token_type":"mac","access_token":"AAAA"}`,
+			wantErr: true,
+		},
+		{
+			name:       "invalid credentials",
+			code:       http.StatusForbidden,
+			body:       `{"errors":[{"code":99,"message":"Unable to verify your credentials","label":"authenticity_token_error"}]}`,
+			wantErr:    true,
+			wantOAuth2: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodPost || r.URL.Path != "/oauth2/token" {
+					t.Errorf("Token() %s %s", r.Method, r.URL.Path)
+				}
+				if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != "client_credentials" {
+					t.Errorf("Token() form %v %v", r.PostForm, err)
+				}
+				if key, secret, _ := r.BasicAuth(); key != "consumer_key" || secret != "consumer_secret" {
+					t.Errorf("Token() basic auth %v %v", key, secret)
+				}
+				w.WriteHeader(tt.code)
+				fmt.Fprint(w, tt.body)
+			}))
+			defer server.Close()
+
+			config := &AppOnlyConfig{
+				ConsumerKey:    "consumer_key",
+				ConsumerSecret: "consumer_secret",
+				Host:           server.URL,
+			}
+			auth, err := config.Authorizer(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Authorizer() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			oauth2Err := &OAuth2Error{}
+			if errors.As(err, &oauth2Err) != tt.wantOAuth2 {
+				t.Errorf("Authorizer() error = %v, want OAuth2Error %v", err, tt.wantOAuth2)
+			}
+			if tt.wantErr {
+				return
+			}
+			req, _ := http.NewRequest(http.MethodGet, "https://api.twitter.com/2/tweets/search/recent", nil)
+			auth.Add(req)
+			if got := req.Header.Get("Authorization"); got != "Bearer "+tt.want {
+				t.Errorf("Authorizer() authorization = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAppOnlyConfig_Invalidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		code    int
+		token   string
+		wantErr bool
+	}{
+		{
+			name:  "success",
+			code:  http.StatusOK,
+			token: "AAAA%2FAAA%3DAAAAAAAA",
+		},
+		{
+			name:    "failure",
+			code:    http.StatusForbidden,
+			token:   "AAAA%2FAAA%3DAAAAAAAA",
+			wantErr: true,
+		},
+		{
+			name:    "no token",
+			token:   "",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodPost || r.URL.Path != "/oauth2/invalidate_token" {
+					t.Errorf("Invalidate() %s %s", r.Method, r.URL.Path)
+				}
+				if err := r.ParseForm(); err != nil || r.PostForm.Get("access_token") != tt.token {
+					t.Errorf("Invalidate() form %v %v", r.PostForm, err)
+				}
+				w.WriteHeader(tt.code)
+				fmt.Fprintf(w, `{"access_token":"%s"}`, tt.token)
+			}))
+			defer server.Close()
+
+			config := &AppOnlyConfig{
+				ConsumerKey:    "consumer_key",
+				ConsumerSecret: "consumer_secret",
+				Host:           server.URL,
+			}
+			if err := config.Invalidate(context.Background(), tt.token); (err != nil) != tt.wantErr {
+				t.Errorf("Invalidate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,350 @@
+package twitter
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	oauth2AuthorizeURL    = "https://twitter.com/i/oauth2/authorize"
+	oauth2TokenURL        = "https://api.twitter.com/2/oauth2/token"
+	oauth2RevokeURL       = "https://api.twitter.com/2/oauth2/revoke"
+	pkceChallengeMethod   = "S256"
+	pkceVerifierMinLength = 43
+	pkceVerifierMaxLength = 128
+	pkceRandomBytes       = 32
+)
+
+// PKCEChallenge is the code verifier, code challenge and state of an authorization code with PKCE login
+type PKCEChallenge struct {
+	// Verifier is the code verifier that is sent with the token exchange
+	Verifier string
+	// Challenge is the S256 code challenge of the verifier that is sent with the authorize request
+	Challenge string
+	// State is sent with the authorize request and must match the state of the callback
+	State string
+}
+
+// NewPKCEChallenge will generate a random code verifier and state
+func NewPKCEChallenge() (*PKCEChallenge, error) {
+	verifier, err := pkceRandom()
+	if err != nil {
+		return nil, fmt.Errorf("pkce code verifier: %w", err)
+	}
+	state, err := pkceRandom()
+	if err != nil {
+		return nil, fmt.Errorf("pkce state: %w", err)
+	}
+	return &PKCEChallenge{
+		Verifier:  verifier,
+		Challenge: pkceS256(verifier),
+		State:     state,
+	}, nil
+}
+
+// Verify will check that the code verifier is valid and the code challenge is derived from it
+func (p *PKCEChallenge) Verify() error {
+	if len(p.Verifier) < pkceVerifierMinLength || len(p.Verifier) > pkceVerifierMaxLength {
+		return fmt.Errorf("pkce code verifier length %d must be between %d and %d: %w", len(p.Verifier), pkceVerifierMinLength, pkceVerifierMaxLength, ErrParameter)
+	}
+	for _, r := range p.Verifier {
+		switch {
+		case r >= 'A' && r <= 'Z', r >= 'a' && r <= 'z', r >= '0' && r <= '9', strings.ContainsRune("-._~", r):
+		default:
+			return fmt.Errorf("pkce code verifier has an invalid character %q: %w", r, ErrParameter)
+		}
+	}
+	if p.Challenge != pkceS256(p.Verifier) {
+		return fmt.Errorf("pkce code challenge does not match the verifier: %w", ErrParameter)
+	}
+	return nil
+}
+
+// VerifyState will check that the state of the callback matches the state of the authorize request
+func (p *PKCEChallenge) VerifyState(state string) error {
+	if p.State == "" || subtle.ConstantTimeCompare([]byte(p.State), []byte(state)) != 1 {
+		return ErrOAuth2State
+	}
+	return nil
+}
+
+func pkceRandom() (string, error) {
+	b := make([]byte, pkceRandomBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func pkceS256(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// OAuth2Config is used for the OAuth2 authorization code with PKCE login flow
+type OAuth2Config struct {
+	// ClientID is the OAuth2 client id of the app
+	ClientID string
+	// ClientSecret is optional and only used by confidential clients
+	ClientSecret string
+	// RedirectURL is the callback URL registered with the app
+	RedirectURL string
+	// Scopes are the scopes to request, like BotScopes
+	Scopes []string
+	// AuthorizeURL is optional and defaults to https://twitter.com/i/oauth2/authorize
+	AuthorizeURL string
+	// TokenURL is optional and defaults to https://api.twitter.com/2/oauth2/token
+	TokenURL string
+	// RevokeURL is optional and defaults to https://api.twitter.com/2/oauth2/revoke
+	RevokeURL string
+	// Client is optional and defaults to http.DefaultClient
+	Client *http.Client
+}
+
+func (c *OAuth2Config) authorizeURL() string {
+	if c.AuthorizeURL != "" {
+		return c.AuthorizeURL
+	}
+	return oauth2AuthorizeURL
+}
+
+func (c *OAuth2Config) tokenURL() string {
+	if c.TokenURL != "" {
+		return c.TokenURL
+	}
+	return oauth2TokenURL
+}
+
+func (c *OAuth2Config) revokeURL() string {
+	if c.RevokeURL != "" {
+		return c.RevokeURL
+	}
+	return oauth2RevokeURL
+}
+
+func (c *OAuth2Config) client() *http.Client {
+	if c.Client != nil {
+		return c.Client
+	}
+	return http.DefaultClient
+}
+
+// AuthCodeURL will build the URL that the user is sent to, to authorize the app
+func (c *OAuth2Config) AuthCodeURL(pkce *PKCEChallenge) string {
+	query := url.Values{}
+	query.Set("response_type", "code")
+	query.Set("client_id", c.ClientID)
+	query.Set("redirect_uri", c.RedirectURL)
+	query.Set("scope", ScopeString(c.Scopes))
+	query.Set("state", pkce.State)
+	query.Set("code_challenge", pkce.Challenge)
+	query.Set("code_challenge_method", pkceChallengeMethod)
+
+	authURL := c.authorizeURL()
+	sep := "?"
+	if strings.Contains(authURL, "?") {
+		sep = "&"
+	}
+	return authURL + sep + query.Encode()
+}
+
+// Exchange will exchange the authorization code of the callback for the tokens
+func (c *OAuth2Config) Exchange(ctx context.Context, code string, pkce *PKCEChallenge) (*OAuth2TokenResponse, error) {
+	switch {
+	case len(code) == 0:
+		return nil, fmt.Errorf("oauth2 exchange: a code is required: %w", ErrParameter)
+	case pkce == nil:
+		return nil, fmt.Errorf("oauth2 exchange: a pkce challenge is required: %w", ErrParameter)
+	}
+	if err := pkce.Verify(); err != nil {
+		return nil, fmt.Errorf("oauth2 exchange: %w", err)
+	}
+
+	data := url.Values{}
+	data.Set("grant_type", "authorization_code")
+	data.Set("code", code)
+	data.Set("redirect_uri", c.RedirectURL)
+	data.Set("code_verifier", pkce.Verifier)
+	data.Set("client_id", c.ClientID)
+
+	token := &OAuth2TokenResponse{}
+	if err := oauth2Post(ctx, c.client(), c.tokenURL(), data, c.ClientID, c.ClientSecret, token); err != nil {
+		return nil, fmt.Errorf("oauth2 exchange: %w", err)
+	}
+	return token, nil
+}
+
+// Authorizer will create the authorizer from the token response, it will refresh the tokens using the
+// client credentials of the config
+func (c *OAuth2Config) Authorizer(token *OAuth2TokenResponse, callback TokenRefreshCallback) *AuthorizerV2 {
+	auth := NewAuthorizerV2(token.AccessToken, token.RefreshToken, c.ClientID, c.ClientSecret, callback)
+	// the client credentials are kept to revoke an access token without a refresh token
+	auth.clientID = c.ClientID
+	auth.clientSecret = c.ClientSecret
+	auth.client = c.client()
+	auth.scope = token.Scope
+	auth.revokeURL = c.revokeURL()
+	if token.RefreshToken != "" {
+		auth.tokenURL = c.tokenURL()
+	}
+	if token.ExpiresIn > 0 {
+		auth.expiresAt = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	}
+	return auth
+}
+
+// LoopbackLogin will run the login for a CLI.  The redirect URL must be a loopback address with a port,
+// like http://127.0.0.1:8080/callback, and a server will listen on it for the callback.  The open func
+// is called with the authorize URL, which should be opened in the browser or shown to the user.  The login
+// ends with the first callback that has the state of the login, the other requests are answered with an
+// error and ignored.
+func (c *OAuth2Config) LoopbackLogin(ctx context.Context, open func(authURL string) error) (*OAuth2TokenResponse, error) {
+	redirect, err := url.Parse(c.RedirectURL)
+	if err != nil {
+		return nil, fmt.Errorf("oauth2 loopback redirect url: %w", err)
+	}
+	if redirect.Scheme != "http" || redirect.Port() == "" || !loopbackHost(redirect.Hostname()) {
+		return nil, fmt.Errorf("oauth2 loopback redirect url %s must be http with a loopback host and port: %w", c.RedirectURL, ErrParameter)
+	}
+	pkce, err := NewPKCEChallenge()
+	if err != nil {
+		return nil, err
+	}
+
+	listener, err := net.Listen("tcp", redirect.Host)
+	if err != nil {
+		return nil, fmt.Errorf("oauth2 loopback listen: %w", err)
+	}
+
+	type callback struct {
+		code string
+		err  error
+	}
+	callbacks := make(chan callback, 1)
+	path := redirect.Path
+	if path == "" {
+		path = "/"
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		// the requests without the state of the login, like a favicon or a forged callback, do not end the login
+		switch state := query.Get("state"); {
+		case state == "":
+			http.NotFound(w, r)
+			return
+		case pkce.VerifyState(state) != nil:
+			http.Error(w, "The login state does not match.", http.StatusBadRequest)
+			return
+		}
+		cb := callback{
+			code: query.Get("code"),
+		}
+		switch {
+		case query.Get("error") != "":
+			cb.err = &OAuth2Error{
+				StatusCode:  http.StatusOK,
+				Code:        query.Get("error"),
+				Description: query.Get("error_description"),
+			}
+		case cb.code == "":
+			cb.err = errors.New("oauth2 loopback callback without a code")
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		if cb.err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintln(w, "Login failed, you can close this window.")
+		} else {
+			fmt.Fprintln(w, "Login complete, you can close this window.")
+		}
+		select {
+		case callbacks <- cb:
+		default:
+		}
+	})
+	server := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go server.Serve(listener)
+	defer server.Close()
+
+	if err := open(c.AuthCodeURL(pkce)); err != nil {
+		return nil, fmt.Errorf("oauth2 loopback open: %w", err)
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case cb := <-callbacks:
+		if cb.err != nil {
+			return nil, fmt.Errorf("oauth2 loopback callback: %w", cb.err)
+		}
+		return c.Exchange(ctx, cb.code, pkce)
+	}
+}
+
+func loopbackHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// oauth2Post will post the form to an OAuth2 endpoint and decode the response into the token
+func oauth2Post(ctx context.Context, client *http.Client, endpoint string, data url.Values, clientID, clientSecret string, token interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(data.Encode()))
+	if err != nil {
+		return fmt.Errorf("request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if clientSecret != "" {
+		req.SetBasicAuth(clientID, clientSecret)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("response: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("response read: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		e := &OAuth2Error{}
+		if err := json.Unmarshal(body, e); err != nil || e.Code == "" {
+			e.Code = http.StatusText(resp.StatusCode)
+			e.Description = strings.TrimSpace(string(body))
+		}
+		e.StatusCode = resp.StatusCode
+		return e
+	}
+
+	if token == nil {
+		return nil
+	}
+	if err := json.Unmarshal(body, token); err != nil {
+		return &ResponseDecodeError{
+			Name: "oauth2",
+			Err:  err,
+		}
+	}
+	return nil
+}
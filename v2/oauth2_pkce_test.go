@@ -0,0 +1,304 @@
+package twitter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPKCEChallenge_Verify(t *testing.T) {
+	tests := []struct {
+		name    string
+		pkce    *PKCEChallenge
+		wantErr bool
+	}{
+		{
+			name: "rfc 7636 vector",
+			pkce: &PKCEChallenge{
+				Verifier:  "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk",
+				Challenge: "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM",
+			},
+		},
+		{
+			name: "challenge mismatch",
+			pkce: &PKCEChallenge{
+				Verifier:  "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk",
+				Challenge: "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk",
+			},
+			wantErr: true,
+		},
+		{
+			name: "short verifier",
+			pkce: &PKCEChallenge{
+				Verifier:  "short",
+				Challenge: pkceS256("short"),
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid character",
+			pkce: &PKCEChallenge{
+				Verifier:  strings.Repeat("a", 42) + "+",
+				Challenge: pkceS256(strings.Repeat("a", 42) + "+"),
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.pkce.Verify()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("PKCEChallenge.Verify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrParameter) {
+				t.Errorf("PKCEChallenge.Verify() error = %v, want %v", err, ErrParameter)
+			}
+		})
+	}
+}
+
+func TestNewPKCEChallenge(t *testing.T) {
+	pkce, err := NewPKCEChallenge()
+	if err != nil {
+		t.Fatalf("NewPKCEChallenge() error = %v", err)
+	}
+	if err := pkce.Verify(); err != nil {
+		t.Errorf("NewPKCEChallenge() verify error = %v", err)
+	}
+	other, _ := NewPKCEChallenge()
+	if pkce.Verifier == other.Verifier || pkce.State == other.State {
+		t.Errorf("NewPKCEChallenge() is not random")
+	}
+	if err := pkce.VerifyState(pkce.State); err != nil {
+		t.Errorf("PKCEChallenge.VerifyState() error = %v", err)
+	}
+	if err := pkce.VerifyState(other.State); !errors.Is(err, ErrOAuth2State) {
+		t.Errorf("PKCEChallenge.VerifyState() error = %v, want %v", err, ErrOAuth2State)
+	}
+}
+
+func TestOAuth2Config_AuthCodeURL(t *testing.T) {
+	config := &OAuth2Config{
+		ClientID:    "client_id",
+		RedirectURL: "https://www.example.com/callback",
+		Scopes:      []string{ScopeTweetRead, ScopeUsersRead, ScopeOfflineAccess},
+	}
+	pkce := &PKCEChallenge{
+		Verifier:  "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk",
+		Challenge: "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM",
+		State:     "state123",
+	}
+	authURL, err := url.Parse(config.AuthCodeURL(pkce))
+	if err != nil {
+		t.Fatalf("OAuth2Config.AuthCodeURL() error = %v", err)
+	}
+	if authURL.Host != "twitter.com" || authURL.Path != "/i/oauth2/authorize" {
+		t.Errorf("OAuth2Config.AuthCodeURL() = %v", authURL)
+	}
+	want := map[string]string{
+		"response_type":         "code",
+		"client_id":             "client_id",
+		"redirect_uri":          "https://www.example.com/callback",
+		"scope":                 "tweet.read users.read offline.access",
+		"state":                 "state123",
+		"code_challenge":        "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM",
+		"code_challenge_method": "S256",
+	}
+	for key, value := range want {
+		if got := authURL.Query().Get(key); got != value {
+			t.Errorf("OAuth2Config.AuthCodeURL() %s = %v, want %v", key, got, value)
+		}
+	}
+}
+
+func TestOAuth2Config_Exchange(t *testing.T) {
+	tests := []struct {
+		name       string
+		secret     string
+		code       int
+		body       string
+		wantErr    bool
+		wantOAuth2 bool
+	}{
+		{
+			name:   "public client",
+			code:   http.StatusOK,
+			body:   `{"token_type":"bearer","expires_in":7200,"access_token":"access123","scope":"tweet.read users.read offline.access","refresh_token":"refresh456"}`,
+			secret: "",
+		},
+		{
+			name:   "confidential client",
+			code:   http.StatusOK,
+			body:   `{"token_type":"bearer","expires_in":7200,"access_token":"access123","scope":"tweet.read users.read offline.access","refresh_token":"refresh456"}`,
+			secret: "client_secret",
+		},
+		{
+			name:       "invalid grant",
+			code:       http.StatusBadRequest,
+			body:       `{"error":"invalid_request","error_description":"Value passed for the authorization code was invalid."}`,
+			wantErr:    true,
+			wantOAuth2: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pkce, _ := NewPKCEChallenge()
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if err := r.ParseForm(); err != nil {
+					t.Errorf("Exchange() form error %v", err)
+				}
+				want := map[string]string{
+					"grant_type":    "authorization_code",
+					"code":          "code123",
+					"redirect_uri":  "https://www.example.com/callback",
+					"code_verifier": pkce.Verifier,
+					"client_id":     "client_id",
+				}
+				for key, value := range want {
+					if got := r.PostForm.Get(key); got != value {
+						t.Errorf("Exchange() form %s = %v, want %v", key, got, value)
+					}
+				}
+				id, secret, has := r.BasicAuth()
+				if has != (tt.secret != "") || (has && (id != "client_id" || secret != tt.secret)) {
+					t.Errorf("Exchange() basic auth %v %v %v", id, secret, has)
+				}
+				w.WriteHeader(tt.code)
+				fmt.Fprint(w, tt.body)
+			}))
+			defer server.Close()
+
+			config := &OAuth2Config{
+				ClientID:     "client_id",
+				ClientSecret: tt.secret,
+				RedirectURL:  "https://www.example.com/callback",
+				TokenURL:     server.URL,
+			}
+			token, err := config.Exchange(context.Background(), "code123", pkce)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Exchange() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			oauth2Err := &OAuth2Error{}
+			if errors.As(err, &oauth2Err) != tt.wantOAuth2 {
+				t.Errorf("Exchange() error = %v, want OAuth2Error %v", err, tt.wantOAuth2)
+			}
+			if tt.wantErr {
+				return
+			}
+			if token.AccessToken != "access123" || token.RefreshToken != "refresh456" || !HasScope(token.Scope, ScopeOfflineAccess) {
+				t.Errorf("Exchange() = %+v", token)
+			}
+			auth := config.Authorizer(token, nil)
+			if access, refresh := auth.GetTokens(); access != "access123" || refresh != "refresh456" {
+				t.Errorf("Authorizer() tokens = %v, %v", access, refresh)
+			}
+			if auth.tokenURL != server.URL {
+				t.Errorf("Authorizer() token url = %v", auth.tokenURL)
+			}
+		})
+	}
+}
+
+func TestOAuth2Config_LoopbackLogin(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen error %v", err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(OAuth2TokenResponse{
+			AccessToken:  "access123",
+			RefreshToken: "refresh456",
+			TokenType:    "bearer",
+			ExpiresIn:    7200,
+		})
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name string
+		// ignored are the paths and queries of the requests before the callback and their status codes
+		ignored map[string]int
+	}{
+		{
+			name: "success",
+		},
+		{
+			name: "ignore the requests without the state",
+			ignored: map[string]int{
+				"/callback?code=forged&state=forged": http.StatusBadRequest,
+				"/callback?code=forged":              http.StatusNotFound,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &OAuth2Config{
+				ClientID:    "client_id",
+				RedirectURL: "http://" + addr + "/callback",
+				Scopes:      BotScopes,
+				TokenURL:    server.URL,
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			statuses := make(chan error, 1)
+			token, err := config.LoopbackLogin(ctx, func(authURL string) error {
+				u, err := url.Parse(authURL)
+				if err != nil {
+					return err
+				}
+				callback := fmt.Sprintf("%s?code=code123&state=%s", config.RedirectURL, url.QueryEscape(u.Query().Get("state")))
+				go func() {
+					for path, want := range tt.ignored {
+						resp, err := http.Get("http://" + addr + path)
+						if err != nil {
+							statuses <- err
+							return
+						}
+						resp.Body.Close()
+						if resp.StatusCode != want {
+							statuses <- fmt.Errorf("%s status %d, want %d", path, resp.StatusCode, want)
+							return
+						}
+					}
+					statuses <- nil
+					resp, err := http.Get(callback)
+					if err == nil {
+						resp.Body.Close()
+					}
+				}()
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("LoopbackLogin() error = %v", err)
+			}
+			if token.AccessToken != "access123" {
+				t.Errorf("LoopbackLogin() = %+v", token)
+			}
+			if err := <-statuses; err != nil {
+				t.Errorf("LoopbackLogin() ignored request %v", err)
+			}
+		})
+	}
+}
+
+func TestOAuth2Config_LoopbackLoginRedirect(t *testing.T) {
+	config := &OAuth2Config{
+		ClientID:    "client_id",
+		RedirectURL: "https://www.example.com/callback",
+	}
+	_, err := config.LoopbackLogin(context.Background(), func(string) error { return nil })
+	if !errors.Is(err, ErrParameter) {
+		t.Errorf("LoopbackLogin() error = %v, want %v", err, ErrParameter)
+	}
+}
@@ -3,9 +3,13 @@ package twitter
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -105,4 +109,263 @@ func TestAuthorizerV2_NoRefreshToken(t *testing.T) {
 	if !strings.Contains(err.Error(), "no refresh token available") {
 		t.Errorf("Expected error about no refresh token, got: %v", err)
 	}
-}
\ No newline at end of file
+}
+func TestAuthorizerV2_SingleFlightRefresh(t *testing.T) {
+	var refreshes int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.PostForm.Get("refresh_token") != "old_refresh" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		atomic.AddInt32(&refreshes, 1)
+		time.Sleep(50 * time.Millisecond)
+		json.NewEncoder(w).Encode(OAuth2TokenResponse{
+			AccessToken:  "new_access_token",
+			RefreshToken: "new_refresh_token",
+			TokenType:    "Bearer",
+			ExpiresIn:    7200,
+		})
+	}))
+	defer server.Close()
+
+	auth := NewAuthorizerV2("old_access", "old_refresh", "client_id", "client_secret", nil)
+	auth.tokenURL = server.URL
+	auth.expiresAt = time.Now()
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req, _ := http.NewRequest("GET", "https://api.twitter.com/2/users/me", nil)
+			auth.Add(req)
+			if got := req.Header.Get("Authorization"); got != "Bearer new_access_token" {
+				t.Errorf("Expected Authorization header to be 'Bearer new_access_token', got '%s'", got)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if refreshes != 1 {
+		t.Errorf("Expected 1 refresh, got %d", refreshes)
+	}
+}
+
+func TestClient_UnauthorizedRefresh(t *testing.T) {
+	tests := []struct {
+		name         string
+		refreshToken string
+		unauthorized int
+		wantCallouts int
+		wantErr      bool
+	}{
+		{
+			name:         "refreshed and replayed",
+			refreshToken: "old_refresh",
+			unauthorized: 1,
+			wantCallouts: 2,
+		},
+		{
+			name:         "replayed once",
+			refreshToken: "old_refresh",
+			unauthorized: 2,
+			wantCallouts: 2,
+			wantErr:      true,
+		},
+		{
+			name:         "bearer only",
+			refreshToken: "",
+			unauthorized: 1,
+			wantCallouts: 1,
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				json.NewEncoder(w).Encode(OAuth2TokenResponse{
+					AccessToken:  "new_access_token",
+					RefreshToken: "new_refresh_token",
+					TokenType:    "Bearer",
+					ExpiresIn:    7200,
+				})
+			}))
+			defer server.Close()
+
+			auth := NewAuthorizerV2("old_access", tt.refreshToken, "client_id", "client_secret", nil)
+			if tt.refreshToken != "" {
+				auth.tokenURL = server.URL
+			}
+
+			callouts := 0
+			client := &Client{
+				Authorizer: auth,
+				Host:       "https://www.test.com",
+				Client: mockHTTPClient(func(req *http.Request) *http.Response {
+					callouts++
+					body, err := io.ReadAll(req.Body)
+					if err != nil || !strings.Contains(string(body), "Hello World!") {
+						t.Errorf("Expected the request body on callout %d, got '%s'", callouts, string(body))
+					}
+					if values := req.Header.Values("Authorization"); len(values) != 1 {
+						t.Errorf("Expected one Authorization header, got %v", values)
+					}
+					if callouts <= tt.unauthorized {
+						return &http.Response{
+							StatusCode: http.StatusUnauthorized,
+							Status:     http.StatusText(http.StatusUnauthorized),
+							Body:       io.NopCloser(strings.NewReader(`{"title":"Unauthorized","type":"about:blank","status":401,"detail":"Unauthorized"}`)),
+							Header:     http.Header{},
+							Request:    req,
+						}
+					}
+					if got := req.Header.Get("Authorization"); got != "Bearer new_access_token" {
+						t.Errorf("Expected the refreshed token, got '%s'", got)
+					}
+					return &http.Response{
+						StatusCode: http.StatusCreated,
+						Body:       io.NopCloser(strings.NewReader(`{"data":{"id":"1445880548472328192","text":"Hello World!"}}`)),
+						Header:     http.Header{},
+					}
+				}),
+			}
+
+			_, err := client.CreateTweet(context.Background(), CreateTweetRequest{Text: "Hello World!"})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CreateTweet() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if callouts != tt.wantCallouts {
+				t.Errorf("CreateTweet() callouts = %d, want %d", callouts, tt.wantCallouts)
+			}
+		})
+	}
+}
+
+func TestAuthorizerV2_Revoke(t *testing.T) {
+	tests := []struct {
+		name         string
+		refreshToken string
+		clientID     string
+		code         int
+		wantRevoked  []string
+		wantErr      bool
+	}{
+		{
+			name:         "access and refresh tokens",
+			refreshToken: "refresh_token",
+			clientID:     "client_id",
+			code:         http.StatusOK,
+			wantRevoked:  []string{"refresh_token:refresh_token", "access_token:access_token"},
+		},
+		{
+			name:         "revoke failure",
+			refreshToken: "refresh_token",
+			clientID:     "client_id",
+			code:         http.StatusBadRequest,
+			wantRevoked:  []string{"refresh_token:refresh_token"},
+			wantErr:      true,
+		},
+		{
+			name:         "bearer only",
+			refreshToken: "",
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var revoked []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				r.ParseForm()
+				revoked = append(revoked, r.PostForm.Get("token_type_hint")+":"+r.PostForm.Get("token"))
+				if r.PostForm.Get("client_id") != tt.clientID {
+					t.Errorf("Expected client_id '%s', got '%s'", tt.clientID, r.PostForm.Get("client_id"))
+				}
+				w.WriteHeader(tt.code)
+				if tt.code != http.StatusOK {
+					w.Write([]byte(`{"error":"invalid_request","error_description":"Value passed for the token was invalid."}`))
+					return
+				}
+				w.Write([]byte(`{"revoked":true}`))
+			}))
+			defer server.Close()
+
+			auth := NewAuthorizerV2("access_token", tt.refreshToken, tt.clientID, "client_secret", nil)
+			auth.revokeURL = server.URL
+
+			err := auth.Revoke(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Revoke() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if strings.Join(revoked, ",") != strings.Join(tt.wantRevoked, ",") {
+				t.Errorf("Revoke() revoked = %v, want %v", revoked, tt.wantRevoked)
+			}
+			if tt.wantErr {
+				return
+			}
+			if access, refresh := auth.GetTokens(); access != "" || refresh != "" {
+				t.Errorf("Expected the tokens to be cleared, got '%s' '%s'", access, refresh)
+			}
+		})
+	}
+}
+
+func TestAuthorizerV2_RevokeStore(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"revoked":true}`))
+	}))
+	defer server.Close()
+
+	store := NewMemoryTokenStore(&OAuth2Token{
+		AccessToken:  "access_token",
+		RefreshToken: "refresh_token",
+	})
+	auth, err := NewAuthorizerV2WithStore(context.Background(), store, "client_id", "client_secret", nil)
+	if err != nil {
+		t.Fatalf("NewAuthorizerV2WithStore() error = %v", err)
+	}
+	auth.revokeURL = server.URL
+	if err := auth.Revoke(context.Background()); err != nil {
+		t.Fatalf("Revoke() error = %v", err)
+	}
+
+	// the revoked tokens are not loaded by another authorizer
+	if _, err := NewAuthorizerV2WithStore(context.Background(), store, "client_id", "client_secret", nil); !errors.Is(err, ErrParameter) {
+		t.Errorf("NewAuthorizerV2WithStore() after revoke error = %v, want %v", err, ErrParameter)
+	}
+}
+
+func TestAuthorizerV2_Scope(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(OAuth2TokenResponse{
+			AccessToken:  "new_access_token",
+			RefreshToken: "new_refresh_token",
+			TokenType:    "Bearer",
+			ExpiresIn:    7200,
+			Scope:        "tweet.read users.read tweet.write offline.access",
+		})
+	}))
+	defer server.Close()
+
+	config := &OAuth2Config{
+		ClientID: "client_id",
+		TokenURL: server.URL,
+	}
+	auth := config.Authorizer(&OAuth2TokenResponse{
+		AccessToken:  "access_token",
+		RefreshToken: "refresh_token",
+		ExpiresIn:    7200,
+		Scope:        "tweet.read users.read offline.access",
+	}, nil)
+
+	if !auth.HasScope(ScopeTweetRead) || auth.HasScope(ScopeTweetWrite) {
+		t.Errorf("Expected the granted scopes of the token response, got '%s'", auth.Scope())
+	}
+
+	if err := auth.ForceRefresh(context.Background()); err != nil {
+		t.Fatalf("Expected force refresh to succeed, got error: %v", err)
+	}
+	if !auth.HasScope(ScopeTweetWrite) || len(SplitScopes(auth.Scope())) != 4 {
+		t.Errorf("Expected the granted scopes of the refresh, got '%s'", auth.Scope())
+	}
+}
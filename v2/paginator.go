@@ -0,0 +1,613 @@
+package twitter
+
+import (
+	"context"
+	"time"
+)
+
+// PaginationOpts are the options used to walk the pages of a callout
+type PaginationOpts struct {
+	// Token will resume the pagination from a previously saved next token
+	Token string
+	// Offset is the number of items of the token page that have already been returned, from a previously saved offset
+	Offset int
+	// MaxItems will stop the pagination once the number of items have been returned, zero is unlimited
+	MaxItems int
+	// MaxDuration will stop the pagination once the duration has elapsed from the first page, zero is unlimited
+	MaxDuration time.Duration
+}
+
+// paginator contains the common logic of walking the next tokens of a callout
+type paginator struct {
+	opts      PaginationOpts
+	token     string
+	offset    int
+	started   bool
+	done      bool
+	items     int
+	pages     int
+	deadline  time.Time
+	rateLimit *RateLimit
+	err       error
+}
+
+func newPaginator(opts PaginationOpts) paginator {
+	return paginator{
+		opts:   opts,
+		token:  opts.Token,
+		offset: opts.Offset,
+	}
+}
+
+// paginatedPage is a page of items that the paginator can cut
+type paginatedPage interface {
+	count() int
+	slice(from, to int)
+	nextToken() string
+	rateLimit() *RateLimit
+}
+
+// more will return if there is another page to be fetched
+func (p *paginator) more(ctx context.Context) bool {
+	switch {
+	case p.done || p.err != nil:
+		return false
+	case p.started && len(p.token) == 0:
+		p.done = true
+		return false
+	case p.opts.MaxItems > 0 && p.items >= p.opts.MaxItems:
+		p.done = true
+		return false
+	case !p.deadline.IsZero() && time.Now().After(p.deadline):
+		p.done = true
+		return false
+	default:
+	}
+	if err := ctx.Err(); err != nil {
+		p.err = err
+		return false
+	}
+	if !p.started {
+		p.started = true
+		if p.opts.MaxDuration > 0 {
+			p.deadline = time.Now().Add(p.opts.MaxDuration)
+		}
+	}
+	return true
+}
+
+// next will fetch the page of the token, skip the items already returned from it and cut it to the max items.
+// When the page is cut, the token is kept with the offset of the items returned, so the pagination resumes with
+// the rest of the page.
+func (p *paginator) next(ctx context.Context, fetch func(ctx context.Context, token string) (paginatedPage, error)) bool {
+	if !p.more(ctx) {
+		return false
+	}
+	token := p.token
+	page, err := fetch(ctx, token)
+	if err != nil {
+		p.fail(err)
+		return false
+	}
+	count := page.count()
+	from := p.offset
+	if from > count {
+		from = count
+	}
+	to := count
+	if p.opts.MaxItems > 0 && p.items+to-from > p.opts.MaxItems {
+		to = from + p.opts.MaxItems - p.items
+	}
+	page.slice(from, to)
+
+	p.pages++
+	p.items += to - from
+	p.rateLimit = page.rateLimit()
+	if to < count {
+		p.offset = to
+		return true
+	}
+	p.token = page.nextToken()
+	p.offset = 0
+	return true
+}
+
+func (p *paginator) fail(err error) {
+	p.err = err
+}
+
+// NextToken returns the token of the next page.  This can be saved with the Offset and used to resume the pagination.
+func (p *paginator) NextToken() string {
+	return p.token
+}
+
+// Offset returns the number of items of the next token page that have already been returned, it is only set when
+// the last page was cut to the max items
+func (p *paginator) Offset() int {
+	return p.offset
+}
+
+// RateLimit returns the rate limits of the last page
+func (p *paginator) RateLimit() *RateLimit {
+	return p.rateLimit
+}
+
+// Items returns the number of items that have been returned
+func (p *paginator) Items() int {
+	return p.items
+}
+
+// Pages returns the number of pages that have been returned
+func (p *paginator) Pages() int {
+	return p.pages
+}
+
+// Err returns the error that stopped the pagination
+func (p *paginator) Err() error {
+	return p.err
+}
+
+// TweetPage is a single page of tweets
+type TweetPage struct {
+	Raw       *TweetRaw
+	NextToken string
+	RateLimit *RateLimit
+}
+
+func (p *TweetPage) count() int {
+	return len(p.Raw.Tweets)
+}
+
+func (p *TweetPage) slice(from, to int) {
+	p.Raw.Tweets = p.Raw.Tweets[from:to]
+}
+
+func (p *TweetPage) nextToken() string {
+	return p.NextToken
+}
+
+func (p *TweetPage) rateLimit() *RateLimit {
+	return p.RateLimit
+}
+
+type tweetPageFunc func(ctx context.Context, token string) (*TweetPage, error)
+
+// TweetPaginator will walk all of the pages of a tweet callout
+//
+//	p := client.UserTweetTimelinePaginator(userID, opts, twitter.PaginationOpts{MaxItems: 500})
+//	for p.Next(ctx) {
+//		page := p.Page()
+//	}
+//	if err := p.Err(); err != nil {
+//	}
+type TweetPaginator struct {
+	paginator
+	fetch    tweetPageFunc
+	page     *TweetPage
+	includes *TweetRawIncludes
+}
+
+func newTweetPaginator(opts PaginationOpts, fetch tweetPageFunc) *TweetPaginator {
+	return &TweetPaginator{
+		paginator: newPaginator(opts),
+		fetch:     fetch,
+		includes:  &TweetRawIncludes{},
+	}
+}
+
+// Next will fetch the next page, returning false when the pages are done or there is an error
+func (t *TweetPaginator) Next(ctx context.Context) bool {
+	var page *TweetPage
+	if !t.next(ctx, func(ctx context.Context, token string) (paginatedPage, error) {
+		var err error
+		if page, err = t.fetch(ctx, token); err != nil {
+			return nil, err
+		}
+		if page.Raw == nil {
+			page.Raw = &TweetRaw{}
+		}
+		return page, nil
+	}) {
+		return false
+	}
+	t.includes.merge(page.Raw.Includes)
+	t.page = page
+	return true
+}
+
+// Page returns the current page
+func (t *TweetPaginator) Page() *TweetPage {
+	return t.page
+}
+
+// Includes returns the includes merged from all of the pages
+func (t *TweetPaginator) Includes() *TweetRawIncludes {
+	return t.includes
+}
+
+// UserPage is a single page of users
+type UserPage struct {
+	Raw       *UserRaw
+	NextToken string
+	RateLimit *RateLimit
+}
+
+func (p *UserPage) count() int {
+	return len(p.Raw.Users)
+}
+
+func (p *UserPage) slice(from, to int) {
+	p.Raw.Users = p.Raw.Users[from:to]
+}
+
+func (p *UserPage) nextToken() string {
+	return p.NextToken
+}
+
+func (p *UserPage) rateLimit() *RateLimit {
+	return p.RateLimit
+}
+
+type userPageFunc func(ctx context.Context, token string) (*UserPage, error)
+
+// UserPaginator will walk all of the pages of an user callout
+type UserPaginator struct {
+	paginator
+	fetch    userPageFunc
+	page     *UserPage
+	includes *UserRawIncludes
+}
+
+func newUserPaginator(opts PaginationOpts, fetch userPageFunc) *UserPaginator {
+	return &UserPaginator{
+		paginator: newPaginator(opts),
+		fetch:     fetch,
+		includes:  &UserRawIncludes{},
+	}
+}
+
+// Next will fetch the next page, returning false when the pages are done or there is an error
+func (u *UserPaginator) Next(ctx context.Context) bool {
+	var page *UserPage
+	if !u.next(ctx, func(ctx context.Context, token string) (paginatedPage, error) {
+		var err error
+		if page, err = u.fetch(ctx, token); err != nil {
+			return nil, err
+		}
+		if page.Raw == nil {
+			page.Raw = &UserRaw{}
+		}
+		return page, nil
+	}) {
+		return false
+	}
+	u.includes.merge(page.Raw.Includes)
+	u.page = page
+	return true
+}
+
+// Page returns the current page
+func (u *UserPaginator) Page() *UserPage {
+	return u.page
+}
+
+// Includes returns the includes merged from all of the pages
+func (u *UserPaginator) Includes() *UserRawIncludes {
+	return u.includes
+}
+
+// ListPage is a single page of lists
+type ListPage struct {
+	Raw       *UserListRaw
+	NextToken string
+	RateLimit *RateLimit
+}
+
+func (p *ListPage) count() int {
+	return len(p.Raw.Lists)
+}
+
+func (p *ListPage) slice(from, to int) {
+	p.Raw.Lists = p.Raw.Lists[from:to]
+}
+
+func (p *ListPage) nextToken() string {
+	return p.NextToken
+}
+
+func (p *ListPage) rateLimit() *RateLimit {
+	return p.RateLimit
+}
+
+type listPageFunc func(ctx context.Context, token string) (*ListPage, error)
+
+// ListPaginator will walk all of the pages of a list callout
+type ListPaginator struct {
+	paginator
+	fetch    listPageFunc
+	page     *ListPage
+	includes *ListRawIncludes
+}
+
+func newListPaginator(opts PaginationOpts, fetch listPageFunc) *ListPaginator {
+	return &ListPaginator{
+		paginator: newPaginator(opts),
+		fetch:     fetch,
+		includes:  &ListRawIncludes{},
+	}
+}
+
+// Next will fetch the next page, returning false when the pages are done or there is an error
+func (l *ListPaginator) Next(ctx context.Context) bool {
+	var page *ListPage
+	if !l.next(ctx, func(ctx context.Context, token string) (paginatedPage, error) {
+		var err error
+		if page, err = l.fetch(ctx, token); err != nil {
+			return nil, err
+		}
+		if page.Raw == nil {
+			page.Raw = &UserListRaw{}
+		}
+		return page, nil
+	}) {
+		return false
+	}
+	l.includes.merge(page.Raw.Includes)
+	l.page = page
+	return true
+}
+
+// Page returns the current page
+func (l *ListPaginator) Page() *ListPage {
+	return l.page
+}
+
+// Includes returns the includes merged from all of the pages
+func (l *ListPaginator) Includes() *ListRawIncludes {
+	return l.includes
+}
+
+// DMEventPage is a single page of direct message events
+type DMEventPage struct {
+	Events    []DMEvent
+	Includes  *DMIncludes
+	NextToken string
+	RateLimit *RateLimit
+}
+
+func (p *DMEventPage) count() int {
+	return len(p.Events)
+}
+
+func (p *DMEventPage) slice(from, to int) {
+	p.Events = p.Events[from:to]
+}
+
+func (p *DMEventPage) nextToken() string {
+	return p.NextToken
+}
+
+func (p *DMEventPage) rateLimit() *RateLimit {
+	return p.RateLimit
+}
+
+type dmEventPageFunc func(ctx context.Context, token string) (*DMEventPage, error)
+
+// DMEventPaginator will walk all of the pages of a direct message event callout
+type DMEventPaginator struct {
+	paginator
+	fetch    dmEventPageFunc
+	page     *DMEventPage
+	includes *DMIncludes
+}
+
+func newDMEventPaginator(opts PaginationOpts, fetch dmEventPageFunc) *DMEventPaginator {
+	return &DMEventPaginator{
+		paginator: newPaginator(opts),
+		fetch:     fetch,
+		includes:  &DMIncludes{},
+	}
+}
+
+// Next will fetch the next page, returning false when the pages are done or there is an error
+func (d *DMEventPaginator) Next(ctx context.Context) bool {
+	var page *DMEventPage
+	if !d.next(ctx, func(ctx context.Context, token string) (paginatedPage, error) {
+		var err error
+		if page, err = d.fetch(ctx, token); err != nil {
+			return nil, err
+		}
+		return page, nil
+	}) {
+		return false
+	}
+	d.includes.merge(page.Includes)
+	d.page = page
+	return true
+}
+
+// Page returns the current page
+func (d *DMEventPaginator) Page() *DMEventPage {
+	return d.page
+}
+
+// Includes returns the includes merged from all of the pages
+func (d *DMEventPaginator) Includes() *DMIncludes {
+	return d.includes
+}
+
+// DMConversationPage is a single page of direct message conversations
+type DMConversationPage struct {
+	Conversations []DMConversation
+	Includes      *DMIncludes
+	NextToken     string
+	RateLimit     *RateLimit
+}
+
+func (p *DMConversationPage) count() int {
+	return len(p.Conversations)
+}
+
+func (p *DMConversationPage) slice(from, to int) {
+	p.Conversations = p.Conversations[from:to]
+}
+
+func (p *DMConversationPage) nextToken() string {
+	return p.NextToken
+}
+
+func (p *DMConversationPage) rateLimit() *RateLimit {
+	return p.RateLimit
+}
+
+type dmConversationPageFunc func(ctx context.Context, token string) (*DMConversationPage, error)
+
+// DMConversationPaginator will walk all of the pages of a direct message conversation callout
+type DMConversationPaginator struct {
+	paginator
+	fetch    dmConversationPageFunc
+	page     *DMConversationPage
+	includes *DMIncludes
+}
+
+func newDMConversationPaginator(opts PaginationOpts, fetch dmConversationPageFunc) *DMConversationPaginator {
+	return &DMConversationPaginator{
+		paginator: newPaginator(opts),
+		fetch:     fetch,
+		includes:  &DMIncludes{},
+	}
+}
+
+// Next will fetch the next page, returning false when the pages are done or there is an error
+func (d *DMConversationPaginator) Next(ctx context.Context) bool {
+	var page *DMConversationPage
+	if !d.next(ctx, func(ctx context.Context, token string) (paginatedPage, error) {
+		var err error
+		if page, err = d.fetch(ctx, token); err != nil {
+			return nil, err
+		}
+		return page, nil
+	}) {
+		return false
+	}
+	d.includes.merge(page.Includes)
+	d.page = page
+	return true
+}
+
+// Page returns the current page
+func (d *DMConversationPaginator) Page() *DMConversationPage {
+	return d.page
+}
+
+// Includes returns the includes merged from all of the pages
+func (d *DMConversationPaginator) Includes() *DMIncludes {
+	return d.includes
+}
+
+func (t *TweetRawIncludes) merge(other *TweetRawIncludes) {
+	if other == nil {
+		return
+	}
+	tweets := t.TweetsByID()
+	for _, tweet := range other.Tweets {
+		if _, has := tweets[tweet.ID]; !has {
+			tweets[tweet.ID] = tweet
+			t.Tweets = append(t.Tweets, tweet)
+		}
+	}
+	users := t.UsersByID()
+	for _, user := range other.Users {
+		if _, has := users[user.ID]; !has {
+			users[user.ID] = user
+			t.Users = append(t.Users, user)
+		}
+	}
+	t.userNames = nil
+	places := t.PlacesByID()
+	for _, place := range other.Places {
+		if _, has := places[place.ID]; !has {
+			places[place.ID] = place
+			t.Places = append(t.Places, place)
+		}
+	}
+	media := t.MediaByKeys()
+	for _, m := range other.Media {
+		if _, has := media[m.Key]; !has {
+			media[m.Key] = m
+			t.Media = append(t.Media, m)
+		}
+	}
+	polls := t.PollsByID()
+	for _, poll := range other.Polls {
+		if _, has := polls[poll.ID]; !has {
+			polls[poll.ID] = poll
+			t.Polls = append(t.Polls, poll)
+		}
+	}
+}
+
+func (u *UserRawIncludes) merge(other *UserRawIncludes) {
+	if other == nil {
+		return
+	}
+	tweets := u.TweetsByID()
+	for _, tweet := range other.Tweets {
+		if _, has := tweets[tweet.ID]; !has {
+			tweets[tweet.ID] = tweet
+			u.Tweets = append(u.Tweets, tweet)
+		}
+	}
+}
+
+func (l *ListRawIncludes) merge(other *ListRawIncludes) {
+	if other == nil {
+		return
+	}
+	users := map[string]bool{}
+	for _, user := range l.Users {
+		users[user.ID] = true
+	}
+	for _, user := range other.Users {
+		if !users[user.ID] {
+			users[user.ID] = true
+			l.Users = append(l.Users, user)
+		}
+	}
+}
+
+func (d *DMIncludes) merge(other *DMIncludes) {
+	if other == nil {
+		return
+	}
+	users := map[string]bool{}
+	for _, user := range d.Users {
+		users[user.ID] = true
+	}
+	for _, user := range other.Users {
+		if !users[user.ID] {
+			users[user.ID] = true
+			d.Users = append(d.Users, user)
+		}
+	}
+	tweets := map[string]bool{}
+	for _, tweet := range d.Tweets {
+		tweets[tweet.ID] = true
+	}
+	for _, tweet := range other.Tweets {
+		if !tweets[tweet.ID] {
+			tweets[tweet.ID] = true
+			d.Tweets = append(d.Tweets, tweet)
+		}
+	}
+	media := map[string]bool{}
+	for _, m := range d.Media {
+		media[m.Key] = true
+	}
+	for _, m := range other.Media {
+		if !media[m.Key] {
+			media[m.Key] = true
+			d.Media = append(d.Media, m)
+		}
+	}
+}
@@ -0,0 +1,399 @@
+package twitter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// QueryTier is the API access level, it determines the available query operators and the query length
+type QueryTier int
+
+const (
+	// QueryTierEssential is the essential access level
+	QueryTierEssential QueryTier = iota
+	// QueryTierElevated is the elevated access level
+	QueryTierElevated
+	// QueryTierAcademic is the academic research access level, which has the advanced operators and the longer queries
+	QueryTierAcademic
+)
+
+// QueryEndpoint is the endpoint the query is built for
+type QueryEndpoint int
+
+const (
+	// QueryRecentSearch is a query for TweetRecentSearch
+	QueryRecentSearch QueryEndpoint = iota
+	// QueryFullArchiveSearch is a query for TweetSearch
+	QueryFullArchiveSearch
+	// QueryRecentCounts is a query for TweetRecentCounts
+	QueryRecentCounts
+	// QueryAllCounts is a query for TweetAllCounts
+	QueryAllCounts
+	// QueryStreamRule is a query for a search stream rule value
+	QueryStreamRule
+
+	streamRuleLength         = 512
+	streamRuleAcademicLength = 1024
+)
+
+var (
+	queryUsernameRegex = regexp.MustCompile(`^[A-Za-z0-9_]{1,15}$`)
+	queryEntityRegex   = regexp.MustCompile(`^[\pL\pN_]+$`)
+	queryLangRegex     = regexp.MustCompile(`^[a-z]{2,3}$`)
+	queryCountryRegex  = regexp.MustCompile(`^[A-Z]{2}$`)
+	queryIDRegex       = regexp.MustCompile(`^[0-9]+$`)
+)
+
+// Query is a search or stream rule query composed from the operators.  The operators are rendered with the
+// values quoted when needed, and the query is validated against the access level and endpoint by Build.
+type Query struct {
+	text       string
+	advanced   []string
+	standalone bool
+	compound   bool
+	group      bool
+	err        error
+}
+
+func queryOperator(text string, standalone bool) Query {
+	return Query{
+		text:       text,
+		standalone: standalone,
+	}
+}
+
+func queryAdvanced(operator string, q Query) Query {
+	q.advanced = append(q.advanced, operator)
+	return q
+}
+
+func queryError(format string, args ...interface{}) Query {
+	return Query{
+		err: fmt.Errorf("query "+format+": %w", append(args, ErrParameter)...),
+	}
+}
+
+// queryQuote will quote the value when it would not be read as a single keyword
+func queryQuote(value string) string {
+	switch {
+	case strings.ContainsAny(value, " \t\r\n\"():"),
+		strings.HasPrefix(value, "-"),
+		strings.HasPrefix(value, "#"),
+		strings.HasPrefix(value, "@"),
+		strings.HasPrefix(value, "$"),
+		value == "OR",
+		value == "AND":
+		return queryPhraseText(value)
+	default:
+		return value
+	}
+}
+
+func queryPhraseText(value string) string {
+	return `"` + strings.ReplaceAll(value, `"`, `\"`) + `"`
+}
+
+// QueryKeyword matches the keyword in the tweet text, a keyword with spaces or reserved characters is quoted
+func QueryKeyword(keyword string) Query {
+	if len(keyword) == 0 {
+		return queryError("keyword is required")
+	}
+	return queryOperator(queryQuote(keyword), true)
+}
+
+// QueryPhrase matches the exact phrase in the tweet text
+func QueryPhrase(phrase string) Query {
+	if len(phrase) == 0 {
+		return queryError("phrase is required")
+	}
+	return queryOperator(queryPhraseText(phrase), true)
+}
+
+func queryUsername(operator, prefix, username string) Query {
+	username = strings.TrimPrefix(username, "@")
+	if !queryUsernameRegex.MatchString(username) {
+		return queryError("%s username [%s] is not valid", operator, username)
+	}
+	return queryOperator(prefix+username, true)
+}
+
+// QueryFrom matches the tweets from the user
+func QueryFrom(username string) Query {
+	return queryUsername("from", "from:", username)
+}
+
+// QueryTo matches the tweets that reply to the user
+func QueryTo(username string) Query {
+	return queryUsername("to", "to:", username)
+}
+
+// QueryMention matches the tweets that mention the user, @username
+func QueryMention(username string) Query {
+	return queryUsername("mention", "@", username)
+}
+
+// QueryRetweetsOf matches the retweets of the user
+func QueryRetweetsOf(username string) Query {
+	return queryUsername("retweets_of", "retweets_of:", username)
+}
+
+// QueryHashtag matches the tweets with the hashtag, #hashtag
+func QueryHashtag(hashtag string) Query {
+	hashtag = strings.TrimPrefix(hashtag, "#")
+	if !queryEntityRegex.MatchString(hashtag) {
+		return queryError("hashtag [%s] is not valid", hashtag)
+	}
+	return queryOperator("#"+hashtag, true)
+}
+
+// QueryCashtag matches the tweets with the cashtag, $symbol.  This is an advanced operator.
+func QueryCashtag(symbol string) Query {
+	symbol = strings.TrimPrefix(symbol, "$")
+	if !queryEntityRegex.MatchString(symbol) {
+		return queryError("cashtag [%s] is not valid", symbol)
+	}
+	return queryAdvanced("$", queryOperator("$"+symbol, true))
+}
+
+// QueryURL matches the tweets with a url that contains the value
+func QueryURL(url string) Query {
+	if len(url) == 0 {
+		return queryError("url is required")
+	}
+	return queryOperator("url:"+queryPhraseText(url), true)
+}
+
+// QueryConversationID matches the tweets of the conversation
+func QueryConversationID(id string) Query {
+	if !queryIDRegex.MatchString(id) {
+		return queryError("conversation id [%s] is not valid", id)
+	}
+	return queryOperator("conversation_id:"+id, true)
+}
+
+// QueryPlaceCountry matches the tweets tagged with the ISO alpha-2 country code.  This is an advanced operator.
+func QueryPlaceCountry(country string) Query {
+	if !queryCountryRegex.MatchString(country) {
+		return queryError("place country [%s] is not an ISO alpha-2 code", country)
+	}
+	return queryAdvanced("place_country:", queryOperator("place_country:"+country, true))
+}
+
+// QueryBoundingBox matches the tweets located in the box of the longitudes and latitudes.  This is an advanced operator.
+func QueryBoundingBox(westLong, southLat, eastLong, northLat float64) Query {
+	switch {
+	case westLong < -180 || eastLong > 180 || westLong >= eastLong:
+		return queryError("bounding box longitudes [%v, %v] are not valid", westLong, eastLong)
+	case southLat < -90 || northLat > 90 || southLat >= northLat:
+		return queryError("bounding box latitudes [%v, %v] are not valid", southLat, northLat)
+	}
+	text := fmt.Sprintf("bounding_box:[%g %g %g %g]", westLong, southLat, eastLong, northLat)
+	return queryAdvanced("bounding_box:", queryOperator(text, true))
+}
+
+// QueryLang matches the tweets classified with the BCP 47 language, it can not be used alone
+func QueryLang(lang string) Query {
+	if !queryLangRegex.MatchString(lang) {
+		return queryError("lang [%s] is not valid", lang)
+	}
+	return queryOperator("lang:"+lang, false)
+}
+
+// QueryIsRetweet matches the retweets, it can not be used alone
+func QueryIsRetweet() Query {
+	return queryOperator("is:retweet", false)
+}
+
+// QueryIsReply matches the replies, it can not be used alone
+func QueryIsReply() Query {
+	return queryOperator("is:reply", false)
+}
+
+// QueryIsQuote matches the quote tweets, it can not be used alone
+func QueryIsQuote() Query {
+	return queryOperator("is:quote", false)
+}
+
+// QueryIsVerified matches the tweets from verified users, it can not be used alone
+func QueryIsVerified() Query {
+	return queryOperator("is:verified", false)
+}
+
+// QueryHasMedia matches the tweets with media, it can not be used alone
+func QueryHasMedia() Query {
+	return queryOperator("has:media", false)
+}
+
+// QueryHasImages matches the tweets with images, it can not be used alone
+func QueryHasImages() Query {
+	return queryOperator("has:images", false)
+}
+
+// QueryHasVideos matches the tweets with videos, it can not be used alone
+func QueryHasVideos() Query {
+	return queryOperator("has:videos", false)
+}
+
+// QueryHasLinks matches the tweets with links, it can not be used alone
+func QueryHasLinks() Query {
+	return queryOperator("has:links", false)
+}
+
+// QueryHasHashtags matches the tweets with hashtags, it can not be used alone
+func QueryHasHashtags() Query {
+	return queryOperator("has:hashtags", false)
+}
+
+// QueryHasMentions matches the tweets with mentions, it can not be used alone
+func QueryHasMentions() Query {
+	return queryOperator("has:mentions", false)
+}
+
+// QueryHasGeo matches the tweets with geo data, it can not be used alone.  This is an advanced operator.
+func QueryHasGeo() Query {
+	return queryAdvanced("has:geo", queryOperator("has:geo", false))
+}
+
+func queryCombine(name string, queries []Query) (Query, []string) {
+	combined := Query{}
+	texts := make([]string, 0, len(queries))
+	for _, q := range queries {
+		if q.err != nil {
+			return Query{err: q.err}, nil
+		}
+		combined.advanced = append(combined.advanced, q.advanced...)
+		texts = append(texts, q.text)
+	}
+	if len(texts) == 0 {
+		return queryError("%s requires a query", name), nil
+	}
+	return combined, texts
+}
+
+// QueryAnd matches the tweets that match all of the queries
+func QueryAnd(queries ...Query) Query {
+	combined, texts := queryCombine("and", queries)
+	if combined.err != nil {
+		return combined
+	}
+	if len(queries) == 1 {
+		return queries[0]
+	}
+	for _, q := range queries {
+		combined.standalone = combined.standalone || q.standalone
+	}
+	combined.text = strings.Join(texts, " ")
+	combined.compound = true
+	return combined
+}
+
+// QueryOr matches the tweets that match any of the queries, the alternatives are grouped
+func QueryOr(queries ...Query) Query {
+	combined, texts := queryCombine("or", queries)
+	if combined.err != nil {
+		return combined
+	}
+	if len(queries) == 1 {
+		return queries[0]
+	}
+	combined.standalone = true
+	for _, q := range queries {
+		combined.standalone = combined.standalone && q.standalone
+	}
+	combined.text = "(" + strings.Join(texts, " OR ") + ")"
+	combined.group = true
+	return combined
+}
+
+// QueryGroup will group the query with parentheses
+func QueryGroup(q Query) Query {
+	if q.err != nil || q.group {
+		return q
+	}
+	q.text = "(" + q.text + ")"
+	q.group = true
+	return q
+}
+
+// QueryNot will negate the query, a group of terms is negated as a whole
+func QueryNot(q Query) Query {
+	if q.err != nil {
+		return q
+	}
+	if q.compound {
+		q = QueryGroup(q)
+	}
+	q.text = "-" + q.text
+	q.standalone = false
+	return q
+}
+
+// String returns the query text
+func (q Query) String() string {
+	return q.text
+}
+
+// Err returns the error of building the query operators
+func (q Query) Err() error {
+	return q.err
+}
+
+// MaxQueryLength returns the maximum query length in characters of the endpoint for the access level
+func MaxQueryLength(tier QueryTier, endpoint QueryEndpoint) int {
+	switch endpoint {
+	case QueryFullArchiveSearch:
+		return tweetSearchQueryLength
+	case QueryAllCounts:
+		return tweetAllCountsQueryLength
+	case QueryRecentCounts:
+		return tweetRecentCountsQueryLength
+	case QueryStreamRule:
+		if tier == QueryTierAcademic {
+			return streamRuleAcademicLength
+		}
+		return streamRuleLength
+	default:
+		return tweetRecentSearchQueryLength
+	}
+}
+
+// Build will validate the query for the access level and endpoint and return the query text.  The query must have
+// a standalone operator, the advanced operators and the full archive require academic access and the query can not
+// be over the maximum length.
+func (q Query) Build(tier QueryTier, endpoint QueryEndpoint) (string, error) {
+	if q.err != nil {
+		return "", q.err
+	}
+	if len(q.text) == 0 {
+		return "", fmt.Errorf("query is empty: %w", ErrParameter)
+	}
+	if !q.standalone {
+		return "", fmt.Errorf("query [%s] requires a standalone operator: %w", q.text, ErrParameter)
+	}
+	if tier != QueryTierAcademic {
+		switch {
+		case endpoint == QueryFullArchiveSearch || endpoint == QueryAllCounts:
+			return "", fmt.Errorf("query full archive requires academic access: %w", ErrParameter)
+		case len(q.advanced) > 0:
+			return "", fmt.Errorf("query operators %v require academic access: %w", q.advanced, ErrParameter)
+		}
+	}
+	if max, length := MaxQueryLength(tier, endpoint), utf8.RuneCountInString(q.text); length > max {
+		return "", fmt.Errorf("query length %d is over the length (%d): %w", length, max, ErrParameter)
+	}
+	return q.text, nil
+}
+
+// Rule will validate the query and return the search stream rule with the tag
+func (q Query) Rule(tier QueryTier, tag string) (TweetSearchStreamRule, error) {
+	value, err := q.Build(tier, QueryStreamRule)
+	if err != nil {
+		return TweetSearchStreamRule{}, err
+	}
+	return TweetSearchStreamRule{
+		Value: value,
+		Tag:   tag,
+	}, nil
+}
@@ -0,0 +1,737 @@
+package twitter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type queryTokenKind int
+
+const (
+	queryTokenTerm queryTokenKind = iota
+	queryTokenPhrase
+	queryTokenOr
+	queryTokenNot
+	queryTokenOpen
+	queryTokenClose
+)
+
+type queryToken struct {
+	kind     queryTokenKind
+	operator string
+	value    string
+}
+
+// QueryTermMatch is the result of evaluating one term of the query
+type QueryTermMatch struct {
+	Term    string
+	Matched bool
+	Reason  string
+}
+
+// QueryExplanation explains why a tweet did or did not match the query
+type QueryExplanation struct {
+	Matched bool
+	Terms   []QueryTermMatch
+}
+
+// String returns the explanation with a line for each term, prefixed by + when it matched and - when it did not
+func (q *QueryExplanation) String() string {
+	sb := strings.Builder{}
+	for _, term := range q.Terms {
+		prefix := "-"
+		if term.Matched {
+			prefix = "+"
+		}
+		sb.WriteString(fmt.Sprintf("%s %s: %s\n", prefix, term.Term, term.Reason))
+	}
+	return sb.String()
+}
+
+type queryNode interface {
+	eval(d *TweetDictionary, explain *QueryExplanation) bool
+}
+
+type queryAndNode []queryNode
+
+func (n queryAndNode) eval(d *TweetDictionary, explain *QueryExplanation) bool {
+	matched := true
+	for _, child := range n {
+		matched = child.eval(d, explain) && matched
+	}
+	return matched
+}
+
+type queryOrNode []queryNode
+
+func (n queryOrNode) eval(d *TweetDictionary, explain *QueryExplanation) bool {
+	matched := false
+	for _, child := range n {
+		matched = child.eval(d, explain) || matched
+	}
+	return matched
+}
+
+type queryNotNode struct {
+	child queryNode
+}
+
+func (n queryNotNode) eval(d *TweetDictionary, explain *QueryExplanation) bool {
+	return !n.child.eval(d, explain)
+}
+
+type queryTermNode struct {
+	term  string
+	match func(d *TweetDictionary) (bool, string)
+}
+
+func (n queryTermNode) eval(d *TweetDictionary, explain *QueryExplanation) bool {
+	matched, reason := n.match(d)
+	if explain != nil {
+		explain.Terms = append(explain.Terms, QueryTermMatch{
+			Term:    n.term,
+			Matched: matched,
+			Reason:  reason,
+		})
+	}
+	return matched
+}
+
+// QueryMatcher evaluates a search or stream rule query against tweets without calling twitter
+type QueryMatcher struct {
+	query string
+	root  queryNode
+}
+
+// NewQueryMatcher will parse the query, an operator that can not be evaluated offline is a parameter error
+func NewQueryMatcher(query string) (*QueryMatcher, error) {
+	tokens, err := lexQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("query matcher: query is empty: %w", ErrParameter)
+	}
+	parser := &queryParser{
+		query:  query,
+		tokens: tokens,
+	}
+	root, err := parser.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if parser.pos < len(tokens) {
+		return nil, fmt.Errorf("query matcher: unexpected ) in [%s]: %w", query, ErrParameter)
+	}
+	return &QueryMatcher{
+		query: query,
+		root:  root,
+	}, nil
+}
+
+// String returns the query
+func (m *QueryMatcher) String() string {
+	return m.query
+}
+
+// Match returns if the tweet matches the query, the dictionary includes are used by the author, media and place operators.
+// A nil tweet does not match.
+func (m *QueryMatcher) Match(tweet *TweetDictionary) bool {
+	if tweet == nil {
+		return false
+	}
+	return m.root.eval(tweet, nil)
+}
+
+// Explain returns if the tweet matches the query and the result of each term, a nil tweet does not match any term
+func (m *QueryMatcher) Explain(tweet *TweetDictionary) *QueryExplanation {
+	explain := &QueryExplanation{
+		Terms: []QueryTermMatch{},
+	}
+	if tweet == nil {
+		return explain
+	}
+	explain.Matched = m.root.eval(tweet, explain)
+	return explain
+}
+
+func lexQuery(query string) ([]queryToken, error) {
+	tokens := []queryToken{}
+	runes := []rune(query)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, queryToken{kind: queryTokenOpen})
+			i++
+		case r == ')':
+			tokens = append(tokens, queryToken{kind: queryTokenClose})
+			i++
+		case r == '-' && i+1 < len(runes) && !unicode.IsSpace(runes[i+1]):
+			tokens = append(tokens, queryToken{kind: queryTokenNot})
+			i++
+		case r == '"':
+			value, next, err := lexQueryQuoted(runes, i)
+			if err != nil {
+				return nil, fmt.Errorf("query matcher: %v in [%s]: %w", err, query, ErrParameter)
+			}
+			tokens = append(tokens, queryToken{kind: queryTokenPhrase, value: value})
+			i = next
+		default:
+			token, next, err := lexQueryTerm(runes, i)
+			if err != nil {
+				return nil, fmt.Errorf("query matcher: %v in [%s]: %w", err, query, ErrParameter)
+			}
+			tokens = append(tokens, token)
+			i = next
+		}
+	}
+	return tokens, nil
+}
+
+// lexQueryQuoted reads the quoted value starting at the quote and returns the unescaped value and the next position
+func lexQueryQuoted(runes []rune, start int) (string, int, error) {
+	sb := strings.Builder{}
+	for i := start + 1; i < len(runes); i++ {
+		switch {
+		case runes[i] == '\\' && i+1 < len(runes) && runes[i+1] == '"':
+			sb.WriteRune('"')
+			i++
+		case runes[i] == '"':
+			return sb.String(), i + 1, nil
+		default:
+			sb.WriteRune(runes[i])
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated quote")
+}
+
+func lexQueryTerm(runes []rune, start int) (queryToken, int, error) {
+	token := queryToken{kind: queryTokenTerm}
+	sb := strings.Builder{}
+	i := start
+	for i < len(runes) {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r), r == '(', r == ')':
+			token.value = sb.String()
+			return lexQueryOperator(token), i, nil
+		case r == ':' && len(token.operator) == 0 && i > start:
+			token.operator = sb.String()
+			sb.Reset()
+			i++
+		case r == '"' && len(token.operator) > 0 && sb.Len() == 0:
+			value, next, err := lexQueryQuoted(runes, i)
+			if err != nil {
+				return token, 0, err
+			}
+			sb.WriteString(value)
+			i = next
+		case r == '[' && len(token.operator) > 0 && sb.Len() == 0:
+			end := i
+			for end < len(runes) && runes[end] != ']' {
+				end++
+			}
+			if end == len(runes) {
+				return token, 0, fmt.Errorf("unterminated [")
+			}
+			sb.WriteString(string(runes[i+1 : end]))
+			i = end + 1
+		default:
+			sb.WriteRune(r)
+			i++
+		}
+	}
+	token.value = sb.String()
+	return lexQueryOperator(token), i, nil
+}
+
+// lexQueryOperator will turn a term with a prefix that is not an operator name, like 10:30, back into a keyword
+func lexQueryOperator(token queryToken) queryToken {
+	if len(token.operator) == 0 {
+		if token.value == "OR" {
+			token.kind = queryTokenOr
+		}
+		return token
+	}
+	for _, r := range token.operator {
+		if !unicode.IsLower(r) && r != '_' {
+			token.value = token.operator + ":" + token.value
+			token.operator = ""
+			return token
+		}
+	}
+	return token
+}
+
+type queryParser struct {
+	query  string
+	tokens []queryToken
+	pos    int
+}
+
+func (p *queryParser) peek() (queryToken, bool) {
+	if p.pos >= len(p.tokens) {
+		return queryToken{}, false
+	}
+	return p.tokens[p.pos], true
+}
+
+func (p *queryParser) parseOr() (queryNode, error) {
+	node, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	or := queryOrNode{node}
+	for {
+		token, ok := p.peek()
+		if !ok || token.kind != queryTokenOr {
+			break
+		}
+		p.pos++
+		node, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		or = append(or, node)
+	}
+	if len(or) == 1 {
+		return or[0], nil
+	}
+	return or, nil
+}
+
+func (p *queryParser) parseAnd() (queryNode, error) {
+	and := queryAndNode{}
+	for {
+		token, ok := p.peek()
+		if !ok || token.kind == queryTokenOr || token.kind == queryTokenClose {
+			break
+		}
+		node, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		and = append(and, node)
+	}
+	switch len(and) {
+	case 0:
+		return nil, fmt.Errorf("query matcher: expected a term at %d in [%s]: %w", p.pos, p.query, ErrParameter)
+	case 1:
+		return and[0], nil
+	default:
+		return and, nil
+	}
+}
+
+func (p *queryParser) parseUnary() (queryNode, error) {
+	token, _ := p.peek()
+	p.pos++
+	switch token.kind {
+	case queryTokenNot:
+		if _, ok := p.peek(); !ok {
+			return nil, fmt.Errorf("query matcher: expected a term after - in [%s]: %w", p.query, ErrParameter)
+		}
+		child, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return queryNotNode{child: child}, nil
+	case queryTokenOpen:
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing, ok := p.peek(); !ok || closing.kind != queryTokenClose {
+			return nil, fmt.Errorf("query matcher: missing ) in [%s]: %w", p.query, ErrParameter)
+		}
+		p.pos++
+		return node, nil
+	case queryTokenPhrase:
+		return queryPhraseTerm(token.value)
+	case queryTokenTerm:
+		return queryTerm(token)
+	default:
+		return nil, fmt.Errorf("query matcher: unexpected token at %d in [%s]: %w", p.pos-1, p.query, ErrParameter)
+	}
+}
+
+func queryTerm(token queryToken) (queryNode, error) {
+	value := token.value
+	if len(token.operator) == 0 {
+		switch {
+		case strings.HasPrefix(value, "#") && len(value) > 1:
+			return queryEntityTerm(value, "hashtag", value[1:], func(d *TweetDictionary) []string {
+				return queryEntityTags(d, func(e *EntitiesObj) []EntityTagObj { return e.HashTags })
+			}), nil
+		case strings.HasPrefix(value, "$") && len(value) > 1:
+			return queryEntityTerm(value, "cashtag", value[1:], func(d *TweetDictionary) []string {
+				return queryEntityTags(d, func(e *EntitiesObj) []EntityTagObj { return e.CashTags })
+			}), nil
+		case strings.HasPrefix(value, "@") && len(value) > 1:
+			return queryEntityTerm(value, "mention", value[1:], queryMentions), nil
+		default:
+			return queryKeywordTerm(value)
+		}
+	}
+
+	term := token.operator + ":" + value
+	if len(value) == 0 {
+		return nil, fmt.Errorf("query matcher: operator %s requires a value: %w", token.operator, ErrParameter)
+	}
+	switch token.operator {
+	case "from":
+		return queryUserTerm(term, "author", value, func(d *TweetDictionary) (*UserObj, string) {
+			return d.Author, d.Tweet.AuthorID
+		}), nil
+	case "to":
+		return queryUserTerm(term, "reply to", value, func(d *TweetDictionary) (*UserObj, string) {
+			return d.InReplyUser, d.Tweet.InReplyToUserID
+		}), nil
+	case "retweets_of":
+		return queryUserTerm(term, "retweet of", value, func(d *TweetDictionary) (*UserObj, string) {
+			for _, ref := range d.ReferencedTweets {
+				if ref.Reference != nil && ref.Reference.Type == "retweeted" && ref.TweetDictionary != nil {
+					return ref.TweetDictionary.Author, ref.TweetDictionary.Tweet.AuthorID
+				}
+			}
+			return nil, ""
+		}), nil
+	case "url":
+		return queryTermNode{
+			term: term,
+			match: func(d *TweetDictionary) (bool, string) {
+				want := strings.ToLower(value)
+				for _, u := range queryURLs(d) {
+					for _, candidate := range []string{u.URL, u.ExpandedURL, u.DisplayURL, u.UnwoundURL} {
+						if len(candidate) > 0 && strings.Contains(strings.ToLower(candidate), want) {
+							return true, fmt.Sprintf("url %s", candidate)
+						}
+					}
+				}
+				return false, "no url contains " + value
+			},
+		}, nil
+	case "conversation_id":
+		return queryFieldTerm(term, "conversation id", value, false, func(d *TweetDictionary) string {
+			return d.Tweet.ConversationID
+		}), nil
+	case "lang":
+		return queryFieldTerm(term, "lang", value, false, func(d *TweetDictionary) string {
+			return d.Tweet.Language
+		}), nil
+	case "place_country":
+		return queryFieldTerm(term, "place country", value, true, func(d *TweetDictionary) string {
+			if d.Place == nil {
+				return ""
+			}
+			return d.Place.CountryCode
+		}), nil
+	case "place":
+		return queryTermNode{
+			term: term,
+			match: func(d *TweetDictionary) (bool, string) {
+				if d.Place == nil {
+					return false, "no place"
+				}
+				if d.Place.ID == value || strings.Contains(strings.ToLower(d.Place.FullName), strings.ToLower(value)) {
+					return true, "place is " + d.Place.FullName
+				}
+				return false, "place is " + d.Place.FullName
+			},
+		}, nil
+	case "bounding_box":
+		return queryBoundingBoxTerm(term, value)
+	case "is":
+		return queryIsTerm(term, value)
+	case "has":
+		return queryHasTerm(term, value)
+	default:
+		return nil, fmt.Errorf("query matcher: operator %s is not supported: %w", token.operator, ErrParameter)
+	}
+}
+
+func queryText(d *TweetDictionary) string {
+	if d.Tweet.NoteTweet != nil && len(d.Tweet.NoteTweet.Text) > 0 {
+		return d.Tweet.NoteTweet.Text
+	}
+	return d.Tweet.Text
+}
+
+func queryEntities(d *TweetDictionary) *EntitiesObj {
+	if d.Tweet.NoteTweet != nil && d.Tweet.NoteTweet.Entities != nil {
+		return d.Tweet.NoteTweet.Entities
+	}
+	return d.Tweet.Entities
+}
+
+// queryTokenize splits the text into the lower case words
+func queryTokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r) && r != '_'
+	})
+}
+
+// queryContains returns if the words appear in order in the text words
+func queryContains(text, words []string) bool {
+	if len(words) == 0 {
+		return false
+	}
+	for i := 0; i+len(words) <= len(text); i++ {
+		matched := true
+		for j := range words {
+			if text[i+j] != words[j] {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+func queryKeywordTerm(keyword string) (queryNode, error) {
+	words := queryTokenize(keyword)
+	if len(words) == 0 {
+		return nil, fmt.Errorf("query matcher: keyword [%s] has no words: %w", keyword, ErrParameter)
+	}
+	return queryTermNode{
+		term: keyword,
+		match: func(d *TweetDictionary) (bool, string) {
+			if queryContains(queryTokenize(queryText(d)), words) {
+				return true, "text contains " + keyword
+			}
+			return false, "text does not contain " + keyword
+		},
+	}, nil
+}
+
+func queryPhraseTerm(phrase string) (queryNode, error) {
+	words := queryTokenize(phrase)
+	if len(words) == 0 {
+		return nil, fmt.Errorf("query matcher: phrase [%s] has no words: %w", phrase, ErrParameter)
+	}
+	return queryTermNode{
+		term: strconv.Quote(phrase),
+		match: func(d *TweetDictionary) (bool, string) {
+			if queryContains(queryTokenize(queryText(d)), words) {
+				return true, "text contains the phrase"
+			}
+			return false, "text does not contain the phrase"
+		},
+	}, nil
+}
+
+func queryEntityTags(d *TweetDictionary, tags func(e *EntitiesObj) []EntityTagObj) []string {
+	entities := queryEntities(d)
+	if entities == nil {
+		return nil
+	}
+	values := []string{}
+	for _, tag := range tags(entities) {
+		values = append(values, tag.Tag)
+	}
+	return values
+}
+
+func queryMentions(d *TweetDictionary) []string {
+	entities := queryEntities(d)
+	if entities == nil {
+		return nil
+	}
+	values := []string{}
+	for _, mention := range entities.Mentions {
+		values = append(values, mention.UserName)
+	}
+	return values
+}
+
+// queryEntityTerm matches the entities, or the text when the tweet has no entities
+func queryEntityTerm(term, name, value string, values func(d *TweetDictionary) []string) queryNode {
+	return queryTermNode{
+		term: term,
+		match: func(d *TweetDictionary) (bool, string) {
+			if queryEntities(d) == nil {
+				for _, word := range strings.Fields(strings.ToLower(queryText(d))) {
+					if strings.TrimRightFunc(word, unicode.IsPunct) == strings.ToLower(term) {
+						return true, "text contains " + term
+					}
+				}
+				return false, "text does not contain " + term
+			}
+			for _, v := range values(d) {
+				if strings.EqualFold(v, value) {
+					return true, fmt.Sprintf("%s entity %s", name, v)
+				}
+			}
+			return false, fmt.Sprintf("no %s entity %s", name, value)
+		},
+	}
+}
+
+func queryUserTerm(term, name, value string, user func(d *TweetDictionary) (*UserObj, string)) queryNode {
+	value = strings.TrimPrefix(value, "@")
+	return queryTermNode{
+		term: term,
+		match: func(d *TweetDictionary) (bool, string) {
+			obj, id := user(d)
+			switch {
+			case len(id) > 0 && id == value:
+				return true, fmt.Sprintf("%s id is %s", name, id)
+			case obj != nil && strings.EqualFold(obj.UserName, value):
+				return true, fmt.Sprintf("%s is %s", name, obj.UserName)
+			case obj != nil:
+				return false, fmt.Sprintf("%s is %s", name, obj.UserName)
+			case len(id) > 0:
+				return false, fmt.Sprintf("%s id is %s", name, id)
+			default:
+				return false, "no " + name
+			}
+		},
+	}
+}
+
+func queryFieldTerm(term, name, value string, fold bool, field func(d *TweetDictionary) string) queryNode {
+	return queryTermNode{
+		term: term,
+		match: func(d *TweetDictionary) (bool, string) {
+			got := field(d)
+			if got == value || (fold && strings.EqualFold(got, value)) {
+				return true, fmt.Sprintf("%s is %s", name, got)
+			}
+			if len(got) == 0 {
+				return false, "no " + name
+			}
+			return false, fmt.Sprintf("%s is %s", name, got)
+		},
+	}
+}
+
+func queryURLs(d *TweetDictionary) []EntityURLObj {
+	entities := queryEntities(d)
+	if entities == nil {
+		return nil
+	}
+	return entities.URLs
+}
+
+func queryReferenced(d *TweetDictionary, refType string) bool {
+	for _, ref := range d.Tweet.ReferencedTweets {
+		if ref != nil && ref.Type == refType {
+			return true
+		}
+	}
+	return false
+}
+
+func queryIsTerm(term, value string) (queryNode, error) {
+	var match func(d *TweetDictionary) bool
+	switch value {
+	case "retweet":
+		match = func(d *TweetDictionary) bool { return queryReferenced(d, "retweeted") }
+	case "reply":
+		match = func(d *TweetDictionary) bool { return queryReferenced(d, "replied_to") }
+	case "quote":
+		match = func(d *TweetDictionary) bool { return queryReferenced(d, "quoted") }
+	case "verified":
+		match = func(d *TweetDictionary) bool { return d.Author != nil && d.Author.Verified }
+	default:
+		return nil, fmt.Errorf("query matcher: operator %s is not supported: %w", term, ErrParameter)
+	}
+	return queryTermNode{
+		term: term,
+		match: func(d *TweetDictionary) (bool, string) {
+			if match(d) {
+				return true, "tweet is " + value
+			}
+			return false, "tweet is not " + value
+		},
+	}, nil
+}
+
+func queryHasMedia(d *TweetDictionary, types ...string) bool {
+	for _, media := range d.AttachmentMedia {
+		if media == nil {
+			continue
+		}
+		for _, t := range types {
+			if media.Type == t {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func queryHasTerm(term, value string) (queryNode, error) {
+	var match func(d *TweetDictionary) bool
+	switch value {
+	case "links":
+		match = func(d *TweetDictionary) bool { return len(queryURLs(d)) > 0 }
+	case "media":
+		match = func(d *TweetDictionary) bool {
+			return d.Tweet.Attachments != nil && len(d.Tweet.Attachments.MediaKeys) > 0
+		}
+	case "images":
+		match = func(d *TweetDictionary) bool { return queryHasMedia(d, "photo") }
+	case "videos":
+		match = func(d *TweetDictionary) bool { return queryHasMedia(d, "video", "animated_gif") }
+	case "hashtags":
+		match = func(d *TweetDictionary) bool {
+			return len(queryEntityTags(d, func(e *EntitiesObj) []EntityTagObj { return e.HashTags })) > 0
+		}
+	case "cashtags":
+		match = func(d *TweetDictionary) bool {
+			return len(queryEntityTags(d, func(e *EntitiesObj) []EntityTagObj { return e.CashTags })) > 0
+		}
+	case "mentions":
+		match = func(d *TweetDictionary) bool { return len(queryMentions(d)) > 0 }
+	case "geo":
+		match = func(d *TweetDictionary) bool { return d.Tweet.Geo != nil }
+	default:
+		return nil, fmt.Errorf("query matcher: operator %s is not supported: %w", term, ErrParameter)
+	}
+	return queryTermNode{
+		term: term,
+		match: func(d *TweetDictionary) (bool, string) {
+			if match(d) {
+				return true, "tweet has " + value
+			}
+			return false, "tweet does not have " + value
+		},
+	}, nil
+}
+
+func queryBoundingBoxTerm(term, value string) (queryNode, error) {
+	fields := strings.Fields(value)
+	if len(fields) != 4 {
+		return nil, fmt.Errorf("query matcher: %s requires four coordinates: %w", term, ErrParameter)
+	}
+	box := make([]float64, 4)
+	for i, field := range fields {
+		coord, err := strconv.ParseFloat(field, 64)
+		if err != nil {
+			return nil, fmt.Errorf("query matcher: %s coordinate %s: %w", term, field, ErrParameter)
+		}
+		box[i] = coord
+	}
+	return queryTermNode{
+		term: "bounding_box:[" + value + "]",
+		match: func(d *TweetDictionary) (bool, string) {
+			if d.Tweet.Geo == nil || len(d.Tweet.Geo.Coordinates.Coordinates) != 2 {
+				return false, "tweet has no coordinates"
+			}
+			long, lat := d.Tweet.Geo.Coordinates.Coordinates[0], d.Tweet.Geo.Coordinates.Coordinates[1]
+			if long >= box[0] && lat >= box[1] && long <= box[2] && lat <= box[3] {
+				return true, fmt.Sprintf("coordinates [%g %g] are in the box", long, lat)
+			}
+			return false, fmt.Sprintf("coordinates [%g %g] are not in the box", long, lat)
+		},
+	}, nil
+}
@@ -0,0 +1,186 @@
+package twitter
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func queryEvalTestTweet(t *testing.T) *TweetDictionary {
+	raw := `{
+		"data": {
+			"id": "1",
+			"text": "Go 1.18 ships generics! @golang #GoLang https://t.co/abc",
+			"author_id": "100",
+			"conversation_id": "1",
+			"lang": "en",
+			"in_reply_to_user_id": "200",
+			"referenced_tweets": [{"type": "replied_to", "id": "0"}],
+			"attachments": {"media_keys": ["3_1"]},
+			"geo": {"place_id": "p1", "coordinates": {"type": "Point", "coordinates": [-105.2, 40.0]}},
+			"entities": {
+				"urls": [{"url": "https://t.co/abc", "expanded_url": "https://go.dev/blog/go1.18", "display_url": "go.dev/blog/go1.18"}],
+				"hashtags": [{"tag": "GoLang"}],
+				"mentions": [{"username": "golang"}]
+			}
+		},
+		"includes": {
+			"users": [
+				{"id": "100", "name": "Gopher", "username": "gopher", "verified": true},
+				{"id": "200", "name": "Go", "username": "golang"}
+			],
+			"media": [{"media_key": "3_1", "type": "photo"}],
+			"places": [{"id": "p1", "full_name": "Boulder, CO", "country_code": "US"}]
+		}
+	}`
+	single := &tweetraw{}
+	if err := json.Unmarshal([]byte(raw), single); err != nil {
+		t.Fatalf("tweet decode error = %v", err)
+	}
+	d := CreateTweetDictionary(*single.Tweet, single.Includes)
+	if d.Place == nil {
+		d.Place = single.Includes.Places[0]
+	}
+	return d
+}
+
+func TestQueryMatcher_Match(t *testing.T) {
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{query: "generics", want: true},
+		{query: "GENERICS", want: true},
+		{query: "generic", want: false},
+		{query: `"ships generics"`, want: true},
+		{query: `"generics ships"`, want: false},
+		{query: "from:gopher", want: true},
+		{query: "from:100", want: true},
+		{query: "from:golang", want: false},
+		{query: "to:golang", want: true},
+		{query: "@golang", want: true},
+		{query: "#golang", want: true},
+		{query: "#rust", want: false},
+		{query: "$GO", want: false},
+		{query: `url:"go.dev/blog"`, want: true},
+		{query: "lang:en generics", want: true},
+		{query: "lang:fr generics", want: false},
+		{query: "conversation_id:1", want: true},
+		{query: "generics is:reply", want: true},
+		{query: "generics is:retweet", want: false},
+		{query: "generics -is:retweet", want: true},
+		{query: "generics -is:quote is:verified", want: true},
+		{query: "generics has:links has:media has:images has:mentions has:hashtags has:geo", want: true},
+		{query: "generics has:videos", want: false},
+		{query: "generics has:cashtags", want: false},
+		{query: "rust OR generics", want: true},
+		{query: "rust OR zig", want: false},
+		{query: "(rust OR generics) -(spam OR scam)", want: true},
+		{query: "generics -(ships OR spam)", want: false},
+		{query: "rust OR generics from:golang", want: false},
+		{query: "place_country:us generics", want: true},
+		{query: `place:"boulder" generics`, want: true},
+		{query: "bounding_box:[-105.3 39.9 -105.1 40.1]", want: true},
+		{query: "bounding_box:[-100 39.9 -99 40.1]", want: false},
+	}
+	d := queryEvalTestTweet(t)
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			m, err := NewQueryMatcher(tt.query)
+			if err != nil {
+				t.Fatalf("NewQueryMatcher() error = %v", err)
+			}
+			if got := m.Match(d); got != tt.want {
+				t.Errorf("QueryMatcher.Match() = %v, want %v\n%s", got, tt.want, m.Explain(d))
+			}
+		})
+	}
+}
+
+func TestQueryMatcher_Builder(t *testing.T) {
+	query, err := QueryAnd(QueryOr(QueryHashtag("golang"), QueryFrom("rustlang")), QueryNot(QueryIsRetweet()), QueryLang("en"), QueryPhrase("ships generics")).Build(QueryTierEssential, QueryStreamRule)
+	if err != nil {
+		t.Fatalf("Query.Build() error = %v", err)
+	}
+	m, err := NewQueryMatcher(query)
+	if err != nil {
+		t.Fatalf("NewQueryMatcher() error = %v", err)
+	}
+	if !m.Match(queryEvalTestTweet(t)) {
+		t.Errorf("QueryMatcher.Match() = false for %s", query)
+	}
+}
+
+func TestQueryMatcher_Explain(t *testing.T) {
+	m, err := NewQueryMatcher("generics -is:reply")
+	if err != nil {
+		t.Fatalf("NewQueryMatcher() error = %v", err)
+	}
+	explain := m.Explain(queryEvalTestTweet(t))
+	want := "+ generics: text contains generics\n+ is:reply: tweet is reply\n"
+	if explain.Matched || explain.String() != want {
+		t.Errorf("QueryMatcher.Explain() = %v %q, want %q", explain.Matched, explain.String(), want)
+	}
+}
+
+func TestNewQueryMatcher_Errors(t *testing.T) {
+	tests := []string{
+		"",
+		`"unterminated`,
+		"(golang",
+		"golang)",
+		"golang OR",
+		`""`,
+		"-",
+		"point_radius:[1 2 3km]",
+		"is:nullcast golang",
+		"bounding_box:[1 2 3]",
+		"from:",
+	}
+	for _, query := range tests {
+		t.Run(query, func(t *testing.T) {
+			_, err := NewQueryMatcher(query)
+			if !errors.Is(err, ErrParameter) {
+				t.Errorf("NewQueryMatcher() error = %v, want ErrParameter", err)
+			}
+		})
+	}
+}
+
+func TestQueryMatcher_NoEntities(t *testing.T) {
+	m, err := NewQueryMatcher("#golang")
+	if err != nil {
+		t.Fatalf("NewQueryMatcher() error = %v", err)
+	}
+	d := CreateTweetDictionary(TweetObj{ID: "1", Text: "learning #GoLang, today"}, nil)
+	if !m.Match(d) {
+		t.Errorf("QueryMatcher.Match() = false\n%s", m.Explain(d))
+	}
+	if strings.Contains(m.Explain(d).String(), "entity") {
+		t.Errorf("QueryMatcher.Explain() used the entities %s", m.Explain(d))
+	}
+}
+
+func TestQueryMatcher_Nil(t *testing.T) {
+	matcher, err := NewQueryMatcher("-is:retweet -has:images -from:gopher")
+	if err != nil {
+		t.Fatalf("NewQueryMatcher() error = %v", err)
+	}
+	if matcher.Match(nil) {
+		t.Errorf("QueryMatcher.Match() nil tweet matched")
+	}
+	if explain := matcher.Explain(nil); explain.Matched || len(explain.Terms) != 0 {
+		t.Errorf("QueryMatcher.Explain() nil tweet = %+v", explain)
+	}
+
+	d := &TweetDictionary{
+		Tweet: TweetObj{
+			ReferencedTweets: []*TweetReferencedTweetObj{nil},
+		},
+		AttachmentMedia: []*MediaObj{nil},
+	}
+	if !matcher.Match(d) {
+		t.Errorf("QueryMatcher.Match() tweet with nil references did not match %s", matcher.Explain(d))
+	}
+}
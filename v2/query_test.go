@@ -0,0 +1,184 @@
+package twitter
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestQuery_Build(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    Query
+		tier     QueryTier
+		endpoint QueryEndpoint
+		want     string
+		wantErr  bool
+	}{
+		{
+			name:     "keywords and operators",
+			query:    QueryAnd(QueryKeyword("golang"), QueryOr(QueryFrom("golang"), QueryMention("@gophers")), QueryNot(QueryIsRetweet()), QueryLang("en"), QueryHasMedia()),
+			endpoint: QueryRecentSearch,
+			want:     "golang (from:golang OR @gophers) -is:retweet lang:en has:media",
+		},
+		{
+			name:     "quoted values",
+			query:    QueryAnd(QueryPhrase(`say "hi"`), QueryKeyword("two words"), QueryKeyword("OR"), QueryKeyword("-minus"), QueryURL("https://go.dev/blog")),
+			endpoint: QueryRecentSearch,
+			want:     `"say \"hi\"" "two words" "OR" "-minus" url:"https://go.dev/blog"`,
+		},
+		{
+			name:     "negated group",
+			query:    QueryAnd(QueryHashtag("#go"), QueryNot(QueryAnd(QueryKeyword("spam"), QueryHasLinks())), QueryTo("golang"), QueryConversationID("1234")),
+			endpoint: QueryRecentSearch,
+			want:     "#go -(spam has:links) to:golang conversation_id:1234",
+		},
+		{
+			name:     "advanced operators",
+			query:    QueryAnd(QueryCashtag("TWTR"), QueryPlaceCountry("US"), QueryBoundingBox(-105.3, 39.9, -105.1, 40.1), QueryHasGeo()),
+			tier:     QueryTierAcademic,
+			endpoint: QueryFullArchiveSearch,
+			want:     "$TWTR place_country:US bounding_box:[-105.3 39.9 -105.1 40.1] has:geo",
+		},
+		{
+			name:     "advanced operator needs academic",
+			query:    QueryAnd(QueryKeyword("stocks"), QueryCashtag("TWTR")),
+			tier:     QueryTierElevated,
+			endpoint: QueryRecentSearch,
+			wantErr:  true,
+		},
+		{
+			name:     "full archive needs academic",
+			query:    QueryKeyword("golang"),
+			tier:     QueryTierEssential,
+			endpoint: QueryFullArchiveSearch,
+			wantErr:  true,
+		},
+		{
+			name:     "conjunction required",
+			query:    QueryAnd(QueryIsRetweet(), QueryLang("en")),
+			endpoint: QueryRecentSearch,
+			wantErr:  true,
+		},
+		{
+			name:     "only negated",
+			query:    QueryNot(QueryKeyword("spam")),
+			endpoint: QueryRecentSearch,
+			wantErr:  true,
+		},
+		{
+			name:     "or with conjunction required",
+			query:    QueryOr(QueryKeyword("golang"), QueryHasMedia()),
+			endpoint: QueryRecentSearch,
+			wantErr:  true,
+		},
+		{
+			name:     "too long",
+			query:    QueryKeyword(strings.Repeat("a", 513)),
+			endpoint: QueryRecentSearch,
+			wantErr:  true,
+		},
+		{
+			name:     "academic rule length",
+			query:    QueryKeyword(strings.Repeat("a", 1000)),
+			tier:     QueryTierAcademic,
+			endpoint: QueryStreamRule,
+			want:     strings.Repeat("a", 1000),
+		},
+		{
+			name:     "length in characters",
+			query:    QueryKeyword(strings.Repeat("é", 500)),
+			endpoint: QueryStreamRule,
+			want:     strings.Repeat("é", 500),
+		},
+		{
+			name:     "invalid username",
+			query:    QueryAnd(QueryKeyword("golang"), QueryFrom("not a user")),
+			endpoint: QueryRecentSearch,
+			wantErr:  true,
+		},
+		{
+			name:     "invalid bounding box",
+			query:    QueryBoundingBox(10, 10, 5, 20),
+			tier:     QueryTierAcademic,
+			endpoint: QueryRecentSearch,
+			wantErr:  true,
+		},
+		{
+			name:     "invalid lang",
+			query:    QueryAnd(QueryKeyword("golang"), QueryLang("English")),
+			endpoint: QueryRecentSearch,
+			wantErr:  true,
+		},
+		{
+			name:     "empty",
+			query:    QueryAnd(),
+			endpoint: QueryRecentSearch,
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.query.Build(tt.tier, tt.endpoint)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Query.Build() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrParameter) {
+				t.Errorf("Query.Build() error = %v, want ErrParameter", err)
+			}
+			if got != tt.want {
+				t.Errorf("Query.Build() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuery_Rule(t *testing.T) {
+	rule, err := QueryAnd(QueryHashtag("golang"), QueryNot(QueryIsRetweet())).Rule(QueryTierEssential, "golang")
+	if err != nil {
+		t.Fatalf("Query.Rule() error = %v", err)
+	}
+	if rule.Value != "#golang -is:retweet" || rule.Tag != "golang" {
+		t.Errorf("Query.Rule() = %+v", rule)
+	}
+	if _, err := QueryKeyword(strings.Repeat("a", 513)).Rule(QueryTierElevated, "long"); !errors.Is(err, ErrParameter) {
+		t.Errorf("Query.Rule() error = %v, want ErrParameter", err)
+	}
+}
+
+func TestQuery_BuildLength(t *testing.T) {
+	c := &Client{
+		Authorizer: &mockAuth{},
+		Host:       "https://www.test.com",
+		Client: mockHTTPClient(func(req *http.Request) *http.Response {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{},
+				Request:    req,
+				Body:       io.NopCloser(strings.NewReader(`{"meta":{"result_count":0}}`)),
+			}
+		}),
+	}
+
+	// the query is 512 characters and over 512 bytes
+	query, err := QueryKeyword(strings.Repeat("é", 512)).Build(QueryTierEssential, QueryRecentSearch)
+	if err != nil {
+		t.Fatalf("Query.Build() error = %v", err)
+	}
+	if _, err := c.TweetRecentSearch(context.Background(), query, TweetRecentSearchOpts{}); err != nil {
+		t.Errorf("Client.TweetRecentSearch() error = %v, want the built query to be accepted", err)
+	}
+	if _, err := c.TweetRecentCounts(context.Background(), query, TweetRecentCountsOpts{}); err != nil {
+		t.Errorf("Client.TweetRecentCounts() error = %v, want the built query to be accepted", err)
+	}
+
+	if _, err := QueryKeyword(strings.Repeat("é", 513)).Build(QueryTierEssential, QueryRecentSearch); !errors.Is(err, ErrParameter) {
+		t.Errorf("Query.Build() error = %v, want ErrParameter", err)
+	}
+	if _, err := c.TweetRecentSearch(context.Background(), strings.Repeat("é", 513), TweetRecentSearchOpts{}); !errors.Is(err, ErrParameter) {
+		t.Errorf("Client.TweetRecentSearch() error = %v, want ErrParameter", err)
+	}
+}
@@ -0,0 +1,206 @@
+package twitter
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// RateLimitMode is how the rate limiter handles a callout when the rate limit has been exhausted
+type RateLimitMode int
+
+const (
+	// RateLimitWait will block the callout until the rate limit resets or the context is done
+	RateLimitWait RateLimitMode = iota
+	// RateLimitFailFast will return a RateLimitError without sending the callout
+	RateLimitFailFast
+)
+
+// RateLimiter will schedule the callouts using the rate limits returned in the response headers.
+//
+// The rate limits are tracked per endpoint and HTTP method for each authorizer.  Before a callout
+// is sent, the rate limiter will check the remaining limit, including the callouts that are in flight,
+// and will either wait until the reset or fail fast.  The rate limiter is safe for concurrent use.
+//
+// An authorizer is told apart by its RateLimitKey when it implements RateLimitKeyer, by its pointer or by
+// its value.  The authorizer values that can not be compared share the rate limits of their type.
+type RateLimiter struct {
+	mode    RateLimitMode
+	mutex   sync.Mutex
+	buckets map[rateLimitKey]*rateLimitBucket
+}
+
+// NewRateLimiter will create a rate limiter with the mode
+func NewRateLimiter(mode RateLimitMode) *RateLimiter {
+	return &RateLimiter{
+		mode:    mode,
+		buckets: map[rateLimitKey]*rateLimitBucket{},
+	}
+}
+
+type rateLimitKey struct {
+	authorizer interface{}
+	method     string
+	endpoint   endpoint
+}
+
+type rateLimitBucket struct {
+	known     bool
+	limit     int
+	remaining int
+	reset     time.Time
+	pending   int
+	changed   chan struct{}
+}
+
+func (b *rateLimitBucket) rateLimit() *RateLimit {
+	return &RateLimit{
+		Limit:     b.limit,
+		Remaining: b.remaining,
+		Reset:     Epoch(b.reset.Unix()),
+	}
+}
+
+// broadcast will wake up any callouts that are waiting on the bucket
+func (b *rateLimitBucket) broadcast() {
+	close(b.changed)
+	b.changed = make(chan struct{})
+}
+
+// RateLimitKeyer is implemented by an authorizer that sets the key of its rate limits, the authorizers with the same
+// key share the rate limits
+type RateLimitKeyer interface {
+	RateLimitKey() string
+}
+
+type rateLimitAuthorizerKey string
+
+// authorizerKey returns the rate limit key of the authorizer, which is its own key, the authorizer itself when it is
+// a pointer or a value that can be compared, or else its type
+func authorizerKey(auth Authorizer) interface{} {
+	if auth == nil {
+		return nil
+	}
+	if keyer, ok := auth.(RateLimitKeyer); ok {
+		return rateLimitAuthorizerKey(keyer.RateLimitKey())
+	}
+	if comparableValue(reflect.ValueOf(auth)) {
+		return auth
+	}
+	return fmt.Sprintf("%T", auth)
+}
+
+// comparableValue returns if the value can be a map key, the interfaces it holds are checked by their dynamic value
+func comparableValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Interface:
+		return v.IsNil() || comparableValue(v.Elem())
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if !comparableValue(v.Field(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if !comparableValue(v.Index(i)) {
+				return false
+			}
+		}
+		return true
+	default:
+		return v.Type().Comparable()
+	}
+}
+
+func (r *RateLimiter) key(auth Authorizer, method string, ep endpoint) rateLimitKey {
+	return rateLimitKey{
+		authorizer: authorizerKey(auth),
+		method:     method,
+		endpoint:   ep,
+	}
+}
+
+func (r *RateLimiter) bucket(key rateLimitKey) *rateLimitBucket {
+	b, has := r.buckets[key]
+	if !has {
+		b = &rateLimitBucket{
+			changed: make(chan struct{}),
+		}
+		r.buckets[key] = b
+	}
+	return b
+}
+
+// acquire will reserve a callout from the bucket, waiting if the rate limit has been exhausted
+func (r *RateLimiter) acquire(ctx context.Context, key rateLimitKey) error {
+	for {
+		r.mutex.Lock()
+		b := r.bucket(key)
+		now := time.Now()
+		if b.known && !b.reset.IsZero() && !now.Before(b.reset) {
+			b.remaining = b.limit
+			b.reset = time.Time{}
+		}
+		// without a reset the limit is only refreshed by a callout, so one is sent when none are in flight
+		if !b.known || b.remaining-b.pending > 0 || (b.reset.IsZero() && b.pending == 0) {
+			b.pending++
+			r.mutex.Unlock()
+			return nil
+		}
+		rl := b.rateLimit()
+		changed := b.changed
+		wait := b.reset.Sub(now)
+		r.mutex.Unlock()
+
+		if r.mode == RateLimitFailFast {
+			return &RateLimitError{
+				Method:    key.method,
+				Endpoint:  string(key.endpoint),
+				RateLimit: rl,
+			}
+		}
+
+		if err := r.wait(ctx, changed, wait); err != nil {
+			return err
+		}
+	}
+}
+
+// wait will block until the bucket has changed, the reset has passed or the context is done
+func (r *RateLimiter) wait(ctx context.Context, changed <-chan struct{}, reset time.Duration) error {
+	var timeout <-chan time.Time
+	if reset > 0 {
+		timer := time.NewTimer(reset)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-changed:
+	case <-timeout:
+	}
+	return nil
+}
+
+// release will return the reservation and update the bucket with the rate limits of the response
+func (r *RateLimiter) release(key rateLimitKey, rl *RateLimit) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	b := r.bucket(key)
+	if b.pending > 0 {
+		b.pending--
+	}
+	if rl != nil {
+		b.known = true
+		b.limit = rl.Limit
+		b.remaining = rl.Remaining
+		b.reset = rl.Reset.Time()
+	}
+	b.broadcast()
+}
@@ -0,0 +1,173 @@
+package twitter
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func rateLimiterTestClient(limiter *RateLimiter, remaining *int32, reset time.Time) *Client {
+	return &Client{
+		Authorizer:  &mockAuth{},
+		Host:        "https://www.test.com",
+		RateLimiter: limiter,
+		Client: mockHTTPClient(func(req *http.Request) *http.Response {
+			left := atomic.AddInt32(remaining, -1)
+			if left < 0 {
+				left = 0
+			}
+			h := http.Header{}
+			h.Add(rateLimit, "15")
+			h.Add(rateRemaining, strconv.Itoa(int(left)))
+			h.Add(rateReset, strconv.FormatInt(reset.Unix(), 10))
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(strings.NewReader(`{"data":{"id":"2244994945","name":"TwitterDev","username":"TwitterDev"}}`)),
+				Header:     h,
+			}
+		}),
+	}
+}
+
+func TestRateLimiter_FailFast(t *testing.T) {
+	remaining := int32(2)
+	c := rateLimiterTestClient(NewRateLimiter(RateLimitFailFast), &remaining, time.Now().Add(time.Hour))
+
+	for i := 0; i < 2; i++ {
+		if _, err := c.AuthUserLookup(context.Background(), UserLookupOpts{}); err != nil {
+			t.Fatalf("AuthUserLookup() callout %d error = %v", i, err)
+		}
+	}
+	_, err := c.AuthUserLookup(context.Background(), UserLookupOpts{})
+	rlErr := &RateLimitError{}
+	if !errors.As(err, &rlErr) {
+		t.Fatalf("AuthUserLookup() error = %v, want RateLimitError", err)
+	}
+	if rlErr.Endpoint != string(userAuthLookupEndpoint) || rlErr.Method != http.MethodGet {
+		t.Errorf("AuthUserLookup() rate limit error = %v", rlErr)
+	}
+	if rl, has := RateLimitFromError(err); !has || rl.Remaining != 0 {
+		t.Errorf("RateLimitFromError() = %v, %v", rl, has)
+	}
+	if remaining != 0 {
+		t.Errorf("AuthUserLookup() callouts sent after the limit was exhausted")
+	}
+
+	// other endpoints are in their own bucket
+	if _, err := c.UserLookup(context.Background(), []string{"2244994945"}, UserLookupOpts{}); err != nil {
+		t.Errorf("UserLookup() error = %v", err)
+	}
+}
+
+func TestRateLimiter_WaitContext(t *testing.T) {
+	remaining := int32(1)
+	c := rateLimiterTestClient(NewRateLimiter(RateLimitWait), &remaining, time.Now().Add(time.Hour))
+
+	if _, err := c.AuthUserLookup(context.Background(), UserLookupOpts{}); err != nil {
+		t.Fatalf("AuthUserLookup() error = %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := c.AuthUserLookup(ctx, UserLookupOpts{})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("AuthUserLookup() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestRateLimiter_WaitReset(t *testing.T) {
+	remaining := int32(1)
+	reset := time.Now().Add(time.Second)
+	c := rateLimiterTestClient(NewRateLimiter(RateLimitWait), &remaining, reset)
+
+	if _, err := c.AuthUserLookup(context.Background(), UserLookupOpts{}); err != nil {
+		t.Fatalf("AuthUserLookup() error = %v", err)
+	}
+	if _, err := c.AuthUserLookup(context.Background(), UserLookupOpts{}); err != nil {
+		t.Fatalf("AuthUserLookup() error = %v", err)
+	}
+	if time.Now().Before(time.Unix(reset.Unix(), 0)) {
+		t.Errorf("AuthUserLookup() did not wait for the reset %v", reset)
+	}
+}
+
+func TestRateLimiter_Concurrent(t *testing.T) {
+	remaining := int32(4)
+	c := rateLimiterTestClient(NewRateLimiter(RateLimitFailFast), &remaining, time.Now().Add(time.Hour))
+
+	// the first callout will learn the rate limits
+	if _, err := c.AuthUserLookup(context.Background(), UserLookupOpts{}); err != nil {
+		t.Fatalf("AuthUserLookup() error = %v", err)
+	}
+
+	var success int32
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := c.AuthUserLookup(context.Background(), UserLookupOpts{}); err == nil {
+				atomic.AddInt32(&success, 1)
+			}
+		}()
+	}
+	wg.Wait()
+	if success != 3 {
+		t.Errorf("AuthUserLookup() concurrent success = %d, want 3", success)
+	}
+}
+
+func TestRateLimiter_NoReset(t *testing.T) {
+	limiter := NewRateLimiter(RateLimitWait)
+	key := limiter.key(&mockAuth{}, http.MethodGet, userAuthLookupEndpoint)
+	limiter.release(key, &RateLimit{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := limiter.acquire(ctx, key); err != nil {
+		t.Errorf("RateLimiter.acquire() error = %v, want a callout to refresh the limit", err)
+	}
+}
+
+type rateLimiterTestAuth struct {
+	values interface{}
+}
+
+func (rateLimiterTestAuth) Add(req *http.Request) {}
+
+type rateLimiterTestKeyAuth struct {
+	key string
+}
+
+func (a rateLimiterTestKeyAuth) Add(req *http.Request) {}
+
+func (a rateLimiterTestKeyAuth) RateLimitKey() string {
+	return a.key
+}
+
+func TestAuthorizerKey(t *testing.T) {
+	limiter := NewRateLimiter(RateLimitWait)
+	first, second := &rateLimiterTestAuth{}, &rateLimiterTestAuth{}
+	if limiter.key(first, http.MethodGet, userAuthLookupEndpoint) == limiter.key(second, http.MethodGet, userAuthLookupEndpoint) {
+		t.Errorf("RateLimiter.key() is the same for two pointers")
+	}
+	if authorizerKey(rateLimiterTestKeyAuth{key: "a"}) == authorizerKey(rateLimiterTestKeyAuth{key: "b"}) {
+		t.Errorf("authorizerKey() is the same for two rate limit keys")
+	}
+
+	// the value holds a map in an interface, so it can not be compared
+	auth := rateLimiterTestAuth{values: map[string]string{}}
+	limiter.release(limiter.key(auth, http.MethodGet, userAuthLookupEndpoint), nil)
+	if authorizerKey(auth) != "twitter.rateLimiterTestAuth" {
+		t.Errorf("authorizerKey() = %v, want the type", authorizerKey(auth))
+	}
+	if authorizerKey(rateLimiterTestAuth{values: "token"}) != (rateLimiterTestAuth{values: "token"}) {
+		t.Errorf("authorizerKey() = %v, want the value", authorizerKey(rateLimiterTestAuth{values: "token"}))
+	}
+}
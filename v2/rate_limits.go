@@ -53,6 +53,7 @@ func RateLimitFromError(err error) (*RateLimit, bool) {
 	var er *ErrorResponse
 	var hr *HTTPError
 	var rde *ResponseDecodeError
+	var rle *RateLimitError
 	switch {
 	case errors.As(err, &er) && er.RateLimit != nil:
 		return er.RateLimit, true
@@ -60,6 +61,8 @@ func RateLimitFromError(err error) (*RateLimit, bool) {
 		return hr.RateLimit, true
 	case errors.As(err, &rde) && rde.RateLimit != nil:
 		return rde.RateLimit, true
+	case errors.As(err, &rle) && rle.RateLimit != nil:
+		return rle.RateLimit, true
 	default:
 	}
 	return nil, false
@@ -119,6 +119,24 @@ func TestRateLimitFromError(t *testing.T) {
 			},
 			want1: true,
 		},
+		{
+			name: "rate limit error",
+			args: args{
+				err: &RateLimitError{
+					RateLimit: &RateLimit{
+						Limit:     15,
+						Remaining: 0,
+						Reset:     Epoch(1644461060),
+					},
+				},
+			},
+			want: &RateLimit{
+				Limit:     15,
+				Remaining: 0,
+				Reset:     Epoch(1644461060),
+			},
+			want1: true,
+		},
 		{
 			name: "error",
 			args: args{
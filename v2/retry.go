@@ -0,0 +1,235 @@
+package twitter
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+const (
+	retryDefaultMaxAttempts = 3
+	retryDefaultBaseDelay   = time.Second
+	retryDefaultMaxDelay    = 30 * time.Second
+)
+
+// RetryNotify is called before a callout is retried with the attempt that failed, the delay before
+// the next attempt and the cause of the failure
+type RetryNotify func(attempt int, delay time.Duration, cause error)
+
+// RetrySucceed is called when a callout succeeds with the number of attempts it took, including the first
+type RetrySucceed func(attempts int)
+
+// RetryPolicy will retry callouts on 429, 500, 502, 503 and 504 responses and transient network errors.
+//
+// The delay between attempts is an exponential back off with jitter.  When the response is a 429 with
+// rate limits, the next attempt will wait for the rate limit reset.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first, defaults to 3
+	MaxAttempts int
+	// BaseDelay is the delay of the first back off, defaults to 1 second
+	BaseDelay time.Duration
+	// MaxDelay is the max delay of the back off, defaults to 30 seconds
+	MaxDelay time.Duration
+	// MaxResetWait is the max time to wait for a rate limit reset before giving up, zero will always wait
+	MaxResetWait time.Duration
+	// RetryWrites will also retry POST callouts, which are not idempotent
+	RetryWrites bool
+	// Notify is optional and called before each retry
+	Notify RetryNotify
+	// Succeed is optional and called when a callout succeeds
+	Succeed RetrySucceed
+}
+
+func (r *RetryPolicy) maxAttempts() int {
+	if r.MaxAttempts > 0 {
+		return r.MaxAttempts
+	}
+	return retryDefaultMaxAttempts
+}
+
+func (r *RetryPolicy) retryable(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return r.RetryWrites
+	}
+}
+
+func (r *RetryPolicy) backoff(attempt int) time.Duration {
+	base := r.BaseDelay
+	if base <= 0 {
+		base = retryDefaultBaseDelay
+	}
+	max := r.MaxDelay
+	if max <= 0 {
+		max = retryDefaultMaxDelay
+	}
+	// the base is compared to the shifted max, shifting the base can overflow
+	delay := max
+	if shift := attempt - 1; shift >= 0 && shift < 32 && base <= max>>shift {
+		delay = base << shift
+	}
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// delay will return the delay before the next attempt and if the callout should be retried
+func (r *RetryPolicy) delay(attempt int, resp *http.Response) (time.Duration, bool) {
+	delay := r.backoff(attempt)
+	if resp == nil || resp.StatusCode != http.StatusTooManyRequests {
+		return delay, true
+	}
+	rl := rateFromHeader(resp.Header)
+	if rl == nil {
+		return delay, true
+	}
+	wait := time.Until(rl.Reset.Time())
+	if r.MaxResetWait > 0 && wait > r.MaxResetWait {
+		return 0, false
+	}
+	if wait > delay {
+		delay = wait
+	}
+	return delay, true
+}
+
+// do will send the request until it succeeds, the error is not retryable or the attempts are exhausted
+func (r *RetryPolicy) do(req *http.Request, send func(*http.Request, int) (*http.Response, error)) (*http.Response, error) {
+	ctx := req.Context()
+	for attempt := 1; ; attempt++ {
+		attemptReq, err := retryRequest(req, attempt)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := send(attemptReq, attempt)
+
+		retry := attempt < r.maxAttempts() && retryableResponse(resp, err)
+		var delay time.Duration
+		if retry {
+			delay, retry = r.delay(attempt, resp)
+		}
+		if !retry {
+			resp, err = retryResult(attempt, resp, err)
+			if err == nil && r.Succeed != nil && successfulResponse(resp) {
+				r.Succeed(attempt)
+			}
+			return resp, err
+		}
+
+		cause := err
+		if cause == nil {
+			cause = responseError(resp)
+		}
+		if r.Notify != nil {
+			r.Notify(attempt, delay, cause)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, &RetryError{
+				Attempts: attempt,
+				Err:      ctx.Err(),
+			}
+		case <-timer.C:
+		}
+	}
+}
+
+// retryRequest will create the request for the attempt, the body is recreated for each retry
+func retryRequest(req *http.Request, attempt int) (*http.Request, error) {
+	if attempt == 1 {
+		return req.Clone(req.Context()), nil
+	}
+	attemptReq, err := replayRequest(req)
+	if err != nil {
+		return nil, &RetryError{
+			Attempts: attempt - 1,
+			Err:      err,
+		}
+	}
+	return attemptReq, nil
+}
+
+// replayRequest will clone the request with a new body so it can be sent again
+func replayRequest(req *http.Request) (*http.Request, error) {
+	replay := req.Clone(req.Context())
+	if req.Body == nil || req.Body == http.NoBody {
+		return replay, nil
+	}
+	if req.GetBody == nil {
+		return nil, errors.New("request body can not be replayed")
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	replay.Body = body
+	return replay, nil
+}
+
+// retryResult will return the final attempt, wrapping any failure with the number of attempts
+func retryResult(attempt int, resp *http.Response, err error) (*http.Response, error) {
+	switch {
+	case attempt == 1:
+		return resp, err
+	case err != nil:
+		return nil, &RetryError{
+			Attempts: attempt,
+			Err:      err,
+		}
+	case !successfulResponse(resp):
+		return nil, &RetryError{
+			Attempts: attempt,
+			Err:      responseError(resp),
+		}
+	default:
+		return resp, nil
+	}
+}
+
+func successfulResponse(resp *http.Response) bool {
+	return resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusMultipleChoices
+}
+
+func retryableResponse(resp *http.Response, err error) bool {
+	if err != nil {
+		return retryableError(err)
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+func retryableError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		err = urlErr.Err
+	}
+	var netErr net.Error
+	switch {
+	case errors.As(err, &netErr):
+		return true
+	case errors.Is(err, io.ErrUnexpectedEOF), errors.Is(err, io.EOF):
+		return true
+	case errors.Is(err, syscall.ECONNRESET), errors.Is(err, syscall.ECONNREFUSED):
+		return true
+	default:
+		return false
+	}
+}
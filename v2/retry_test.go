@@ -0,0 +1,309 @@
+package twitter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+type retryRoundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f retryRoundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+type retryTestAuth struct{}
+
+func (a retryTestAuth) Add(req *http.Request) {
+	req.Header.Add("Authorization", "Bearer token")
+}
+
+func retryTestResponse(code int, body string, header http.Header) *http.Response {
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		StatusCode: code,
+		Status:     http.StatusText(code),
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Header:     header,
+	}
+}
+
+const retryTestUser = `{"data":{"id":"2244994945","name":"TwitterDev","username":"TwitterDev"}}`
+
+func TestRetryPolicy_Idempotent(t *testing.T) {
+	tests := []struct {
+		name         string
+		responses    []*http.Response
+		errs         []error
+		wantAttempts int
+		wantRetryErr bool
+		wantStatus   int
+		wantErr      bool
+	}{
+		{
+			name: "first try success",
+			responses: []*http.Response{
+				retryTestResponse(http.StatusOK, retryTestUser, nil),
+			},
+			wantAttempts: 1,
+		},
+		{
+			name: "retried success",
+			responses: []*http.Response{
+				retryTestResponse(http.StatusServiceUnavailable, `{"title":"Service Unavailable"}`, nil),
+				retryTestResponse(http.StatusBadGateway, `<html></html>`, nil),
+				retryTestResponse(http.StatusOK, retryTestUser, nil),
+			},
+			wantAttempts: 3,
+		},
+		{
+			name: "transient network error",
+			responses: []*http.Response{
+				nil,
+				retryTestResponse(http.StatusOK, retryTestUser, nil),
+			},
+			errs: []error{
+				syscall.ECONNRESET,
+			},
+			wantAttempts: 2,
+		},
+		{
+			name: "attempts exhausted",
+			responses: []*http.Response{
+				retryTestResponse(http.StatusInternalServerError, `{"title":"Internal Error"}`, nil),
+				retryTestResponse(http.StatusInternalServerError, `{"title":"Internal Error"}`, nil),
+				retryTestResponse(http.StatusInternalServerError, `{"title":"Internal Error"}`, nil),
+			},
+			wantAttempts: 3,
+			wantRetryErr: true,
+			wantStatus:   http.StatusInternalServerError,
+			wantErr:      true,
+		},
+		{
+			name: "not retryable",
+			responses: []*http.Response{
+				retryTestResponse(http.StatusBadRequest, `{"title":"Invalid Request"}`, nil),
+			},
+			wantAttempts: 1,
+			wantStatus:   http.StatusBadRequest,
+			wantErr:      true,
+		},
+		{
+			name: "rate limit reset too far",
+			responses: []*http.Response{
+				retryTestResponse(http.StatusTooManyRequests, `{"title":"Too Many Requests"}`, func() http.Header {
+					h := http.Header{}
+					h.Add(rateLimit, "15")
+					h.Add(rateRemaining, "0")
+					h.Add(rateReset, strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10))
+					return h
+				}()),
+			},
+			wantAttempts: 1,
+			wantStatus:   http.StatusTooManyRequests,
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			notified := 0
+			succeeded := 0
+			c := &Client{
+				Authorizer: retryTestAuth{},
+				Host:       "https://www.test.com",
+				Retry: &RetryPolicy{
+					BaseDelay:    time.Millisecond,
+					MaxDelay:     5 * time.Millisecond,
+					MaxResetWait: time.Minute,
+					Notify: func(attempt int, delay time.Duration, cause error) {
+						notified++
+						if cause == nil {
+							t.Errorf("RetryPolicy notify attempt %d without a cause", attempt)
+						}
+					},
+					Succeed: func(attempts int) {
+						succeeded = attempts
+					},
+				},
+				Client: &http.Client{
+					Transport: retryRoundTripFunc(func(req *http.Request) (*http.Response, error) {
+						idx := attempts
+						attempts++
+						if len(req.Header.Values("Authorization")) != 1 {
+							t.Errorf("RetryPolicy attempt %d authorization %v", attempts, req.Header.Values("Authorization"))
+						}
+						if idx < len(tt.errs) && tt.errs[idx] != nil {
+							return nil, tt.errs[idx]
+						}
+						resp := tt.responses[idx]
+						resp.Request = req
+						return resp, nil
+					}),
+				},
+			}
+			_, err := c.AuthUserLookup(context.Background(), UserLookupOpts{})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("AuthUserLookup() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if attempts != tt.wantAttempts {
+				t.Errorf("AuthUserLookup() attempts = %d, want %d", attempts, tt.wantAttempts)
+			}
+			if notified != tt.wantAttempts-1 {
+				t.Errorf("AuthUserLookup() notified = %d, want %d", notified, tt.wantAttempts-1)
+			}
+			wantSucceeded := tt.wantAttempts
+			if tt.wantErr {
+				wantSucceeded = 0
+			}
+			if succeeded != wantSucceeded {
+				t.Errorf("AuthUserLookup() succeeded attempts = %d, want %d", succeeded, wantSucceeded)
+			}
+			retryErr := &RetryError{}
+			if errors.As(err, &retryErr) != tt.wantRetryErr {
+				t.Errorf("AuthUserLookup() retry error = %v, want %v", err, tt.wantRetryErr)
+			}
+			if tt.wantRetryErr && retryErr.Attempts != tt.wantAttempts {
+				t.Errorf("AuthUserLookup() retry error attempts = %d, want %d", retryErr.Attempts, tt.wantAttempts)
+			}
+			if tt.wantStatus > 0 {
+				errResp := &ErrorResponse{}
+				if !errors.As(err, &errResp) || errResp.StatusCode != tt.wantStatus {
+					t.Errorf("AuthUserLookup() error = %v, want status %d", err, tt.wantStatus)
+				}
+			}
+		})
+	}
+}
+
+func TestRetryPolicy_Writes(t *testing.T) {
+	tests := []struct {
+		name         string
+		retryWrites  bool
+		wantAttempts int
+		wantErr      bool
+	}{
+		{
+			name:         "writes are not retried",
+			retryWrites:  false,
+			wantAttempts: 1,
+			wantErr:      true,
+		},
+		{
+			name:         "writes are retried",
+			retryWrites:  true,
+			wantAttempts: 2,
+			wantErr:      false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			c := &Client{
+				Authorizer: &mockAuth{},
+				Host:       "https://www.test.com",
+				Retry: &RetryPolicy{
+					BaseDelay:   time.Millisecond,
+					RetryWrites: tt.retryWrites,
+				},
+				Client: mockHTTPClient(func(req *http.Request) *http.Response {
+					attempts++
+					body, err := io.ReadAll(req.Body)
+					if err != nil || !strings.Contains(string(body), "Hello World!") {
+						t.Errorf("CreateTweet() attempt %d body %s %v", attempts, string(body), err)
+					}
+					resp := retryTestResponse(http.StatusServiceUnavailable, `{"title":"Service Unavailable"}`, nil)
+					if attempts > 1 {
+						resp = retryTestResponse(http.StatusCreated, `{"data":{"id":"1445880548472328192","text":"Hello World!"}}`, nil)
+					}
+					resp.Request = req
+					return resp
+				}),
+			}
+			_, err := c.CreateTweet(context.Background(), CreateTweetRequest{Text: "Hello World!"})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CreateTweet() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if attempts != tt.wantAttempts {
+				t.Errorf("CreateTweet() attempts = %d, want %d", attempts, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestRetryPolicy_Context(t *testing.T) {
+	c := &Client{
+		Authorizer: &mockAuth{},
+		Host:       "https://www.test.com",
+		Retry: &RetryPolicy{
+			BaseDelay: time.Hour,
+			MaxDelay:  time.Hour,
+		},
+		Client: mockHTTPClient(func(req *http.Request) *http.Response {
+			resp := retryTestResponse(http.StatusServiceUnavailable, `{"title":"Service Unavailable"}`, nil)
+			resp.Request = req
+			return resp
+		}),
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := c.AuthUserLookup(ctx, UserLookupOpts{})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("AuthUserLookup() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestRetryPolicy_backoff(t *testing.T) {
+	r := &RetryPolicy{
+		BaseDelay: 100 * time.Millisecond,
+		MaxDelay:  time.Second,
+	}
+	for attempt := 1; attempt < 40; attempt++ {
+		max := time.Second
+		if attempt < 5 {
+			max = 100 * time.Millisecond << (attempt - 1)
+		}
+		got := r.backoff(attempt)
+		if got < max/2 || got > max {
+			t.Errorf("RetryPolicy.backoff(%d) = %v, want between %v and %v", attempt, got, max/2, max)
+		}
+	}
+}
+
+func TestRetryPolicy_backoffOverflow(t *testing.T) {
+	r := &RetryPolicy{
+		BaseDelay: time.Hour,
+		MaxDelay:  math.MaxInt64,
+	}
+	for attempt := 1; attempt < 40; attempt++ {
+		if got := r.backoff(attempt); got < time.Hour/2 {
+			t.Errorf("RetryPolicy.backoff(%d) = %v, want no overflow", attempt, got)
+		}
+	}
+}
+
+func TestRetryError(t *testing.T) {
+	err := fmt.Errorf("user lookup response: %w", &RetryError{
+		Attempts: 3,
+		Err: &ErrorResponse{
+			StatusCode: http.StatusServiceUnavailable,
+		},
+	})
+	if !strings.Contains(err.Error(), "3 attempts") {
+		t.Errorf("RetryError.Error() = %v", err)
+	}
+	errResp := &ErrorResponse{}
+	if !errors.As(err, &errResp) {
+		t.Errorf("RetryError.Unwrap() = %v", err)
+	}
+}
@@ -46,6 +46,9 @@ const (
 	ScopeDMRead         = "dm.read"          // Read Direct Messages
 	ScopeDMWrite        = "dm.write"         // Send and manage Direct Messages
 	
+	// Media Scopes
+	ScopeMediaWrite     = "media.write"      // Upload media
+	
 	// Offline Access
 	ScopeOfflineAccess  = "offline.access"   // Maintain access when user not present
 )
@@ -87,6 +90,7 @@ var (
 		ScopeBookmarkWrite,
 		ScopeDMRead,
 		ScopeDMWrite,
+		ScopeMediaWrite,
 	}
 	
 	// AllScopes - All available scopes including moderation and offline access
@@ -112,6 +116,7 @@ var (
 		ScopeBookmarkWrite,
 		ScopeDMRead,
 		ScopeDMWrite,
+		ScopeMediaWrite,
 		ScopeOfflineAccess,
 	}
 	
@@ -154,7 +154,7 @@ func TestReadOnlyScopes(t *testing.T) {
 	for _, scope := range ReadOnlyScopes {
 		if !contains([]string{
 			ScopeTweetRead, ScopeUsersRead, ScopeFollowsRead, ScopeLikeRead,
-			ScopeListRead, ScopeBlockRead, ScopeMuteRead, ScopeSpaceRead, ScopeBookmarkRead,
+			ScopeListRead, ScopeBlockRead, ScopeMuteRead, ScopeSpaceRead, ScopeBookmarkRead, ScopeDMRead,
 		}, scope) {
 			t.Errorf("ReadOnlyScopes contains non-read scope: %s", scope)
 		}
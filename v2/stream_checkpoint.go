@@ -0,0 +1,581 @@
+package twitter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	streamCheckpointInterval = time.Second
+	// the recent search only reaches back seven days, the recovery starts a minute inside of it
+	tweetRecentSearchWindow  = 7 * 24 * time.Hour
+	streamRecoverWindowSlack = time.Minute
+)
+
+// StreamCheckpoint is the position of a stream consumer
+type StreamCheckpoint struct {
+	// TweetID is the newest tweet that has been handled, it is empty if no tweets have been handled
+	TweetID string `json:"tweet_id,omitempty"`
+	// ReceivedAt is when the checkpoint was recorded
+	ReceivedAt time.Time `json:"received_at"`
+}
+
+// StreamCheckpointStore keeps the checkpoints of the stream consumers so a restarted consumer can resume
+type StreamCheckpointStore interface {
+	// Load will return the checkpoint of the stream, or nil if there is no checkpoint
+	Load(ctx context.Context, name string) (*StreamCheckpoint, error)
+	// Save will store the checkpoint of the stream
+	Save(ctx context.Context, name string, checkpoint *StreamCheckpoint) error
+}
+
+// MemoryStreamCheckpointStore is a checkpoint store for a single process
+type MemoryStreamCheckpointStore struct {
+	mutex       sync.Mutex
+	checkpoints map[string]StreamCheckpoint
+}
+
+// NewMemoryStreamCheckpointStore creates an empty memory checkpoint store
+func NewMemoryStreamCheckpointStore() *MemoryStreamCheckpointStore {
+	return &MemoryStreamCheckpointStore{
+		checkpoints: map[string]StreamCheckpoint{},
+	}
+}
+
+// Load will return the checkpoint of the stream
+func (m *MemoryStreamCheckpointStore) Load(_ context.Context, name string) (*StreamCheckpoint, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	checkpoint, has := m.checkpoints[name]
+	if !has {
+		return nil, nil
+	}
+	return &checkpoint, nil
+}
+
+// Save will store the checkpoint of the stream
+func (m *MemoryStreamCheckpointStore) Save(_ context.Context, name string, checkpoint *StreamCheckpoint) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.checkpoints[name] = *checkpoint
+	return nil
+}
+
+// FileStreamCheckpointStore is a checkpoint store that keeps each stream as a JSON file in a directory
+type FileStreamCheckpointStore struct {
+	// Dir is the directory of the checkpoint files
+	Dir   string
+	mutex sync.Mutex
+}
+
+// NewFileStreamCheckpointStore creates a file checkpoint store
+func NewFileStreamCheckpointStore(dir string) *FileStreamCheckpointStore {
+	return &FileStreamCheckpointStore{
+		Dir: dir,
+	}
+}
+
+// Load will read the checkpoint of the stream from its file
+func (f *FileStreamCheckpointStore) Load(_ context.Context, name string) (*StreamCheckpoint, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	data, err := os.ReadFile(storeFilePath(f.Dir, name))
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return nil, nil
+	case err != nil:
+		return nil, fmt.Errorf("stream checkpoint store read: %w", err)
+	}
+	checkpoint := &StreamCheckpoint{}
+	if err := json.Unmarshal(data, checkpoint); err != nil {
+		return nil, fmt.Errorf("stream checkpoint store decode: %w", err)
+	}
+	return checkpoint, nil
+}
+
+// Save will write the checkpoint to a temp file and rename it, so a crash never leaves a partial checkpoint
+func (f *FileStreamCheckpointStore) Save(_ context.Context, name string, checkpoint *StreamCheckpoint) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	data, err := json.Marshal(checkpoint)
+	if err != nil {
+		return fmt.Errorf("stream checkpoint store encode: %w", err)
+	}
+	if err := writeFileAtomic(storeFilePath(f.Dir, name), data); err != nil {
+		return fmt.Errorf("stream checkpoint store write: %w", err)
+	}
+	return nil
+}
+
+// StreamCheckpointer records the checkpoint of a stream consumer.
+//
+// The consumer records each tweet once it has been handled, and the checkpoint is saved at most once per
+// interval.  Flush should be called when the consumer stops to save the last tweet.
+type StreamCheckpointer struct {
+	store    StreamCheckpointStore
+	name     string
+	interval time.Duration
+	mutex    sync.Mutex
+	current  StreamCheckpoint
+	saved    time.Time
+	dirty    bool
+}
+
+// NewStreamCheckpointer creates a checkpointer of the named stream, the interval defaults to one second
+func NewStreamCheckpointer(store StreamCheckpointStore, name string, interval time.Duration) *StreamCheckpointer {
+	if interval <= 0 {
+		interval = streamCheckpointInterval
+	}
+	return &StreamCheckpointer{
+		store:    store,
+		name:     name,
+		interval: interval,
+	}
+}
+
+// Record will record that the tweet has been handled, and save the checkpoint if the interval has passed.  The
+// tweets older than the checkpoint, like the backfilled tweets, and the compliance events, which are not in tweet
+// order, only update the received at time.
+func (s *StreamCheckpointer) Record(ctx context.Context, msg *TweetMessage) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if msg != nil && msg.Raw != nil && len(msg.Raw.Tweets) > 0 && msg.Raw.Tweets[0] != nil {
+		if id := msg.Raw.Tweets[0].ID; tweetIDLess(s.current.TweetID, id) {
+			s.current.TweetID = id
+		}
+	}
+	s.current.ReceivedAt = time.Now()
+	s.dirty = true
+	if time.Since(s.saved) < s.interval {
+		return nil
+	}
+	return s.save(ctx)
+}
+
+// Flush will save the checkpoint if there are tweets that have been recorded since the last save
+func (s *StreamCheckpointer) Flush(ctx context.Context) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if !s.dirty {
+		return nil
+	}
+	return s.save(ctx)
+}
+
+// Checkpoint returns the last recorded checkpoint
+func (s *StreamCheckpointer) Checkpoint() StreamCheckpoint {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.current
+}
+
+func (s *StreamCheckpointer) save(ctx context.Context) error {
+	checkpoint := s.current
+	if err := s.store.Save(ctx, s.name, &checkpoint); err != nil {
+		return fmt.Errorf("stream checkpoint save: %w", err)
+	}
+	s.saved = time.Now()
+	s.dirty = false
+	return nil
+}
+
+// StreamResumeOpts are the options to resume a stream from its checkpoint
+type StreamResumeOpts struct {
+	// Store has the checkpoint of the stream
+	Store StreamCheckpointStore
+	// Name of the stream checkpoint
+	Name string
+	// Rules are searched with the recent search when the gap is over the backfill window, the filtered stream
+	// defaults to its current rules and the sample stream is not recovered without rules
+	Rules []TweetSearchStreamRule
+	// Search are the fields and expansions of the recent search
+	Search TweetRecentSearchOpts
+}
+
+func (s StreamResumeOpts) validate() error {
+	switch {
+	case s.Store == nil:
+		return fmt.Errorf("a checkpoint store is required: %w", ErrParameter)
+	case len(s.Name) == 0:
+		return fmt.Errorf("a checkpoint name is required: %w", ErrParameter)
+	default:
+		return nil
+	}
+}
+
+// ResumeTweetSearchStream will start the filtered stream from the checkpoint of the stream.
+//
+// When the gap since the checkpoint is within the backfill window, the backfill minutes covering the gap are
+// requested.  When the gap is longer, the rules are searched with the recent search since the checkpoint tweet and
+// the recovered tweets are delivered page by page, newest first for each rule, before the stream is connected with
+// the backfill since the end of the search.  The duplicates of the recovered tweets and the stream are dropped.  A
+// StreamGapError with the lost range is sent to Err for the part of a checkpoint older than the seven days of the
+// recent search, for the rest of the range when a search fails and for a recovery that outlasts the backfill window.
+// A recovered stream has no rate limit, as it is connected after it has started, and a connect error ends it.
+// Without a checkpoint the stream is started as is.
+func (c *Client) ResumeTweetSearchStream(ctx context.Context, opts TweetSearchStreamOpts, resume StreamResumeOpts) (*TweetStream, error) {
+	if err := resume.validate(); err != nil {
+		return nil, fmt.Errorf("resume tweet search stream: %w", err)
+	}
+	rules := func(ctx context.Context) ([]TweetSearchStreamRule, error) {
+		if resume.Rules != nil {
+			return resume.Rules, nil
+		}
+		resp, err := c.TweetSearchStreamRules(ctx, nil)
+		if err != nil {
+			return nil, err
+		}
+		current := make([]TweetSearchStreamRule, 0, len(resp.Rules))
+		for _, rule := range resp.Rules {
+			current = append(current, rule.TweetSearchStreamRule)
+		}
+		return current, nil
+	}
+	stream, err := c.resumeTweetStream(ctx, resume, rules, func(ctx context.Context, backfillMinutes int) (io.ReadCloser, *RateLimit, error) {
+		connectOpts := opts
+		connectOpts.BackfillMinutes = backfillMinutes
+		return c.tweetSearchStreamConnect(ctx, connectOpts)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("resume tweet search stream: %w", err)
+	}
+	return stream, nil
+}
+
+// ResumeTweetSampleStream will start the sample stream from the checkpoint of the stream.
+//
+// The gap is backfilled the same as ResumeTweetSearchStream, but the gap over the backfill window is only
+// recovered with the recent search when the resume rules are set, otherwise it is sent as a StreamGapError.
+func (c *Client) ResumeTweetSampleStream(ctx context.Context, opts TweetSampleStreamOpts, resume StreamResumeOpts) (*TweetStream, error) {
+	if err := resume.validate(); err != nil {
+		return nil, fmt.Errorf("resume tweet sample stream: %w", err)
+	}
+	rules := func(context.Context) ([]TweetSearchStreamRule, error) {
+		return resume.Rules, nil
+	}
+	stream, err := c.resumeTweetStream(ctx, resume, rules, func(ctx context.Context, backfillMinutes int) (io.ReadCloser, *RateLimit, error) {
+		connectOpts := opts
+		connectOpts.BackfillMinutes = backfillMinutes
+		return c.tweetSampleStreamConnect(ctx, connectOpts)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("resume tweet sample stream: %w", err)
+	}
+	return stream, nil
+}
+
+// ManagedResumeTweetSearchStream will resume the filtered stream from the checkpoint of the stream, the same as
+// ResumeTweetSearchStream, as a managed stream that reconnects with the backfill after the resumed connection
+func (c *Client) ManagedResumeTweetSearchStream(ctx context.Context, opts TweetSearchStreamOpts, resume StreamResumeOpts, managed ManagedStreamOpts) (*ManagedTweetStream, error) {
+	resumed := false
+	return startManagedTweetStream(ctx, opts.BackfillMinutes, managed, func(ctx context.Context, backfillMinutes int) (*TweetStream, error) {
+		if !resumed {
+			resumed = true
+			return c.ResumeTweetSearchStream(ctx, opts, resume)
+		}
+		connectOpts := opts
+		connectOpts.BackfillMinutes = backfillMinutes
+		return c.TweetSearchStream(ctx, connectOpts)
+	})
+}
+
+// ManagedResumeTweetSampleStream will resume the sample stream from the checkpoint of the stream, the same as
+// ResumeTweetSampleStream, as a managed stream that reconnects with the backfill after the resumed connection
+func (c *Client) ManagedResumeTweetSampleStream(ctx context.Context, opts TweetSampleStreamOpts, resume StreamResumeOpts, managed ManagedStreamOpts) (*ManagedTweetStream, error) {
+	resumed := false
+	return startManagedTweetStream(ctx, opts.BackfillMinutes, managed, func(ctx context.Context, backfillMinutes int) (*TweetStream, error) {
+		if !resumed {
+			resumed = true
+			return c.ResumeTweetSampleStream(ctx, opts, resume)
+		}
+		connectOpts := opts
+		connectOpts.BackfillMinutes = backfillMinutes
+		return c.TweetSampleStream(ctx, connectOpts)
+	})
+}
+
+type streamConnectFunc func(ctx context.Context, backfillMinutes int) (io.ReadCloser, *RateLimit, error)
+
+func (c *Client) resumeTweetStream(ctx context.Context, resume StreamResumeOpts, rules func(context.Context) ([]TweetSearchStreamRule, error), connect streamConnectFunc) (*TweetStream, error) {
+	checkpoint, err := resume.Store.Load(ctx, resume.Name)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.StreamOpts.validate(); err != nil {
+		return nil, err
+	}
+
+	if checkpoint == nil || checkpoint.ReceivedAt.IsZero() || time.Since(checkpoint.ReceivedAt) <= sampleStreamMaxBackOffMin*time.Minute {
+		body, rl, err := connect(ctx, streamResumeBackfill(checkpoint))
+		if err != nil {
+			return nil, err
+		}
+		stream, err := StartTweetStreamWithOpts(ctx, body, c.StreamOpts)
+		if err != nil {
+			return nil, err
+		}
+		stream.RateLimit = rl
+		return stream, nil
+	}
+
+	searchRules, err := rules(ctx)
+	if err != nil {
+		return nil, err
+	}
+	recovery := &streamRecovery{
+		client:     c,
+		checkpoint: checkpoint,
+		rules:      searchRules,
+		search:     resume.Search,
+		connect:    connect,
+		closer:     &streamCloser{},
+	}
+	return startTweetStream(ctx, c.StreamOpts, recovery.closer, recovery.start)
+}
+
+// streamResumeBackfill returns the backfill minutes covering the gap since the checkpoint, capped at the max backfill
+func streamResumeBackfill(checkpoint *StreamCheckpoint) int {
+	if checkpoint == nil || checkpoint.ReceivedAt.IsZero() {
+		return 0
+	}
+	return streamBackfillMinutes(checkpoint.ReceivedAt)
+}
+
+// streamBackfillMinutes returns the backfill minutes covering the gap since the time, capped at the max backfill
+func streamBackfillMinutes(since time.Time) int {
+	minutes := int(math.Ceil(time.Since(since).Minutes()))
+	switch {
+	case minutes < 1:
+		return 1
+	case minutes > sampleStreamMaxBackOffMin:
+		return sampleStreamMaxBackOffMin
+	default:
+		return minutes
+	}
+}
+
+// streamRecovery will search the rules since the checkpoint, send the recovered tweets and then connect the stream
+// with the backfill since the end of the recovery
+type streamRecovery struct {
+	client     *Client
+	checkpoint *StreamCheckpoint
+	rules      []TweetSearchStreamRule
+	search     TweetRecentSearchOpts
+	connect    streamConnectFunc
+	closer     *streamCloser
+}
+
+func (r *streamRecovery) start(ctx context.Context) streamSource {
+	lines := make(chan []byte)
+	errs := make(chan error)
+	readErr := new(error)
+	go func() {
+		defer close(lines)
+		*readErr = r.run(ctx, lines, errs)
+	}()
+	return streamSource{
+		lines:   lines,
+		readErr: readErr,
+		errs:    errs,
+	}
+}
+
+// run will recover the tweets and then read the stream, the stream tweets that were recovered are dropped until the
+// stream is past the newest recovered tweet
+func (r *streamRecovery) run(ctx context.Context, lines chan<- []byte, errs chan<- error) error {
+	sendGap := func(gap *StreamGapError) bool {
+		select {
+		case errs <- gap:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	// the tweets before recovered have been recovered or sent as a gap
+	recovered := r.checkpoint.ReceivedAt
+	seen := map[string]bool{}
+	newestID := ""
+	if len(r.rules) > 0 {
+		startTime, sinceID := r.checkpoint.ReceivedAt, r.checkpoint.TweetID
+		if windowStart := time.Now().Add(-tweetRecentSearchWindow + streamRecoverWindowSlack); startTime.Before(windowStart) {
+			if !sendGap(&StreamGapError{From: startTime, To: windowStart}) {
+				return nil
+			}
+			startTime, sinceID = windowStart, ""
+		}
+		// the recent search end time has to be in the past
+		endTime := time.Now().Add(-streamRecoverWindowSlack)
+		for _, rule := range r.rules {
+			newest, err := r.recoverRule(ctx, rule, startTime, sinceID, endTime, lines)
+			if ctx.Err() != nil {
+				return nil
+			}
+			// the backfill reaches back less than a minute before the end time, so it can only repeat the newest tweets
+			for _, id := range newest {
+				seen[id] = true
+				if tweetIDLess(newestID, id) {
+					newestID = id
+				}
+			}
+			if err != nil {
+				if !sendGap(&StreamGapError{From: startTime, To: endTime, Err: err}) {
+					return nil
+				}
+				break
+			}
+		}
+		recovered = endTime
+	}
+
+	if lost := time.Now().Add(-sampleStreamMaxBackOffMin * time.Minute); recovered.Before(lost) {
+		if !sendGap(&StreamGapError{From: recovered, To: lost}) {
+			return nil
+		}
+	}
+
+	body, _, err := r.connect(ctx, streamBackfillMinutes(recovered))
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return err
+	}
+	if !r.closer.set(body) {
+		return nil
+	}
+	live, liveErr := readStream(ctx, body)
+	for msg := range live {
+		if len(seen) > 0 {
+			id := streamTweetID(msg)
+			switch {
+			case seen[id]:
+				continue
+			case tweetIDLess(newestID, id):
+				seen = nil
+			default:
+			}
+		}
+		select {
+		case lines <- msg:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+	return *liveErr
+}
+
+// recoverRule will search the rule page by page and send its tweets in the order of the search, newest first, and
+// returns the tweet ids of the first page
+func (r *streamRecovery) recoverRule(ctx context.Context, rule TweetSearchStreamRule, startTime time.Time, sinceID string, endTime time.Time, lines chan<- []byte) ([]string, error) {
+	entity := &TweetSearchStreamRuleEntity{
+		ID:                    TweetPollStreamRuleID(rule),
+		TweetSearchStreamRule: rule,
+	}
+	opts := r.search
+	opts.StartTime = time.Time{}
+	opts.EndTime = endTime
+	opts.UntilID = ""
+	opts.SortOrder = ""
+	opts.SinceID = sinceID
+	opts.NextToken = ""
+	if len(sinceID) == 0 {
+		opts.StartTime = startTime
+	}
+	if opts.MaxResults == 0 {
+		opts.MaxResults = tweetPollStreamMaxResults
+	}
+
+	var newest []string
+	for {
+		resp, err := r.client.TweetRecentSearch(ctx, rule.Value, opts)
+		if err != nil {
+			return newest, err
+		}
+		if resp.Raw != nil {
+			for _, tweet := range resp.Raw.Tweets {
+				if tweet == nil {
+					continue
+				}
+				if len(opts.NextToken) == 0 {
+					newest = append(newest, tweet.ID)
+				}
+				msg, err := json.Marshal(&tweetPollMatch{
+					Tweet:         tweet,
+					Includes:      resp.Raw.Includes,
+					MatchingRules: []*TweetSearchStreamRuleEntity{entity},
+				})
+				if err != nil {
+					return newest, fmt.Errorf("stream recover encode: %w", err)
+				}
+				select {
+				case lines <- msg:
+				case <-ctx.Done():
+					return newest, ctx.Err()
+				}
+			}
+		}
+		if resp.Meta == nil || len(resp.Meta.NextToken) == 0 {
+			return newest, nil
+		}
+		opts.NextToken = resp.Meta.NextToken
+	}
+}
+
+// streamCloser closes the connection of a stream that is connected after the stream has started
+type streamCloser struct {
+	mutex  sync.Mutex
+	closer io.Closer
+	closed bool
+}
+
+// set will set the connection, returns false and closes it if the stream has already been closed
+func (s *streamCloser) set(closer io.Closer) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.closed {
+		closer.Close()
+		return false
+	}
+	s.closer = closer
+	return true
+}
+
+func (s *streamCloser) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.closed = true
+	if s.closer == nil {
+		return nil
+	}
+	return s.closer.Close()
+}
+
+// streamTweetID returns the id of a tweet stream message, or empty if the message is not a tweet
+func streamTweetID(msg []byte) string {
+	if len(msg) == 0 {
+		return ""
+	}
+	reader, err := normalizeStream(msg)
+	if err != nil {
+		return ""
+	}
+	tweet := struct {
+		Data struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}{}
+	if err := json.NewDecoder(reader).Decode(&tweet); err != nil {
+		return ""
+	}
+	return tweet.Data.ID
+}
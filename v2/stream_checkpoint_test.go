@@ -0,0 +1,337 @@
+package twitter
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStreamCheckpointer(t *testing.T) {
+	stores := map[string]StreamCheckpointStore{
+		"memory": NewMemoryStreamCheckpointStore(),
+		"file":   NewFileStreamCheckpointStore(t.TempDir()),
+	}
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			if checkpoint, err := store.Load(ctx, "stream"); err != nil || checkpoint != nil {
+				t.Fatalf("Load() empty = %v, %v", checkpoint, err)
+			}
+
+			checkpointer := NewStreamCheckpointer(store, "stream", time.Hour)
+			for _, id := range []string{"10", "12", "9"} {
+				msg := &TweetMessage{Raw: &TweetRaw{Tweets: []*TweetObj{{ID: id}}}}
+				if err := checkpointer.Record(ctx, msg); err != nil {
+					t.Fatalf("Record() error = %v", err)
+				}
+			}
+			checkpoint, err := store.Load(ctx, "stream")
+			if err != nil || checkpoint == nil || checkpoint.TweetID != "10" {
+				t.Fatalf("Load() after first record = %v, %v", checkpoint, err)
+			}
+
+			if err := checkpointer.Flush(ctx); err != nil {
+				t.Fatalf("Flush() error = %v", err)
+			}
+			checkpoint, err = store.Load(ctx, "stream")
+			if err != nil || checkpoint.TweetID != "12" || time.Since(checkpoint.ReceivedAt) > time.Minute {
+				t.Errorf("Load() after flush = %v, %v", checkpoint, err)
+			}
+			if checkpointer.Checkpoint().TweetID != "12" {
+				t.Errorf("Checkpoint() = %v", checkpointer.Checkpoint())
+			}
+		})
+	}
+}
+
+// mockResumeServer serves the filtered stream, its rules and the recent search
+type mockResumeServer struct {
+	mutex    sync.Mutex
+	backfill []string
+	searches []string
+	stream   string
+	fail     bool
+}
+
+func (m *mockResumeServer) handle(req *http.Request) *http.Response {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	body := ""
+	switch {
+	case strings.HasSuffix(req.URL.Path, string(tweetSearchStreamRulesEndpoint)):
+		body = `{"data":[{"id":"1","value":"golang","tag":"go"}],"meta":{}}`
+	case strings.HasSuffix(req.URL.Path, string(tweetRecentSearchEndpoint)):
+		q := req.URL.Query()
+		if q.Get("since_id") == "" && q.Get("start_time") == "" {
+			panic("the recovery is missing the since id and the start time")
+		}
+		m.searches = append(m.searches, q.Get("query")+"/"+q.Get("since_id"))
+		body = `{"data":[{"id":"102","text":"golang"}],"meta":{"newest_id":"102","result_count":1,"next_token":"page2"}}`
+		if q.Get("next_token") == "page2" {
+			body = `{"data":[{"id":"101","text":"golang"}],"meta":{"newest_id":"101","result_count":1}}`
+			if m.fail {
+				return &http.Response{
+					StatusCode: http.StatusServiceUnavailable,
+					Status:     http.StatusText(http.StatusServiceUnavailable),
+					Body:       io.NopCloser(strings.NewReader(`{"title":"Service Unavailable"}`)),
+					Header:     http.Header{},
+					Request:    req,
+				}
+			}
+		}
+	default:
+		m.backfill = append(m.backfill, req.URL.Query().Get("backfill_minutes"))
+		body = m.stream
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Header:     http.Header{},
+		Request:    req,
+	}
+}
+
+func streamCheckpointReceive(t *testing.T, stream *TweetStream) ([]string, []string, []error) {
+	ids := []string{}
+	tags := []string{}
+	msgs, _, _, errs := tweetStreamReceive(t, stream)
+	for _, msg := range msgs {
+		ids = append(ids, msg.Raw.Tweets[0].ID)
+		for _, rule := range msg.Raw.MatchingRules {
+			tags = append(tags, rule.Tag)
+		}
+	}
+	return ids, tags, errs
+}
+
+func TestClient_ResumeTweetSearchStream(t *testing.T) {
+	tests := []struct {
+		name         string
+		checkpoint   *StreamCheckpoint
+		want         []string
+		wantTags     []string
+		wantBackfill string
+		wantSearches []string
+		wantGap      bool
+		// fail fails the second page of the search
+		fail bool
+	}{
+		{
+			name:         "no checkpoint",
+			want:         []string{"102", "103"},
+			wantTags:     []string{},
+			wantBackfill: "",
+			wantSearches: nil,
+		},
+		{
+			name: "backfill the gap",
+			checkpoint: &StreamCheckpoint{
+				TweetID:    "100",
+				ReceivedAt: time.Now().Add(-150 * time.Second),
+			},
+			want:         []string{"102", "103"},
+			wantTags:     []string{},
+			wantBackfill: "3",
+			wantSearches: nil,
+		},
+		{
+			name: "recover the gap with recent search",
+			checkpoint: &StreamCheckpoint{
+				TweetID:    "100",
+				ReceivedAt: time.Now().Add(-time.Hour),
+			},
+			want:         []string{"102", "101", "103"},
+			wantTags:     []string{"go", "go"},
+			wantBackfill: "2",
+			wantSearches: []string{"golang/100", "golang/100"},
+		},
+		{
+			name: "recover the recent search window",
+			checkpoint: &StreamCheckpoint{
+				TweetID:    "100",
+				ReceivedAt: time.Now().Add(-8 * 24 * time.Hour),
+			},
+			want:         []string{"102", "101", "103"},
+			wantTags:     []string{"go", "go"},
+			wantBackfill: "2",
+			wantSearches: []string{"golang/", "golang/"},
+			wantGap:      true,
+		},
+		{
+			name: "recover part of the gap",
+			checkpoint: &StreamCheckpoint{
+				TweetID:    "100",
+				ReceivedAt: time.Now().Add(-time.Hour),
+			},
+			fail:         true,
+			want:         []string{"102", "103"},
+			wantTags:     []string{"go"},
+			wantBackfill: "2",
+			wantSearches: []string{"golang/100", "golang/100"},
+			wantGap:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := &mockResumeServer{
+				stream: managedStreamTweets("102", "103"),
+				fail:   tt.fail,
+			}
+			c := &Client{
+				Authorizer: &mockAuth{},
+				Host:       "https://www.test.com",
+				Client:     mockHTTPClient(server.handle),
+			}
+			store := NewMemoryStreamCheckpointStore()
+			if tt.checkpoint != nil {
+				store.Save(context.Background(), "golang", tt.checkpoint)
+			}
+
+			stream, err := c.ResumeTweetSearchStream(context.Background(), TweetSearchStreamOpts{}, StreamResumeOpts{
+				Store: store,
+				Name:  "golang",
+			})
+			if err != nil {
+				t.Fatalf("ResumeTweetSearchStream() error = %v", err)
+			}
+			got, tags, errs := streamCheckpointReceive(t, stream)
+
+			gapErr := &StreamGapError{}
+			switch {
+			case tt.wantGap && (len(errs) == 0 || !errors.As(errs[0], &gapErr)):
+				t.Errorf("ResumeTweetSearchStream() errors = %v, want the gap", errs)
+			case tt.wantGap && !gapErr.From.Equal(tt.checkpoint.ReceivedAt):
+				t.Errorf("ResumeTweetSearchStream() gap = %v", gapErr)
+			case tt.fail && gapErr.Err == nil:
+				t.Errorf("ResumeTweetSearchStream() gap = %v, want the search error", gapErr)
+			case !tt.wantGap && len(errs) > 1:
+				t.Errorf("ResumeTweetSearchStream() errors = %v", errs)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ResumeTweetSearchStream() tweets = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(tags, tt.wantTags) {
+				t.Errorf("ResumeTweetSearchStream() tags = %v, want %v", tags, tt.wantTags)
+			}
+			if len(server.backfill) != 1 || server.backfill[0] != tt.wantBackfill {
+				t.Errorf("ResumeTweetSearchStream() backfill = %v, want %v", server.backfill, tt.wantBackfill)
+			}
+			if !reflect.DeepEqual(server.searches, tt.wantSearches) {
+				t.Errorf("ResumeTweetSearchStream() searches = %v, want %v", server.searches, tt.wantSearches)
+			}
+		})
+	}
+}
+
+func TestClient_ResumeTweetSampleStream(t *testing.T) {
+	server := &mockResumeServer{
+		stream: managedStreamTweets("102", "103"),
+	}
+	c := &Client{
+		Authorizer: &mockAuth{},
+		Host:       "https://www.test.com",
+		Client:     mockHTTPClient(server.handle),
+	}
+	store := NewMemoryStreamCheckpointStore()
+	store.Save(context.Background(), "sample", &StreamCheckpoint{
+		TweetID:    "100",
+		ReceivedAt: time.Now().Add(-time.Hour),
+	})
+
+	if _, err := c.ResumeTweetSampleStream(context.Background(), TweetSampleStreamOpts{}, StreamResumeOpts{}); !errors.Is(err, ErrParameter) {
+		t.Errorf("ResumeTweetSampleStream() no store error = %v", err)
+	}
+
+	stream, err := c.ResumeTweetSampleStream(context.Background(), TweetSampleStreamOpts{}, StreamResumeOpts{
+		Store: store,
+		Name:  "sample",
+	})
+	if err != nil {
+		t.Fatalf("ResumeTweetSampleStream() error = %v", err)
+	}
+	got, _, errs := streamCheckpointReceive(t, stream)
+	if !reflect.DeepEqual(got, []string{"102", "103"}) {
+		t.Errorf("ResumeTweetSampleStream() tweets = %v", got)
+	}
+	gapErr := &StreamGapError{}
+	if len(errs) == 0 || !errors.As(errs[0], &gapErr) || time.Since(gapErr.To) < 5*time.Minute {
+		t.Errorf("ResumeTweetSampleStream() errors = %v, want the gap before the backfill", errs)
+	}
+	if len(server.searches) != 0 || !reflect.DeepEqual(server.backfill, []string{"5"}) {
+		t.Errorf("ResumeTweetSampleStream() searches = %v, backfill = %v", server.searches, server.backfill)
+	}
+}
+
+func TestClient_ManagedResumeTweetSearchStream(t *testing.T) {
+	managedStreamTestBackoffs(t)
+
+	server := &mockResumeServer{
+		stream: managedStreamTweets("102", "103"),
+	}
+	c := &Client{
+		Authorizer: &mockAuth{},
+		Host:       "https://www.test.com",
+		Client:     mockHTTPClient(server.handle),
+	}
+	store := NewMemoryStreamCheckpointStore()
+	store.Save(context.Background(), "golang", &StreamCheckpoint{
+		TweetID:    "100",
+		ReceivedAt: time.Now().Add(-8 * 24 * time.Hour),
+	})
+
+	stream, err := c.ManagedResumeTweetSearchStream(context.Background(), TweetSearchStreamOpts{}, StreamResumeOpts{
+		Store: store,
+		Name:  "golang",
+	}, ManagedStreamOpts{})
+	if err != nil {
+		t.Fatalf("ManagedResumeTweetSearchStream() error = %v", err)
+	}
+	defer stream.Close()
+
+	got, errs := managedStreamReceive(t, stream, 3)
+	if !reflect.DeepEqual(got, []string{"102", "101", "103"}) {
+		t.Errorf("ManagedResumeTweetSearchStream() tweets = %v", got)
+	}
+	timer := time.NewTimer(5 * time.Second)
+	defer timer.Stop()
+	gapErr := &StreamGapError{}
+	for len(errs) == 0 || !errors.As(errs[len(errs)-1], &gapErr) {
+		select {
+		case err := <-stream.Err():
+			errs = append(errs, err)
+		case <-timer.C:
+			t.Fatalf("ManagedResumeTweetSearchStream() errors = %v, want the gap", errs)
+		}
+	}
+	for {
+		server.mutex.Lock()
+		reconnected := len(server.backfill) > 1
+		server.mutex.Unlock()
+		if reconnected {
+			break
+		}
+		select {
+		case <-stream.Tweets():
+		case <-time.After(time.Millisecond):
+		case <-timer.C:
+			t.Fatalf("ManagedResumeTweetSearchStream() did not reconnect")
+		}
+	}
+	stream.Close()
+
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	if !reflect.DeepEqual(server.searches, []string{"golang/", "golang/"}) {
+		t.Errorf("ManagedResumeTweetSearchStream() searches = %v", server.searches)
+	}
+	if len(server.backfill) < 2 || server.backfill[0] != "2" || server.backfill[1] != "1" {
+		t.Errorf("ManagedResumeTweetSearchStream() backfill = %v, want the resume and then the reconnects", server.backfill)
+	}
+}
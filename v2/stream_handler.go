@@ -0,0 +1,169 @@
+package twitter
+
+import (
+	"context"
+	"errors"
+)
+
+// StreamHandler handles the messages of a stream that is run with RunStream.
+//
+// The callbacks are called one at a time from the goroutine of RunStream.  When a callback returns nil the stream
+// continues, when it returns an error the stream is closed and the error is returned by RunStream, unless it is
+// ErrStopStream.
+type StreamHandler interface {
+	// OnTweet is called with the dictionary of each tweet of a tweet message
+	OnTweet(ctx context.Context, tweet *TweetDictionary, msg *TweetMessage) error
+	// OnSystem is called with the system messages
+	OnSystem(ctx context.Context, msgs map[SystemMessageType]SystemMessage) error
+	// OnDisconnect is called with the disconnect messages
+	OnDisconnect(ctx context.Context, disconnect *DisconnectionError) error
+	// OnError is called with the stream errors, other than the stall errors
+	OnError(ctx context.Context, err error) error
+	// OnStall is called with the stall errors, which wrap ErrStreamStall
+	OnStall(ctx context.Context, err error) error
+}
+
+// StreamHandlerFuncs is a stream handler from functions, the callbacks that are nil continue the stream.  It is also
+// a ComplianceHandler.
+type StreamHandlerFuncs struct {
+	Tweet      func(ctx context.Context, tweet *TweetDictionary, msg *TweetMessage) error
+	System     func(ctx context.Context, msgs map[SystemMessageType]SystemMessage) error
+	Disconnect func(ctx context.Context, disconnect *DisconnectionError) error
+	Error      func(ctx context.Context, err error) error
+	Stall      func(ctx context.Context, err error) error
+	Compliance func(ctx context.Context, event *ComplianceEvent) error
+}
+
+// OnTweet will call the tweet function
+func (f StreamHandlerFuncs) OnTweet(ctx context.Context, tweet *TweetDictionary, msg *TweetMessage) error {
+	if f.Tweet == nil {
+		return nil
+	}
+	return f.Tweet(ctx, tweet, msg)
+}
+
+// OnSystem will call the system function
+func (f StreamHandlerFuncs) OnSystem(ctx context.Context, msgs map[SystemMessageType]SystemMessage) error {
+	if f.System == nil {
+		return nil
+	}
+	return f.System(ctx, msgs)
+}
+
+// OnDisconnect will call the disconnect function
+func (f StreamHandlerFuncs) OnDisconnect(ctx context.Context, disconnect *DisconnectionError) error {
+	if f.Disconnect == nil {
+		return nil
+	}
+	return f.Disconnect(ctx, disconnect)
+}
+
+// OnError will call the error function
+func (f StreamHandlerFuncs) OnError(ctx context.Context, err error) error {
+	if f.Error == nil {
+		return nil
+	}
+	return f.Error(ctx, err)
+}
+
+// OnStall will call the stall function
+func (f StreamHandlerFuncs) OnStall(ctx context.Context, err error) error {
+	if f.Stall == nil {
+		return nil
+	}
+	return f.Stall(ctx, err)
+}
+
+// OnCompliance will call the compliance function
+func (f StreamHandlerFuncs) OnCompliance(ctx context.Context, event *ComplianceEvent) error {
+	if f.Compliance == nil {
+		return nil
+	}
+	return f.Compliance(ctx, event)
+}
+
+// RunStream will deliver the messages of the stream to the handler until the stream ends, the context is done or
+// a callback returns an error.
+//
+// The stream is always closed when RunStream returns, which closes the connection.  It returns nil when the stream
+// ends or a callback returns ErrStopStream, the context error when the context is done and otherwise the error
+// of the callback.
+func RunStream(ctx context.Context, stream TweetStreamSource, handler StreamHandler) error {
+	defer stream.Close()
+
+	var err error
+	// a message that was received as the context is done is not dispatched
+	handled := func(callback func() error) bool {
+		if ctx.Err() != nil {
+			return false
+		}
+		err = callback()
+		return err == nil
+	}
+	streamReceiver{
+		tweet: func(msg *TweetMessage) bool {
+			return handled(func() error { return runStreamTweet(ctx, handler, msg) })
+		},
+		system: func(msgs map[SystemMessageType]SystemMessage) bool {
+			return handled(func() error { return handler.OnSystem(ctx, msgs) })
+		},
+		disconnect: func(disconnect *DisconnectionError) bool {
+			return handled(func() error { return handler.OnDisconnect(ctx, disconnect) })
+		},
+		err: func(streamErr error) bool {
+			if errors.Is(streamErr, ErrStreamStall) {
+				return handled(func() error { return handler.OnStall(ctx, streamErr) })
+			}
+			return handled(func() error { return handler.OnError(ctx, streamErr) })
+		},
+	}.receive(stream, ctx.Done())
+
+	switch {
+	case errors.Is(err, ErrStopStream):
+		return nil
+	case err != nil:
+		return err
+	default:
+		return ctx.Err()
+	}
+}
+
+// runStreamTweet will call the handler with each tweet of the message in order, the nil tweets are skipped, or
+// with the compliance event of the message when the handler is a ComplianceHandler
+func runStreamTweet(ctx context.Context, handler StreamHandler, msg *TweetMessage) error {
+	switch {
+	case msg == nil:
+		return nil
+	case msg.Compliance != nil:
+		if compliance, ok := handler.(ComplianceHandler); ok {
+			return compliance.OnCompliance(ctx, msg.Compliance)
+		}
+		return nil
+	case msg.Raw == nil:
+		return nil
+	default:
+	}
+	dictionaries := msg.Raw.TweetDictionaries()
+	for _, tweet := range msg.Raw.Tweets {
+		if tweet == nil {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := handler.OnTweet(ctx, dictionaries[tweet.ID], msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Run will deliver the messages of the stream to the handler, see RunStream
+func (ts *TweetStream) Run(ctx context.Context, handler StreamHandler) error {
+	return RunStream(ctx, ts, handler)
+}
+
+// Run will deliver the messages of the stream to the handler, see RunStream
+func (m *ManagedTweetStream) Run(ctx context.Context, handler StreamHandler) error {
+	return RunStream(ctx, m, handler)
+}
@@ -0,0 +1,160 @@
+package twitter
+
+import (
+	"context"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunStream(t *testing.T) {
+	body := `{"data":{"id":"1","text":"world"},"includes":{"users":[{"id":"10","name":"gopher"}]}}` + "\r\n"
+	body += `{"error":{"message":"Forced Disconnect","sent":"2017-01-11T18:12:52+00:00"}}` + "\r\n"
+	body += "\r\n"
+	body += `{"data":{"id":"2","text":"hello"}}` + "\r\n"
+	body += `{"warn":{"message":"Rules changed","sent":"2017-01-11T18:12:53+00:00"}}` + "\r\n"
+	body += `{"errors":[{"title":"operational-disconnect","disconnect_type":"UpstreamOperationalDisconnect","detail":"This stream has been disconnected upstream for operational reasons.","type":"https://api.twitter.com/2/problems/operational-disconnect"}]}` + "\r\n"
+
+	stream, err := StartTweetStreamWithOpts(context.Background(), io.NopCloser(strings.NewReader(body)), StreamOpts{})
+	if err != nil {
+		t.Fatalf("StartTweetStreamWithOpts() error = %v", err)
+	}
+	tweets := []string{}
+	system := []string{}
+	disconnects := []string{}
+	err = stream.Run(context.Background(), StreamHandlerFuncs{
+		Tweet: func(ctx context.Context, tweet *TweetDictionary, msg *TweetMessage) error {
+			tweets = append(tweets, tweet.Tweet.Text)
+			return nil
+		},
+		System: func(ctx context.Context, msgs map[SystemMessageType]SystemMessage) error {
+			for _, msg := range msgs {
+				system = append(system, msg.Message)
+			}
+			return nil
+		},
+		Disconnect: func(ctx context.Context, disconnect *DisconnectionError) error {
+			disconnects = append(disconnects, disconnect.Disconnections[0].DisconnectType)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	// the messages are in order within each type, the types are delivered from their own channels
+	if want := []string{"world", "hello"}; !reflect.DeepEqual(tweets, want) {
+		t.Errorf("Run() tweets = %v, want %v", tweets, want)
+	}
+	if want := []string{"Forced Disconnect", "Rules changed"}; !reflect.DeepEqual(system, want) {
+		t.Errorf("Run() system = %v, want %v", system, want)
+	}
+	if want := []string{"UpstreamOperationalDisconnect"}; !reflect.DeepEqual(disconnects, want) {
+		t.Errorf("Run() disconnects = %v, want %v", disconnects, want)
+	}
+}
+
+func TestRunStream_Stop(t *testing.T) {
+	errHandler := errors.New("handler error")
+	tests := []struct {
+		name    string
+		handler func(cancel context.CancelFunc) StreamHandler
+		wantErr error
+	}{
+		{
+			name: "handler error",
+			handler: func(cancel context.CancelFunc) StreamHandler {
+				return StreamHandlerFuncs{
+					Tweet: func(ctx context.Context, tweet *TweetDictionary, msg *TweetMessage) error {
+						return errHandler
+					},
+				}
+			},
+			wantErr: errHandler,
+		},
+		{
+			name: "stop",
+			handler: func(cancel context.CancelFunc) StreamHandler {
+				return StreamHandlerFuncs{
+					Tweet: func(ctx context.Context, tweet *TweetDictionary, msg *TweetMessage) error {
+						return ErrStopStream
+					},
+				}
+			},
+			wantErr: nil,
+		},
+		{
+			name: "context cancel",
+			handler: func(cancel context.CancelFunc) StreamHandler {
+				return StreamHandlerFuncs{
+					Tweet: func(ctx context.Context, tweet *TweetDictionary, msg *TweetMessage) error {
+						cancel()
+						return nil
+					},
+				}
+			},
+			wantErr: context.Canceled,
+		},
+		{
+			name: "stall",
+			handler: func(cancel context.CancelFunc) StreamHandler {
+				return StreamHandlerFuncs{
+					Stall: func(ctx context.Context, err error) error {
+						return err
+					},
+				}
+			},
+			wantErr: ErrStreamStall,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader, writer := io.Pipe()
+			stream, err := StartTweetStreamWithOpts(context.Background(), reader, StreamOpts{KeepAlive: 100 * time.Millisecond})
+			if err != nil {
+				t.Fatalf("StartTweetStreamWithOpts() error = %v", err)
+			}
+			go writer.Write([]byte(`{"data":{"id":"1","text":"hello"}}` + "\r\n"))
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if err := stream.Run(ctx, tt.handler(cancel)); !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Errorf("Run() error = %v, want %v", err, tt.wantErr)
+			}
+			if _, err := writer.Write([]byte("\r\n")); !errors.Is(err, io.ErrClosedPipe) {
+				t.Errorf("Run() did not close the body, write error = %v", err)
+			}
+		})
+	}
+}
+
+func TestRunStream_Cancel(t *testing.T) {
+	source := newMockTweetStreamSource()
+	go func() {
+		source.tweets <- &TweetMessage{
+			Raw: &TweetRaw{
+				Tweets: []*TweetObj{nil, {ID: "1"}, {ID: "2"}},
+			},
+		}
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tweets := []string{}
+	err := RunStream(ctx, source, StreamHandlerFuncs{
+		Tweet: func(ctx context.Context, tweet *TweetDictionary, msg *TweetMessage) error {
+			tweets = append(tweets, tweet.Tweet.ID)
+			cancel()
+			return nil
+		},
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("RunStream() error = %v, want %v", err, context.Canceled)
+	}
+	// the nil tweet is skipped and the tweets after the cancel are not dispatched
+	if want := []string{"1"}; !reflect.DeepEqual(tweets, want) {
+		t.Errorf("RunStream() tweets = %v, want %v", tweets, want)
+	}
+}
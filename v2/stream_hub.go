@@ -0,0 +1,422 @@
+package twitter
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// TweetStreamSource is a stream of tweet messages, like the TweetStream and the ManagedTweetStream.  The stream
+// has ended when its channels are closed or its Done channel is closed.
+type TweetStreamSource interface {
+	Tweets() <-chan *TweetMessage
+	SystemMessages() <-chan map[SystemMessageType]SystemMessage
+	DisconnectionError() <-chan *DisconnectionError
+	Err() <-chan error
+	Done() <-chan struct{}
+	Close()
+}
+
+// streamReceiver has the functions that receive the messages of a stream source, the channels of the functions
+// that are nil are not read and a function that returns false stops the receiving
+type streamReceiver struct {
+	tweet      func(msg *TweetMessage) bool
+	system     func(msgs map[SystemMessageType]SystemMessage) bool
+	disconnect func(disconnect *DisconnectionError) bool
+	err        func(err error) bool
+}
+
+// streamReceiverChannels are the channels of a stream source that are still being read
+type streamReceiverChannels struct {
+	tweets        <-chan *TweetMessage
+	system        <-chan map[SystemMessageType]SystemMessage
+	disconnection <-chan *DisconnectionError
+	errs          <-chan error
+}
+
+func (c streamReceiverChannels) open() bool {
+	return c.tweets != nil || c.system != nil || c.disconnection != nil || c.errs != nil
+}
+
+// receive will deliver the messages of the source until its channels are closed or it is done, the messages that
+// are buffered when the source is done are still delivered.  It returns false if a function has stopped the
+// receiving or the stop channel was closed.
+func (r streamReceiver) receive(source TweetStreamSource, stop <-chan struct{}) bool {
+	channels := streamReceiverChannels{}
+	if r.tweet != nil {
+		channels.tweets = source.Tweets()
+	}
+	if r.system != nil {
+		channels.system = source.SystemMessages()
+	}
+	if r.disconnect != nil {
+		channels.disconnection = source.DisconnectionError()
+	}
+	if r.err != nil {
+		channels.errs = source.Err()
+	}
+	done := source.Done()
+	for channels.open() {
+		select {
+		case <-stop:
+			return false
+		case <-done:
+			return r.drain(channels)
+		case msg, ok := <-channels.tweets:
+			if !ok {
+				channels.tweets = nil
+			} else if !r.tweet(msg) {
+				return false
+			}
+		case msgs, ok := <-channels.system:
+			if !ok {
+				channels.system = nil
+			} else if !r.system(msgs) {
+				return false
+			}
+		case disconnect, ok := <-channels.disconnection:
+			if !ok {
+				channels.disconnection = nil
+			} else if !r.disconnect(disconnect) {
+				return false
+			}
+		case err, ok := <-channels.errs:
+			if !ok {
+				channels.errs = nil
+			} else if !r.err(err) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// drain will deliver the messages that are buffered by a source that is done, nothing more is sent to them
+func (r streamReceiver) drain(channels streamReceiverChannels) bool {
+	for len(channels.tweets) > 0 {
+		if !r.tweet(<-channels.tweets) {
+			return false
+		}
+	}
+	for len(channels.system) > 0 {
+		if !r.system(<-channels.system) {
+			return false
+		}
+	}
+	for len(channels.disconnection) > 0 {
+		if !r.disconnect(<-channels.disconnection) {
+			return false
+		}
+	}
+	for len(channels.errs) > 0 {
+		if !r.err(<-channels.errs) {
+			return false
+		}
+	}
+	return true
+}
+
+// StreamFilter returns if the tweet message should be delivered to a subscription
+type StreamFilter func(msg *TweetMessage) bool
+
+// MatchRuleTags will match the tweets of the filtered stream rules with any of the tags
+func MatchRuleTags(tags ...string) StreamFilter {
+	wanted := map[string]bool{}
+	for _, tag := range tags {
+		wanted[tag] = true
+	}
+	return func(msg *TweetMessage) bool {
+		if msg == nil || msg.Raw == nil {
+			return false
+		}
+		for _, tag := range msg.Raw.GetMatchingRuleTags() {
+			if wanted[tag] {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// MatchRuleIDs will match the tweets of the filtered stream rules with any of the ids
+func MatchRuleIDs(ids ...TweetSearchStreamRuleID) StreamFilter {
+	wanted := map[string]bool{}
+	for _, id := range ids {
+		wanted[string(id)] = true
+	}
+	return func(msg *TweetMessage) bool {
+		if msg == nil || msg.Raw == nil {
+			return false
+		}
+		for _, id := range msg.Raw.GetMatchingRuleIDs() {
+			if wanted[id] {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// StreamSubscriptionOpts are the options of how a subscription buffers the tweets for its consumer
+type StreamSubscriptionOpts struct {
+	// BufferSize is the capacity of the tweets channel, defaults to 10
+	BufferSize int
+	// Overflow is the policy when the channel is full, defaults to OverflowDropNewest.  A subscription that
+	// blocks will block the delivery to all of the subscriptions.
+	Overflow StreamOverflowPolicy
+	// SpillDir is the directory of the spill file, defaults to the temporary directory
+	SpillDir string
+}
+
+func (s StreamSubscriptionOpts) streamOpts() StreamOpts {
+	return StreamOpts{
+		BufferSize: s.BufferSize,
+		Overflow:   s.Overflow,
+		SpillDir:   s.SpillDir,
+	}
+}
+
+// StreamSubscriptionStats are the delivery metrics of a subscription
+type StreamSubscriptionStats struct {
+	// Matched is the number of tweets that matched the filter
+	Matched uint64
+	// Delivered is the number of matched tweets that have been put in the tweets channel, the tweets that are
+	// still in the spill file or were evicted by OverflowDropOldest are not delivered
+	Delivered uint64
+	// Dropped is the number of matched tweets dropped by the overflow policy
+	Dropped uint64
+}
+
+// StreamHub will share one tweet stream between many subscriptions.
+//
+// Each subscription has a filter, like the rule tags or ids of the filtered stream, and its own tweets channel
+// and overflow policy.  The tweets are delivered to the subscriptions in the order they subscribed.  The system
+// messages, disconnections and errors are not fanned out, the hub reads them from the stream and forwards them to
+// its own channels, dropping them when the channels are full, so a stream is never blocked by the channels that
+// are not read.  When the stream ends or the hub is closed, the channels of the hub and all of the subscriptions
+// are closed.
+type StreamHub struct {
+	stream        TweetStreamSource
+	subs          []*StreamSubscription
+	system        chan map[SystemMessageType]SystemMessage
+	disconnection chan *DisconnectionError
+	err           chan error
+	dropped       StreamDrops
+	closed        bool
+	done          chan struct{}
+	mutex         sync.RWMutex
+}
+
+// NewStreamHub creates a hub that will read the messages of the stream
+func NewStreamHub(stream TweetStreamSource) *StreamHub {
+	h := &StreamHub{
+		stream:        stream,
+		subs:          []*StreamSubscription{},
+		system:        make(chan map[SystemMessageType]SystemMessage, streamBufferSize),
+		disconnection: make(chan *DisconnectionError, streamBufferSize),
+		err:           make(chan error, streamBufferSize),
+		done:          make(chan struct{}),
+	}
+	go h.run()
+	return h
+}
+
+func (h *StreamHub) run() {
+	defer close(h.done)
+	defer close(h.err)
+	defer close(h.disconnection)
+	defer close(h.system)
+
+	streamReceiver{
+		tweet: func(msg *TweetMessage) bool {
+			h.mutex.RLock()
+			defer h.mutex.RUnlock()
+			for _, sub := range h.subs {
+				if sub.filter != nil && !sub.filter(msg) {
+					continue
+				}
+				atomic.AddUint64(&sub.matched, 1)
+				sub.outlet.send(msg, sub.stop)
+			}
+			return true
+		},
+		system: func(msgs map[SystemMessageType]SystemMessage) bool {
+			select {
+			case h.system <- msgs:
+			default:
+				h.drop(func(dropped *StreamDrops) { dropped.SystemMessages++ })
+			}
+			return true
+		},
+		disconnect: func(disconnect *DisconnectionError) bool {
+			select {
+			case h.disconnection <- disconnect:
+			default:
+				h.drop(func(dropped *StreamDrops) { dropped.Disconnections++ })
+			}
+			return true
+		},
+		err: func(err error) bool {
+			select {
+			case h.err <- err:
+			default:
+				h.drop(func(dropped *StreamDrops) { dropped.Errors++ })
+			}
+			return true
+		},
+	}.receive(h.stream, nil)
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.closed = true
+	for _, sub := range h.subs {
+		sub.finish()
+	}
+	h.subs = nil
+}
+
+// drop will count a message that did not fit in the hub channels
+func (h *StreamHub) drop(count func(dropped *StreamDrops)) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	count(&h.dropped)
+}
+
+// Dropped returns the number of system messages, disconnections and errors dropped because the hub channels were
+// full, the tweets dropped by the subscriptions are in their stats
+func (h *StreamHub) Dropped() StreamDrops {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	return h.dropped
+}
+
+// Subscribe will add a subscription with the filter, a nil filter will match all of the tweets
+func (h *StreamHub) Subscribe(filter StreamFilter, opts StreamSubscriptionOpts) (*StreamSubscription, error) {
+	streamOpts := opts.streamOpts()
+	if err := streamOpts.validate(); err != nil {
+		return nil, fmt.Errorf("stream hub subscribe: %w", err)
+	}
+
+	sub := &StreamSubscription{
+		hub:    h,
+		filter: filter,
+		tweets: make(chan *TweetMessage, streamOpts.bufferSize()),
+		stop:   make(chan struct{}),
+	}
+	sub.outlet = newTweetMessageOutlet(sub.tweets, streamOpts.policy())
+	if sub.outlet.policy == OverflowSpill {
+		spill, err := newTweetMessageSpill(opts.SpillDir, "subscription")
+		if err != nil {
+			return nil, fmt.Errorf("stream hub subscribe: %w", err)
+		}
+		sub.outlet.spill = spill
+	}
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if h.closed {
+		if sub.outlet.spill != nil {
+			sub.outlet.spill.stop()
+		}
+		return nil, fmt.Errorf("stream hub subscribe: %w", ErrStreamClosed)
+	}
+	if spill := sub.outlet.spill; spill != nil {
+		sub.pump.Add(1)
+		go func() {
+			defer sub.pump.Done()
+			spill.pump(sub.outlet, sub.stop)
+		}()
+	}
+	h.subs = append(h.subs, sub)
+	return sub, nil
+}
+
+// remove will remove the subscription from the hub and close it
+func (h *StreamHub) remove(sub *StreamSubscription) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	for i, s := range h.subs {
+		if s == sub {
+			h.subs = append(h.subs[:i], h.subs[i+1:]...)
+			sub.finish()
+			return
+		}
+	}
+}
+
+// Subscriptions returns the number of subscriptions
+func (h *StreamHub) Subscriptions() int {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	return len(h.subs)
+}
+
+// SystemMessages will return the channel of the stream system messages
+func (h *StreamHub) SystemMessages() <-chan map[SystemMessageType]SystemMessage {
+	return h.system
+}
+
+// DisconnectionError will return the channel of the stream disconnect error messages
+func (h *StreamHub) DisconnectionError() <-chan *DisconnectionError {
+	return h.disconnection
+}
+
+// Err will return the channel of the stream errors
+func (h *StreamHub) Err() <-chan error {
+	return h.err
+}
+
+// Done will return a channel that is closed when the stream has ended and the subscriptions are closed
+func (h *StreamHub) Done() <-chan struct{} {
+	return h.done
+}
+
+// Close will close the stream and return once all of the subscriptions are closed
+func (h *StreamHub) Close() {
+	h.stream.Close()
+	<-h.done
+}
+
+// StreamSubscription is a subscription to the tweets of a stream hub
+type StreamSubscription struct {
+	hub      *StreamHub
+	filter   StreamFilter
+	tweets   chan *TweetMessage
+	outlet   *streamOutlet
+	matched  uint64
+	stop     chan struct{}
+	stopOnce sync.Once
+	pump     sync.WaitGroup
+}
+
+// Tweets will return the channel to receive the tweets of the subscription, it is closed when the subscription
+// is removed or the stream ends
+func (s *StreamSubscription) Tweets() <-chan *TweetMessage {
+	return s.tweets
+}
+
+// Stats returns the delivery metrics of the subscription
+func (s *StreamSubscription) Stats() StreamSubscriptionStats {
+	return StreamSubscriptionStats{
+		Matched:   atomic.LoadUint64(&s.matched),
+		Delivered: s.outlet.deliveries(),
+		Dropped:   s.outlet.drops(),
+	}
+}
+
+// Unsubscribe will remove the subscription from the hub and close its channel, it is safe to call more than once
+func (s *StreamSubscription) Unsubscribe() {
+	// stopping first will release the hub if it is blocked on the subscription
+	s.stopOnce.Do(func() { close(s.stop) })
+	s.hub.remove(s)
+}
+
+// finish will stop the subscription and close its channel, the hub lock is held so there are no more sends
+func (s *StreamSubscription) finish() {
+	s.stopOnce.Do(func() { close(s.stop) })
+	if s.outlet.spill != nil {
+		atomic.AddUint64(&s.outlet.dropped, uint64(s.outlet.spill.stop()))
+	}
+	s.pump.Wait()
+	close(s.tweets)
+}
@@ -0,0 +1,278 @@
+package twitter
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// mockTweetStreamSource is a stream source that is fed by the test
+type mockTweetStreamSource struct {
+	tweets    chan *TweetMessage
+	system    chan map[SystemMessageType]SystemMessage
+	disconnet chan *DisconnectionError
+	err       chan error
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func newMockTweetStreamSource() *mockTweetStreamSource {
+	return &mockTweetStreamSource{
+		tweets:    make(chan *TweetMessage),
+		system:    make(chan map[SystemMessageType]SystemMessage),
+		disconnet: make(chan *DisconnectionError),
+		err:       make(chan error),
+		done:      make(chan struct{}),
+	}
+}
+
+func (m *mockTweetStreamSource) Tweets() <-chan *TweetMessage { return m.tweets }
+func (m *mockTweetStreamSource) SystemMessages() <-chan map[SystemMessageType]SystemMessage {
+	return m.system
+}
+func (m *mockTweetStreamSource) DisconnectionError() <-chan *DisconnectionError { return m.disconnet }
+func (m *mockTweetStreamSource) Err() <-chan error                              { return m.err }
+func (m *mockTweetStreamSource) Done() <-chan struct{}                          { return m.done }
+func (m *mockTweetStreamSource) Close() {
+	m.closeOnce.Do(func() { close(m.done) })
+}
+
+func streamHubTweet(id string, rules ...string) *TweetMessage {
+	raw := &TweetRaw{Tweets: []*TweetObj{{ID: id}}}
+	for _, rule := range rules {
+		raw.MatchingRules = append(raw.MatchingRules, &TweetSearchStreamRuleEntity{
+			ID:                    TweetSearchStreamRuleID(rule + "-id"),
+			TweetSearchStreamRule: TweetSearchStreamRule{Tag: rule},
+		})
+	}
+	return &TweetMessage{Raw: raw}
+}
+
+func streamHubReceive(sub *StreamSubscription) []string {
+	ids := []string{}
+	for msg := range sub.Tweets() {
+		ids = append(ids, msg.Raw.Tweets[0].ID)
+	}
+	return ids
+}
+
+// tweetStreamReceive will receive the messages of the stream until it has ended
+func tweetStreamReceive(t *testing.T, stream TweetStreamSource) ([]*TweetMessage, []map[SystemMessageType]SystemMessage, []*DisconnectionError, []error) {
+	tweets := []*TweetMessage{}
+	system := []map[SystemMessageType]SystemMessage{}
+	disconnects := []*DisconnectionError{}
+	errs := []error{}
+	timeout := make(chan struct{})
+	timer := time.AfterFunc(5*time.Second, func() { close(timeout) })
+	defer timer.Stop()
+	ended := streamReceiver{
+		tweet: func(msg *TweetMessage) bool {
+			tweets = append(tweets, msg)
+			return true
+		},
+		system: func(msgs map[SystemMessageType]SystemMessage) bool {
+			system = append(system, msgs)
+			return true
+		},
+		disconnect: func(disconnect *DisconnectionError) bool {
+			disconnects = append(disconnects, disconnect)
+			return true
+		},
+		err: func(err error) bool {
+			errs = append(errs, err)
+			return true
+		},
+	}.receive(stream, timeout)
+	if !ended {
+		t.Errorf("stream receive timeout with %d tweets", len(tweets))
+	}
+	return tweets, system, disconnects, errs
+}
+
+func TestStreamHub_Routing(t *testing.T) {
+	source := newMockTweetStreamSource()
+	hub := NewStreamHub(source)
+
+	opts := StreamSubscriptionOpts{BufferSize: 10}
+	golang, err := hub.Subscribe(MatchRuleTags("golang"), opts)
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	rust, err := hub.Subscribe(MatchRuleIDs("rust-id"), opts)
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	all, err := hub.Subscribe(nil, opts)
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	even, err := hub.Subscribe(func(msg *TweetMessage) bool {
+		return msg.Raw.Tweets[0].ID == "2"
+	}, opts)
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	if hub.Subscriptions() != 4 {
+		t.Errorf("Subscriptions() = %d, want 4", hub.Subscriptions())
+	}
+
+	source.tweets <- streamHubTweet("1", "golang")
+	source.tweets <- streamHubTweet("2", "rust")
+	source.tweets <- streamHubTweet("3", "golang", "rust")
+	source.tweets <- streamHubTweet("4")
+	hub.Close()
+
+	tests := []struct {
+		name string
+		sub  *StreamSubscription
+		want []string
+	}{
+		{name: "tags", sub: golang, want: []string{"1", "3"}},
+		{name: "ids", sub: rust, want: []string{"2", "3"}},
+		{name: "all", sub: all, want: []string{"1", "2", "3", "4"}},
+		{name: "predicate", sub: even, want: []string{"2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := streamHubReceive(tt.sub); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Tweets() = %v, want %v", got, tt.want)
+			}
+			stats := tt.sub.Stats()
+			if stats.Matched != uint64(len(tt.want)) || stats.Delivered != stats.Matched || stats.Dropped != 0 {
+				t.Errorf("Stats() = %+v", stats)
+			}
+		})
+	}
+
+	if _, err := hub.Subscribe(nil, opts); !errors.Is(err, ErrStreamClosed) {
+		t.Errorf("Subscribe() after close error = %v", err)
+	}
+}
+
+func TestStreamHub_Overflow(t *testing.T) {
+	source := newMockTweetStreamSource()
+	hub := NewStreamHub(source)
+	defer hub.Close()
+
+	dropping, err := hub.Subscribe(nil, StreamSubscriptionOpts{BufferSize: 1})
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	spilling, err := hub.Subscribe(nil, StreamSubscriptionOpts{BufferSize: 1, Overflow: OverflowSpill, SpillDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	blocking, err := hub.Subscribe(nil, StreamSubscriptionOpts{BufferSize: 1, Overflow: OverflowBlock})
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	if _, err := hub.Subscribe(nil, StreamSubscriptionOpts{BufferSize: -1}); !errors.Is(err, ErrParameter) {
+		t.Errorf("Subscribe() buffer size error = %v", err)
+	}
+
+	source.tweets <- streamHubTweet("1")
+	source.tweets <- streamHubTweet("2")
+
+	// the hub is blocked on the second tweet until the blocking subscription is removed
+	blocking.Unsubscribe()
+	blocking.Unsubscribe()
+	if _, ok := <-blocking.Tweets(); !ok {
+		t.Errorf("Unsubscribe() closed before the buffered tweet")
+	}
+	if _, ok := <-blocking.Tweets(); ok {
+		t.Errorf("Unsubscribe() did not close the tweets")
+	}
+	source.tweets <- streamHubTweet("3")
+
+	got := []string{}
+	timeout := time.After(5 * time.Second)
+	for len(got) < 3 {
+		select {
+		case msg := <-spilling.Tweets():
+			got = append(got, msg.Raw.Tweets[0].ID)
+		case <-timeout:
+			t.Fatalf("Tweets() spill timeout = %v", got)
+		}
+	}
+	if !reflect.DeepEqual(got, []string{"1", "2", "3"}) {
+		t.Errorf("Tweets() spill = %v", got)
+	}
+	// the tweets are delivered in the order of the subscriptions, so the dropping subscription has them all
+	if stats := dropping.Stats(); stats.Matched != 3 || stats.Dropped != 2 || stats.Delivered != 1 {
+		t.Errorf("Stats() drop newest = %+v", stats)
+	}
+	if hub.Subscriptions() != 2 {
+		t.Errorf("Subscriptions() = %d, want 2", hub.Subscriptions())
+	}
+}
+
+func TestStreamHub_SpillDelivered(t *testing.T) {
+	source := newMockTweetStreamSource()
+	hub := NewStreamHub(source)
+	defer hub.Close()
+
+	spilling, err := hub.Subscribe(nil, StreamSubscriptionOpts{BufferSize: 1, Overflow: OverflowSpill, SpillDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	for _, id := range []string{"1", "2", "3"} {
+		source.tweets <- streamHubTweet(id)
+	}
+	// the hub has sent the third tweet once it has received the next message
+	source.system <- map[SystemMessageType]SystemMessage{}
+
+	if stats := spilling.Stats(); stats.Matched != 3 || stats.Delivered != 1 || stats.Dropped != 0 {
+		t.Errorf("Stats() spilled = %+v", stats)
+	}
+	for i := 0; i < 3; i++ {
+		select {
+		case <-spilling.Tweets():
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Tweets() spill timeout")
+		}
+	}
+	if stats := spilling.Stats(); stats.Matched != 3 || stats.Delivered != 3 || stats.Dropped != 0 {
+		t.Errorf("Stats() pumped = %+v", stats)
+	}
+}
+
+func TestStreamHub_Channels(t *testing.T) {
+	source := newMockTweetStreamSource()
+	hub := NewStreamHub(source)
+
+	sub, err := hub.Subscribe(nil, StreamSubscriptionOpts{})
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	// the errors are sent to the hub without anyone reading them, the hub drops the errors over its buffer
+	for i := 0; i < streamBufferSize+2; i++ {
+		source.err <- ErrStreamStall
+	}
+	source.system <- map[SystemMessageType]SystemMessage{InfoMessageType: {Message: "info"}}
+	source.disconnet <- &DisconnectionError{}
+	source.tweets <- streamHubTweet("1")
+	source.Close()
+
+	if got := streamHubReceive(sub); !reflect.DeepEqual(got, []string{"1"}) {
+		t.Errorf("Tweets() = %v", got)
+	}
+	errs := 0
+	for range hub.Err() {
+		errs++
+	}
+	if errs != streamBufferSize {
+		t.Errorf("Err() = %d errors, want %d", errs, streamBufferSize)
+	}
+	if msgs := <-hub.SystemMessages(); msgs[InfoMessageType].Message != "info" {
+		t.Errorf("SystemMessages() = %v", msgs)
+	}
+	if disconnect := <-hub.DisconnectionError(); disconnect == nil {
+		t.Errorf("DisconnectionError() = nil")
+	}
+	if dropped := hub.Dropped(); dropped.Errors != 2 || dropped.Total() != 2 {
+		t.Errorf("Dropped() = %+v", dropped)
+	}
+	<-hub.Done()
+}
@@ -0,0 +1,290 @@
+package twitter
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// StreamOverflowPolicy is how the stream handles a message when the consumer has fallen behind and the channel is full
+type StreamOverflowPolicy string
+
+const (
+	// OverflowDropNewest will drop the message that does not fit, this is the default
+	OverflowDropNewest StreamOverflowPolicy = "drop_newest"
+	// OverflowDropOldest will drop the oldest buffered message to make room
+	OverflowDropOldest StreamOverflowPolicy = "drop_oldest"
+	// OverflowBlock will stop reading the stream until the consumer catches up, which pushes back on the connection
+	OverflowBlock StreamOverflowPolicy = "block"
+	// OverflowSpill will write the messages that do not fit to a temporary file and deliver them in order
+	OverflowSpill StreamOverflowPolicy = "spill"
+
+	streamBufferSize = 10
+)
+
+// StreamOpts are the options of how a tweet stream buffers the messages for the consumer and detects a stall
+type StreamOpts struct {
+	// BufferSize is the capacity of the message channels, defaults to 10
+	BufferSize int
+	// Overflow is the policy when a channel is full, defaults to OverflowDropNewest
+	Overflow StreamOverflowPolicy
+	// SpillDir is the directory of the spill files, defaults to the temporary directory
+	SpillDir string
+	// KeepAlive is how long the stream can go without a message or keep alive before a stall error is sent,
+	// defaults to 21 seconds
+	KeepAlive time.Duration
+}
+
+func (s StreamOpts) validate() error {
+	switch s.Overflow {
+	case "", OverflowDropNewest, OverflowDropOldest, OverflowBlock, OverflowSpill:
+	default:
+		return fmt.Errorf("stream overflow policy [%s] is not supported: %w", s.Overflow, ErrParameter)
+	}
+	if s.KeepAlive < 0 {
+		return fmt.Errorf("stream keep alive [%v] is negative: %w", s.KeepAlive, ErrParameter)
+	}
+	if s.BufferSize < 0 {
+		return fmt.Errorf("stream buffer size [%d] is negative: %w", s.BufferSize, ErrParameter)
+	}
+	return nil
+}
+
+func (s StreamOpts) bufferSize() int {
+	if s.BufferSize == 0 {
+		return streamBufferSize
+	}
+	return s.BufferSize
+}
+
+func (s StreamOpts) keepAlive() time.Duration {
+	if s.KeepAlive == 0 {
+		return keepAliveTO
+	}
+	return s.KeepAlive
+}
+
+func (s StreamOpts) policy() StreamOverflowPolicy {
+	if s.Overflow == "" {
+		return OverflowDropNewest
+	}
+	return s.Overflow
+}
+
+// StreamDrops are the number of messages dropped by the overflow policy for each message type
+type StreamDrops struct {
+	Tweets         uint64
+	SystemMessages uint64
+	Disconnections uint64
+	Errors         uint64
+}
+
+func (s StreamDrops) add(other StreamDrops) StreamDrops {
+	return StreamDrops{
+		Tweets:         s.Tweets + other.Tweets,
+		SystemMessages: s.SystemMessages + other.SystemMessages,
+		Disconnections: s.Disconnections + other.Disconnections,
+		Errors:         s.Errors + other.Errors,
+	}
+}
+
+// Total returns the number of dropped messages of all types
+func (s StreamDrops) Total() uint64 {
+	return s.Tweets + s.SystemMessages + s.Disconnections + s.Errors
+}
+
+// streamOutlet delivers one type of message to its channel with the overflow policy
+type streamOutlet struct {
+	policy  StreamOverflowPolicy
+	dropped uint64
+	// delivered is the number of messages put in the channel and not evicted
+	delivered uint64
+	// offer will send without blocking and return false if the channel is full
+	offer func(msg interface{}) bool
+	// wait will send and block until the message is sent or the stop channel is closed
+	wait func(msg interface{}, stop <-chan struct{}) bool
+	// evict will remove the oldest message of the channel
+	evict func() bool
+	spill *streamSpill
+}
+
+// send will deliver the message, returns false if the stream was stopped while blocked
+func (o *streamOutlet) send(msg interface{}, stop <-chan struct{}) bool {
+	if o.spill != nil && o.spill.pending() > 0 {
+		if err := o.spill.push(msg); err != nil {
+			atomic.AddUint64(&o.dropped, 1)
+		}
+		return true
+	}
+	if o.offer(msg) {
+		return o.delivery(true)
+	}
+
+	switch o.policy {
+	case OverflowBlock:
+		return o.delivery(o.wait(msg, stop))
+	case OverflowDropOldest:
+		for {
+			if o.evict() {
+				atomic.AddUint64(&o.dropped, 1)
+				atomic.AddUint64(&o.delivered, ^uint64(0))
+			}
+			if o.offer(msg) {
+				return o.delivery(true)
+			}
+		}
+	case OverflowSpill:
+		if o.spill != nil {
+			if err := o.spill.push(msg); err == nil {
+				return true
+			}
+		}
+		atomic.AddUint64(&o.dropped, 1)
+		return true
+	default:
+		atomic.AddUint64(&o.dropped, 1)
+		return true
+	}
+}
+
+// delivery will count the message if it was put in the channel and return if it was
+func (o *streamOutlet) delivery(sent bool) bool {
+	if sent {
+		atomic.AddUint64(&o.delivered, 1)
+	}
+	return sent
+}
+
+func (o *streamOutlet) drops() uint64 {
+	return atomic.LoadUint64(&o.dropped)
+}
+
+func (o *streamOutlet) deliveries() uint64 {
+	return atomic.LoadUint64(&o.delivered)
+}
+
+// streamSpill is a file backed queue of the messages that did not fit in the channel
+type streamSpill struct {
+	mutex   sync.Mutex
+	cond    *sync.Cond
+	writer  *os.File
+	reader  *os.File
+	decoder *json.Decoder
+	count   int
+	empty   chan struct{}
+	stopped bool
+	decode  func(decoder *json.Decoder) (interface{}, error)
+}
+
+func newStreamSpill(dir, name string, decode func(decoder *json.Decoder) (interface{}, error)) (*streamSpill, error) {
+	writer, err := os.CreateTemp(dir, "twitter-stream-"+name+"-*.spill")
+	if err != nil {
+		return nil, fmt.Errorf("stream spill create: %w", err)
+	}
+	reader, err := os.Open(writer.Name())
+	if err != nil {
+		writer.Close()
+		os.Remove(writer.Name())
+		return nil, fmt.Errorf("stream spill open: %w", err)
+	}
+	s := &streamSpill{
+		writer:  writer,
+		reader:  reader,
+		decoder: json.NewDecoder(reader),
+		empty:   make(chan struct{}),
+		decode:  decode,
+	}
+	close(s.empty)
+	s.cond = sync.NewCond(&s.mutex)
+	return s, nil
+}
+
+func (s *streamSpill) pending() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.count
+}
+
+func (s *streamSpill) push(msg interface{}) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.stopped {
+		return io.ErrClosedPipe
+	}
+	if err := json.NewEncoder(s.writer).Encode(msg); err != nil {
+		return fmt.Errorf("stream spill write: %w", err)
+	}
+	if s.count == 0 {
+		s.empty = make(chan struct{})
+	}
+	s.count++
+	s.cond.Signal()
+	return nil
+}
+
+// drained returns a channel that is closed when all of the spilled messages have been delivered
+func (s *streamSpill) drained() <-chan struct{} {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.empty
+}
+
+// pump will deliver the spilled messages in order until the spill is stopped
+func (s *streamSpill) pump(outlet *streamOutlet, stop <-chan struct{}) {
+	for {
+		s.mutex.Lock()
+		for s.count == 0 && !s.stopped {
+			s.cond.Wait()
+		}
+		if s.stopped {
+			s.mutex.Unlock()
+			return
+		}
+		msg, err := s.decode(s.decoder)
+		s.mutex.Unlock()
+
+		if err != nil {
+			atomic.AddUint64(&outlet.dropped, 1)
+		} else if !outlet.delivery(outlet.wait(msg, stop)) {
+			return
+		}
+
+		s.mutex.Lock()
+		s.count--
+		if s.count == 0 {
+			s.rewind()
+			close(s.empty)
+		}
+		s.mutex.Unlock()
+	}
+}
+
+// rewind will truncate the drained spill file so it does not grow over the life of the stream
+func (s *streamSpill) rewind() {
+	if err := s.writer.Truncate(0); err != nil {
+		return
+	}
+	if _, err := s.writer.Seek(0, io.SeekStart); err != nil {
+		return
+	}
+	if _, err := s.reader.Seek(0, io.SeekStart); err != nil {
+		return
+	}
+	s.decoder = json.NewDecoder(s.reader)
+}
+
+// stop will stop the pump and remove the spill file, returns the number of messages that were not delivered
+func (s *streamSpill) stop() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.stopped = true
+	s.cond.Broadcast()
+	s.writer.Close()
+	s.reader.Close()
+	os.Remove(s.writer.Name())
+	return s.count
+}
@@ -0,0 +1,142 @@
+package twitter
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func overflowTestStream(count int) io.ReadCloser {
+	lines := []string{}
+	for i := 1; i <= count; i++ {
+		lines = append(lines, `{"data":{"id":"`+string(rune('0'+i))+`","text":"hello"}}`)
+	}
+	lines = append(lines, `{"info":{"message":"done","sent":"2017-01-11T18:12:52+00:00"}}`)
+	return io.NopCloser(strings.NewReader(strings.Join(lines, "\r\n")))
+}
+
+func overflowTestTweets(t *testing.T, stream *TweetStream) []string {
+	ids := []string{}
+	msgs, _, _, _ := tweetStreamReceive(t, stream)
+	for _, msg := range msgs {
+		ids = append(ids, msg.Raw.Tweets[0].ID)
+	}
+	return ids
+}
+
+func TestStartTweetStreamWithOpts_Overflow(t *testing.T) {
+	tests := []struct {
+		name        string
+		opts        StreamOpts
+		slow        bool
+		want        []string
+		wantDropped StreamDrops
+	}{
+		{
+			name:        "drop newest",
+			opts:        StreamOpts{BufferSize: 1},
+			want:        []string{"1"},
+			wantDropped: StreamDrops{Tweets: 4},
+		},
+		{
+			name:        "drop oldest",
+			opts:        StreamOpts{BufferSize: 1, Overflow: OverflowDropOldest},
+			want:        []string{"5"},
+			wantDropped: StreamDrops{Tweets: 4},
+		},
+		{
+			name: "block",
+			opts: StreamOpts{BufferSize: 1, Overflow: OverflowBlock},
+			slow: true,
+			want: []string{"1", "2", "3", "4", "5"},
+		},
+		{
+			name: "spill",
+			opts: StreamOpts{BufferSize: 1, Overflow: OverflowSpill},
+			slow: true,
+			want: []string{"1", "2", "3", "4", "5"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.SpillDir = t.TempDir()
+			stream, err := StartTweetStreamWithOpts(context.Background(), overflowTestStream(5), tt.opts)
+			if err != nil {
+				t.Fatalf("StartTweetStreamWithOpts() error = %v", err)
+			}
+			defer stream.Close()
+
+			if tt.slow {
+				select {
+				case <-stream.done:
+					t.Fatalf("StartTweetStreamWithOpts() read the stream without the consumer")
+				case <-time.After(50 * time.Millisecond):
+				}
+			} else {
+				<-stream.done
+			}
+
+			got := overflowTestTweets(t, stream)
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("StartTweetStreamWithOpts() tweets = %v, want %v", got, tt.want)
+			}
+			if dropped := stream.Dropped(); dropped != tt.wantDropped {
+				t.Errorf("StartTweetStreamWithOpts() dropped = %+v, want %+v", dropped, tt.wantDropped)
+			}
+		})
+	}
+}
+
+func TestStartTweetStreamWithOpts_SpillClose(t *testing.T) {
+	dir := t.TempDir()
+	stream, err := StartTweetStreamWithOpts(context.Background(), overflowTestStream(5), StreamOpts{BufferSize: 1, Overflow: OverflowSpill, SpillDir: dir})
+	if err != nil {
+		t.Fatalf("StartTweetStreamWithOpts() error = %v", err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil || len(entries) != 3 {
+		t.Fatalf("StartTweetStreamWithOpts() spill files = %v, error = %v", entries, err)
+	}
+
+	// the first tweet is buffered and the rest are spilled when the consumer closes without reading
+	time.Sleep(50 * time.Millisecond)
+	stream.Close()
+	stream.Close()
+	for range stream.Tweets() {
+	}
+
+	if dropped := stream.Dropped(); dropped.Tweets != 4 {
+		t.Errorf("StartTweetStreamWithOpts() dropped = %+v", dropped)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("StartTweetStreamWithOpts() spill files were not removed %v", entries)
+	}
+}
+
+func TestStartTweetStreamWithOpts_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		opts StreamOpts
+	}{
+		{
+			name: "policy",
+			opts: StreamOpts{Overflow: "fast"},
+		},
+		{
+			name: "buffer size",
+			opts: StreamOpts{BufferSize: -1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := StartTweetStreamWithOpts(context.Background(), overflowTestStream(1), tt.opts)
+			if !errors.Is(err, ErrParameter) {
+				t.Errorf("StartTweetStreamWithOpts() error = %v, want ErrParameter", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,556 @@
+package twitter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	streamRecorderMaxBytes  = 100 << 20
+	streamRecordingTimeForm = "20060102T150405.000000000Z"
+	streamRecordingExt      = ".ndjson"
+)
+
+// StreamRecordEvent is the type of a stream record
+type StreamRecordEvent string
+
+const (
+	// StreamRecordConnect is the start of a stream connection
+	StreamRecordConnect StreamRecordEvent = "connect"
+	// StreamRecordLine is a line of the stream, a keep alive is an empty line
+	StreamRecordLine StreamRecordEvent = "line"
+	// StreamRecordDisconnect is the end of a stream connection, with the read error if it failed
+	StreamRecordDisconnect StreamRecordEvent = "disconnect"
+)
+
+// StreamRecord is a line of a stream recording
+type StreamRecord struct {
+	Time  time.Time         `json:"time"`
+	Event StreamRecordEvent `json:"event"`
+	// Endpoint is the stream endpoint of a connect record, when it is known
+	Endpoint string `json:"endpoint,omitempty"`
+	// Line is the stream line without the \r\n separator
+	Line string `json:"line,omitempty"`
+	// Data is the stream line when it is not valid UTF-8
+	Data []byte `json:"data,omitempty"`
+	// Partial is a line at the end of the connection that did not have a separator
+	Partial bool `json:"partial,omitempty"`
+	// Error is the read error of a disconnect record, it is empty when the stream ended or was closed
+	Error string `json:"error,omitempty"`
+}
+
+// bytes returns the stream bytes of a line record
+func (r *StreamRecord) bytes() []byte {
+	line := []byte(r.Line)
+	if r.Data != nil {
+		line = r.Data
+	}
+	if r.Partial {
+		return line
+	}
+	return append(line, '\r', '\n')
+}
+
+// StreamRecorderOpts are the options of the stream recorder files
+type StreamRecorderOpts struct {
+	// Dir is the directory of the recording files, it is created if it does not exist
+	Dir string
+	// Name is the prefix of the recording files, defaults to stream
+	Name string
+	// MaxBytes is the size of a file before it is rotated, defaults to 100 MiB
+	MaxBytes int64
+	// MaxAge is the age of a file before it is rotated, zero will only rotate by size
+	MaxAge time.Duration
+}
+
+func (s StreamRecorderOpts) validate() error {
+	switch {
+	case len(s.Dir) == 0:
+		return fmt.Errorf("stream recorder: the dir is required: %w", ErrParameter)
+	case s.MaxBytes < 0:
+		return fmt.Errorf("stream recorder: max bytes [%d] is negative: %w", s.MaxBytes, ErrParameter)
+	case s.MaxAge < 0:
+		return fmt.Errorf("stream recorder: max age [%v] is negative: %w", s.MaxAge, ErrParameter)
+	default:
+		return nil
+	}
+}
+
+func (s StreamRecorderOpts) name() string {
+	if len(s.Name) == 0 {
+		return "stream"
+	}
+	return storeFileName(s.Name)
+}
+
+func (s StreamRecorderOpts) maxBytes() int64 {
+	if s.MaxBytes == 0 {
+		return streamRecorderMaxBytes
+	}
+	return s.MaxBytes
+}
+
+// StreamRecorder will record the raw bytes of the stream connections to timestamped NDJSON files.
+//
+// Each connection is recorded as a connect record, a line record for each line, including the keep alives and
+// the system messages, and a disconnect record.  A recorder records one stream, so the connections of a managed
+// stream are recorded in order, and a recorder is needed for each of the streams that are read at the same time.
+// The files are rotated by size and age and are named with the time they were started.
+type StreamRecorder struct {
+	opts    StreamRecorderOpts
+	file    *os.File
+	size    int64
+	started time.Time
+	err     error
+	mutex   sync.Mutex
+}
+
+// NewStreamRecorder creates a stream recorder, the first file is created with the first record
+func NewStreamRecorder(opts StreamRecorderOpts) (*StreamRecorder, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(opts.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("stream recorder dir: %w", err)
+	}
+	return &StreamRecorder{
+		opts: opts,
+	}, nil
+}
+
+// Record will return a stream body that records the bytes as they are read from the body
+func (r *StreamRecorder) Record(body io.ReadCloser) io.ReadCloser {
+	return r.record(body, "")
+}
+
+func (r *StreamRecorder) record(body io.ReadCloser, endpoint string) io.ReadCloser {
+	r.write(&StreamRecord{
+		Time:     time.Now(),
+		Event:    StreamRecordConnect,
+		Endpoint: endpoint,
+	})
+	return &streamRecordBody{
+		recorder: r,
+		body:     body,
+	}
+}
+
+// Middleware returns the middleware that records the bodies of the stream responses of the client
+func (r *StreamRecorder) Middleware() Middleware {
+	return func(next CalloutHandler) CalloutHandler {
+		return func(callout *Callout) *CalloutResult {
+			result := next(callout)
+			if result == nil || result.Response == nil || result.Err != nil || !strings.HasSuffix(callout.Endpoint, "/stream") {
+				return result
+			}
+			result.Response.Body = r.record(result.Response.Body, callout.Endpoint)
+			return result
+		}
+	}
+}
+
+// Err returns the first error writing the recording, a recording error does not stop the stream
+func (r *StreamRecorder) Err() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.err
+}
+
+// Close will close the current recording file
+func (r *StreamRecorder) Close() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
+
+// write will write the record to the current file, rotating the file first if it is over the size or age
+func (r *StreamRecorder) write(record *StreamRecord) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if err := r.writeRecord(record); err != nil && r.err == nil {
+		r.err = err
+	}
+}
+
+func (r *StreamRecorder) writeRecord(record *StreamRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("stream recorder encode: %w", err)
+	}
+	data = append(data, '\n')
+
+	if r.rotate(int64(len(data))) {
+		if err := r.open(record.Time); err != nil {
+			return err
+		}
+	}
+	n, err := r.file.Write(data)
+	r.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("stream recorder write: %w", err)
+	}
+	return nil
+}
+
+func (r *StreamRecorder) rotate(size int64) bool {
+	switch {
+	case r.file == nil:
+		return true
+	case r.size > 0 && r.size+size > r.opts.maxBytes():
+		return true
+	case r.opts.MaxAge > 0 && time.Since(r.started) >= r.opts.MaxAge:
+		return true
+	default:
+		return false
+	}
+}
+
+func (r *StreamRecorder) open(started time.Time) error {
+	if r.file != nil {
+		r.file.Close()
+		r.file = nil
+	}
+	name := r.opts.name() + "-" + started.UTC().Format(streamRecordingTimeForm) + streamRecordingExt
+	file, err := os.OpenFile(filepath.Join(r.opts.Dir, name), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("stream recorder open: %w", err)
+	}
+	r.file = file
+	r.size = 0
+	r.started = started
+	return nil
+}
+
+// streamRecordBody records the lines of a stream body as they are read
+type streamRecordBody struct {
+	recorder *StreamRecorder
+	body     io.ReadCloser
+	pending  []byte
+	done     bool
+	mutex    sync.Mutex
+}
+
+func (b *streamRecordBody) Read(p []byte) (int, error) {
+	n, err := b.body.Read(p)
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.done {
+		return n, err
+	}
+	now := time.Now()
+	b.pending = append(b.pending, p[:n]...)
+	for {
+		idx := bytes.Index(b.pending, []byte("\r\n"))
+		if idx < 0 {
+			break
+		}
+		b.line(now, b.pending[:idx], false)
+		b.pending = b.pending[idx+2:]
+	}
+	if err != nil {
+		disconnect := &StreamRecord{
+			Time:  now,
+			Event: StreamRecordDisconnect,
+		}
+		if !errors.Is(err, io.EOF) {
+			disconnect.Error = err.Error()
+		}
+		b.end(disconnect)
+	}
+	return n, err
+}
+
+// Close will record the disconnect, unless the stream has already ended, and close the body
+func (b *streamRecordBody) Close() error {
+	b.mutex.Lock()
+	if !b.done {
+		b.end(&StreamRecord{
+			Time:  time.Now(),
+			Event: StreamRecordDisconnect,
+		})
+	}
+	b.mutex.Unlock()
+	return b.body.Close()
+}
+
+func (b *streamRecordBody) line(now time.Time, line []byte, partial bool) {
+	record := &StreamRecord{
+		Time:    now,
+		Event:   StreamRecordLine,
+		Partial: partial,
+	}
+	if utf8.Valid(line) {
+		record.Line = string(line)
+	} else {
+		record.Data = append([]byte{}, line...)
+	}
+	b.recorder.write(record)
+}
+
+// end will record the partial line and the disconnect
+func (b *streamRecordBody) end(disconnect *StreamRecord) {
+	if len(b.pending) > 0 {
+		b.line(disconnect.Time, b.pending, true)
+		b.pending = nil
+	}
+	b.recorder.write(disconnect)
+	b.done = true
+}
+
+// StreamRecordingFiles returns the recording files of the name in the directory, oldest first
+func StreamRecordingFiles(dir, name string) ([]string, error) {
+	name = StreamRecorderOpts{Name: name}.name()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("stream recording files: %w", err)
+	}
+	files := []string{}
+	for _, entry := range entries {
+		stamp := strings.TrimPrefix(entry.Name(), name+"-")
+		if entry.IsDir() || stamp == entry.Name() || !strings.HasSuffix(stamp, streamRecordingExt) {
+			continue
+		}
+		if _, err := time.Parse(streamRecordingTimeForm, strings.TrimSuffix(stamp, streamRecordingExt)); err != nil {
+			continue
+		}
+		files = append(files, filepath.Join(dir, entry.Name()))
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// OpenStreamRecording will open the recording files of the name in the directory as one reader
+func OpenStreamRecording(dir, name string) (io.ReadCloser, error) {
+	paths, err := StreamRecordingFiles(dir, name)
+	if err != nil {
+		return nil, err
+	}
+	recording := &streamRecording{}
+	readers := make([]io.Reader, 0, len(paths))
+	for _, path := range paths {
+		file, err := os.Open(path)
+		if err != nil {
+			recording.Close()
+			return nil, fmt.Errorf("stream recording open: %w", err)
+		}
+		recording.files = append(recording.files, file)
+		readers = append(readers, file)
+	}
+	recording.Reader = io.MultiReader(readers...)
+	return recording, nil
+}
+
+type streamRecording struct {
+	io.Reader
+	files []*os.File
+}
+
+func (s *streamRecording) Close() error {
+	for _, file := range s.files {
+		file.Close()
+	}
+	return nil
+}
+
+// StreamReplayOpts are the options of a stream replay
+type StreamReplayOpts struct {
+	// Pacing will replay the lines at their recorded times, otherwise they are replayed as fast as they are read
+	Pacing bool
+	// Stream are the options of the replayed tweet streams
+	Stream StreamOpts
+}
+
+// StreamReplayer will replay the connections of a stream recording.
+//
+// Each connection is replayed as a stream body with the bytes of the recorded lines, and ends with the recorded
+// read error as a simulated disconnect.  With pacing, the lines are delayed to their recorded time from the
+// start of the connection, so the keep alives and the stalls are the same as the recording.
+type StreamReplayer struct {
+	decoder   *json.Decoder
+	opts      StreamReplayOpts
+	lookahead *StreamRecord
+	started   bool
+	mutex     sync.Mutex
+}
+
+// NewStreamReplayer creates a replayer of the recording
+func NewStreamReplayer(recording io.Reader, opts StreamReplayOpts) *StreamReplayer {
+	return &StreamReplayer{
+		decoder: json.NewDecoder(recording),
+		opts:    opts,
+	}
+}
+
+// Next returns the body of the next connection of the recording, io.EOF when there are no more connections
+func (r *StreamReplayer) Next(ctx context.Context) (io.ReadCloser, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for {
+		record, err := r.read()
+		if err != nil {
+			return nil, err
+		}
+		first := !r.started
+		r.started = true
+		switch {
+		case record.Event == StreamRecordConnect:
+		case record.Event == StreamRecordLine && first:
+			// a recording that does not start with a connect is replayed from its first line
+			r.lookahead = record
+		default:
+			// the rest of a connection that was closed before it ended
+			continue
+		}
+		return &streamReplayBody{
+			replayer: r,
+			ctx:      ctx,
+			start:    time.Now(),
+			recorded: record.Time,
+			closed:   make(chan struct{}),
+		}, nil
+	}
+}
+
+// TweetStream will replay the next connection of the recording as a tweet stream
+func (r *StreamReplayer) TweetStream(ctx context.Context) (*TweetStream, error) {
+	body, err := r.Next(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("stream replay: %w", err)
+	}
+	return StartTweetStreamWithOpts(ctx, body, r.opts.Stream)
+}
+
+// ManagedTweetStream will replay the connections of the recording as a managed stream, which reconnects to the
+// next connection after each disconnect.  The stream ends with an error that wraps ErrStreamClosed after the
+// last connection.
+func (r *StreamReplayer) ManagedTweetStream(ctx context.Context, managed ManagedStreamOpts) (*ManagedTweetStream, error) {
+	return startManagedTweetStream(ctx, 0, managed, func(ctx context.Context, _ int) (*TweetStream, error) {
+		body, err := r.Next(ctx)
+		switch {
+		case errors.Is(err, io.EOF):
+			return nil, fmt.Errorf("stream replay: end of the recording: %w", ErrStreamClosed)
+		case err != nil:
+			return nil, fmt.Errorf("stream replay: %w", err)
+		default:
+		}
+		return StartTweetStreamWithOpts(ctx, body, r.opts.Stream)
+	})
+}
+
+// read returns the next record
+func (r *StreamReplayer) read() (*StreamRecord, error) {
+	if record := r.lookahead; record != nil {
+		r.lookahead = nil
+		return record, nil
+	}
+	record := &StreamRecord{}
+	if err := r.decoder.Decode(record); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, io.EOF
+		}
+		return nil, fmt.Errorf("stream replay decode: %w", err)
+	}
+	return record, nil
+}
+
+// streamReplayBody is the body of a replayed connection
+type streamReplayBody struct {
+	replayer  *StreamReplayer
+	ctx       context.Context
+	start     time.Time
+	recorded  time.Time
+	buffer    []byte
+	err       error
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func (b *streamReplayBody) Read(p []byte) (int, error) {
+	for len(b.buffer) == 0 {
+		if b.err != nil {
+			return 0, b.err
+		}
+		record, err := b.next()
+		if err != nil {
+			b.err = err
+			continue
+		}
+		if !b.wait(record.Time) {
+			b.err = io.ErrClosedPipe
+			continue
+		}
+		b.buffer = record.bytes()
+	}
+	n := copy(p, b.buffer)
+	b.buffer = b.buffer[n:]
+	return n, nil
+}
+
+// next returns the next line of the connection, or the error that ends it
+func (b *streamReplayBody) next() (*StreamRecord, error) {
+	b.replayer.mutex.Lock()
+	defer b.replayer.mutex.Unlock()
+	select {
+	case <-b.closed:
+		return nil, io.ErrClosedPipe
+	default:
+	}
+	record, err := b.replayer.read()
+	switch {
+	case err != nil:
+		return nil, err
+	case record.Event == StreamRecordConnect:
+		// the connection was not recorded to the end
+		b.replayer.lookahead = record
+		return nil, io.EOF
+	case record.Event == StreamRecordDisconnect && len(record.Error) > 0:
+		return nil, errors.New(record.Error)
+	case record.Event == StreamRecordDisconnect:
+		return nil, io.EOF
+	default:
+		return record, nil
+	}
+}
+
+// wait will wait for the recorded time of the line with pacing, returns false if the body is closed
+func (b *streamReplayBody) wait(recorded time.Time) bool {
+	if !b.replayer.opts.Pacing {
+		return true
+	}
+	delay := time.Until(b.start.Add(recorded.Sub(b.recorded)))
+	if delay <= 0 {
+		return true
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-b.closed:
+		return false
+	case <-b.ctx.Done():
+		return false
+	}
+}
+
+func (b *streamReplayBody) Close() error {
+	b.closeOnce.Do(func() { close(b.closed) })
+	return nil
+}
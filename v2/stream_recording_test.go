@@ -0,0 +1,193 @@
+package twitter
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// streamRecordingReader returns the chunks of the stream with an error at the end
+type streamRecordingReader struct {
+	chunks []string
+	err    error
+}
+
+func (r *streamRecordingReader) Read(p []byte) (int, error) {
+	if len(r.chunks) == 0 {
+		return 0, r.err
+	}
+	n := copy(p, r.chunks[0])
+	r.chunks[0] = r.chunks[0][n:]
+	if len(r.chunks[0]) == 0 {
+		r.chunks = r.chunks[1:]
+	}
+	return n, nil
+}
+
+func (r *streamRecordingReader) Close() error {
+	return nil
+}
+
+func TestStreamRecorder(t *testing.T) {
+	dir := t.TempDir()
+	recorder, err := NewStreamRecorder(StreamRecorderOpts{
+		Dir:      dir,
+		Name:     "sample",
+		MaxBytes: 300,
+	})
+	if err != nil {
+		t.Fatalf("NewStreamRecorder() error = %v", err)
+	}
+	connections := []*streamRecordingReader{
+		{
+			chunks: []string{`{"data":{"id":"1","text":"hel`, `lo"}}` + "\r\n\r\n", `{"data":{"id":"2","text":"world"}}` + "\r\n"},
+			err:    errors.New("connection reset"),
+		},
+		{
+			chunks: []string{`{"error":{"message":"Forced Disconnect","sent":"2017-01-11T18:12:52+00:00"}}` + "\r\n", `{"data":{"id":"3"`},
+			err:    io.EOF,
+		},
+	}
+	want := []string{}
+	for _, conn := range connections {
+		want = append(want, strings.Join(conn.chunks, ""))
+		body := recorder.Record(conn)
+		if _, err := io.ReadAll(body); err != nil && err != conn.err {
+			t.Fatalf("Record() read error = %v", err)
+		}
+		body.Close()
+	}
+	if err := recorder.Close(); err != nil || recorder.Err() != nil {
+		t.Fatalf("Close() error = %v, %v", err, recorder.Err())
+	}
+
+	files, err := StreamRecordingFiles(dir, "sample")
+	if err != nil {
+		t.Fatalf("StreamRecordingFiles() error = %v", err)
+	}
+	if len(files) < 2 {
+		t.Errorf("StreamRecordingFiles() not rotated = %v", files)
+	}
+
+	recording, err := OpenStreamRecording(dir, "sample")
+	if err != nil {
+		t.Fatalf("OpenStreamRecording() error = %v", err)
+	}
+	defer recording.Close()
+	replayer := NewStreamReplayer(recording, StreamReplayOpts{})
+	wantErrs := []string{"connection reset", ""}
+	for i := range connections {
+		body, err := replayer.Next(context.Background())
+		if err != nil {
+			t.Fatalf("Next() error = %v", err)
+		}
+		got, err := io.ReadAll(body)
+		if string(got) != want[i] {
+			t.Errorf("Next() connection %d = %q, want %q", i, got, want[i])
+		}
+		if gotErr := ""; err != nil {
+			gotErr = err.Error()
+			if gotErr != wantErrs[i] {
+				t.Errorf("Next() connection %d error = %v, want %v", i, gotErr, wantErrs[i])
+			}
+		} else if wantErrs[i] != "" {
+			t.Errorf("Next() connection %d no error, want %v", i, wantErrs[i])
+		}
+	}
+	if _, err := replayer.Next(context.Background()); !errors.Is(err, io.EOF) {
+		t.Errorf("Next() after the recording error = %v", err)
+	}
+}
+
+func TestStreamRecorder_Middleware(t *testing.T) {
+	dir := t.TempDir()
+	recorder, err := NewStreamRecorder(StreamRecorderOpts{Dir: dir})
+	if err != nil {
+		t.Fatalf("NewStreamRecorder() error = %v", err)
+	}
+	defer recorder.Close()
+	c := &Client{
+		Authorizer: &mockAuth{},
+		Host:       "https://www.test.com",
+		Client: mockHTTPClient(func(req *http.Request) *http.Response {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(strings.NewReader(managedStreamTweets("1", "2"))),
+				Header:     http.Header{},
+				Request:    req,
+			}
+		}),
+		Middleware: []Middleware{recorder.Middleware()},
+	}
+	stream, err := c.TweetSampleStream(context.Background(), TweetSampleStreamOpts{})
+	if err != nil {
+		t.Fatalf("TweetSampleStream() error = %v", err)
+	}
+	tweetStreamReceive(t, stream)
+	stream.Close()
+
+	recording, err := OpenStreamRecording(dir, "")
+	if err != nil {
+		t.Fatalf("OpenStreamRecording() error = %v", err)
+	}
+	defer recording.Close()
+	replayer := NewStreamReplayer(recording, StreamReplayOpts{})
+	replay, err := replayer.TweetStream(context.Background())
+	if err != nil {
+		t.Fatalf("TweetStream() error = %v", err)
+	}
+	got := []string{}
+	msgs, _, _, _ := tweetStreamReceive(t, replay)
+	for _, msg := range msgs {
+		got = append(got, msg.Raw.Tweets[0].ID)
+	}
+	if !reflect.DeepEqual(got, []string{"1", "2"}) {
+		t.Errorf("TweetStream() replay = %v", got)
+	}
+}
+
+func TestStreamReplayer_ManagedTweetStream(t *testing.T) {
+	managedStreamTestBackoffs(t)
+
+	recording := ""
+	for _, record := range []string{
+		`{"time":"2021-05-26T00:00:00Z","event":"connect"}`,
+		`{"time":"2021-05-26T00:00:00.05Z","event":"line","line":"{\"data\":{\"id\":\"1\"}}"}`,
+		`{"time":"2021-05-26T00:00:00.1Z","event":"line"}`,
+		`{"time":"2021-05-26T00:00:00.15Z","event":"disconnect","error":"connection reset"}`,
+		`{"time":"2021-05-26T00:00:01Z","event":"connect"}`,
+		`{"time":"2021-05-26T00:00:01.05Z","event":"line","line":"{\"data\":{\"id\":\"1\"}}"}`,
+		`{"time":"2021-05-26T00:00:01.1Z","event":"line","line":"{\"data\":{\"id\":\"2\"}}"}`,
+		`{"time":"2021-05-26T00:00:01.15Z","event":"disconnect"}`,
+	} {
+		recording += record + "\n"
+	}
+
+	began := time.Now()
+	replayer := NewStreamReplayer(strings.NewReader(recording), StreamReplayOpts{Pacing: true})
+	stream, err := replayer.ManagedTweetStream(context.Background(), ManagedStreamOpts{})
+	if err != nil {
+		t.Fatalf("ManagedTweetStream() error = %v", err)
+	}
+	defer stream.Close()
+
+	got, _ := managedStreamReceive(t, stream, 2)
+	if !reflect.DeepEqual(got, []string{"1", "2"}) {
+		t.Errorf("ManagedTweetStream() replay = %v", got)
+	}
+	var last error
+	for err := range stream.Err() {
+		last = err
+	}
+	if !errors.Is(last, ErrStreamClosed) {
+		t.Errorf("ManagedTweetStream() end of the recording error = %v", last)
+	}
+	if elapsed := time.Since(began); elapsed < 200*time.Millisecond {
+		t.Errorf("ManagedTweetStream() replay was not paced, elapsed %v", elapsed)
+	}
+}
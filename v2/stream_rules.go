@@ -0,0 +1,199 @@
+package twitter
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+const tweetSearchStreamRuleBatchSize = 100
+
+// TweetSearchStreamSyncResponse is the plan and the result of synchronizing the search stream rules
+type TweetSearchStreamSyncResponse struct {
+	// Add are the desired rules that are missing
+	Add []TweetSearchStreamRule
+	// Delete are the current rules that are not desired, including the duplicates
+	Delete []*TweetSearchStreamRuleEntity
+	// Keep are the current rules that are desired
+	Keep []*TweetSearchStreamRuleEntity
+	// Created are the rules created when the plan was applied
+	Created []*TweetSearchStreamRuleEntity
+	// Deleted is the number of rules deleted when the plan was applied
+	Deleted int
+	// Errors are the partial errors of the add and delete callouts
+	Errors []*ErrorObj
+	DryRun bool
+}
+
+// Changed returns if the current rules are different from the desired rules
+func (t *TweetSearchStreamSyncResponse) Changed() bool {
+	return len(t.Add) > 0 || len(t.Delete) > 0
+}
+
+// Plan returns the changes as lines prefixed by + for an added rule and - for a deleted rule
+func (t *TweetSearchStreamSyncResponse) Plan() string {
+	sb := strings.Builder{}
+	for _, rule := range t.Delete {
+		sb.WriteString(fmt.Sprintf("- %s %s\n", rule.ID, tweetSearchStreamRuleString(rule.TweetSearchStreamRule)))
+	}
+	for _, rule := range t.Add {
+		sb.WriteString(fmt.Sprintf("+ %s\n", tweetSearchStreamRuleString(rule)))
+	}
+	return sb.String()
+}
+
+func tweetSearchStreamRuleString(rule TweetSearchStreamRule) string {
+	if len(rule.Tag) == 0 {
+		return fmt.Sprintf("%q", rule.Value)
+	}
+	return fmt.Sprintf("%q tag %q", rule.Value, rule.Tag)
+}
+
+func tweetSearchStreamRuleKey(rule TweetSearchStreamRule) string {
+	return rule.Value + "\x00" + rule.Tag
+}
+
+// planTweetSearchStreamRules will diff the current rules against the desired rules by value and tag
+func planTweetSearchStreamRules(current []*TweetSearchStreamRuleEntity, desired []TweetSearchStreamRule) *TweetSearchStreamSyncResponse {
+	plan := &TweetSearchStreamSyncResponse{
+		Add:    []TweetSearchStreamRule{},
+		Delete: []*TweetSearchStreamRuleEntity{},
+		Keep:   []*TweetSearchStreamRuleEntity{},
+	}
+
+	wanted := map[string]bool{}
+	for _, rule := range desired {
+		wanted[tweetSearchStreamRuleKey(rule)] = true
+	}
+
+	kept := map[string]bool{}
+	for _, rule := range current {
+		key := tweetSearchStreamRuleKey(rule.TweetSearchStreamRule)
+		switch {
+		case wanted[key] && !kept[key]:
+			kept[key] = true
+			plan.Keep = append(plan.Keep, rule)
+		default:
+			plan.Delete = append(plan.Delete, rule)
+		}
+	}
+
+	for _, rule := range desired {
+		key := tweetSearchStreamRuleKey(rule)
+		if kept[key] {
+			continue
+		}
+		kept[key] = true
+		plan.Add = append(plan.Add, rule)
+	}
+	return plan
+}
+
+// SyncRules will make the search stream rules match the desired rules.  The current rules are compared to the
+// desired rules by value and tag, the missing rules are added and then the rules that are not desired are deleted,
+// in batches, so the stream does not run without the desired rules in between.  Twitter does not allow two rules
+// with the same value, so when only the tag of a rule changes the current rule is deleted before the rule is added.
+// Syncing the same rules again will not make any changes.
+//
+// When twitter rejects some of the rules, the rest of the plan is still applied and the plan is returned with an
+// error wrapping a PartialError of the rejected rules.
+//
+// With dry run, the plan is returned without deleting any rules and the rules to add are only validated by twitter.
+func (c *Client) SyncRules(ctx context.Context, desired []TweetSearchStreamRule, dryRun bool) (*TweetSearchStreamSyncResponse, error) {
+	if err := tweetSearchStreamRules(desired).validate(); err != nil {
+		return nil, fmt.Errorf("tweet search stream sync rules: %w", err)
+	}
+
+	current, err := c.TweetSearchStreamRules(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("tweet search stream sync rules: %w", err)
+	}
+
+	plan := planTweetSearchStreamRules(current.Rules, desired)
+	plan.DryRun = dryRun
+	plan.Created = []*TweetSearchStreamRuleEntity{}
+
+	// the current rules with the value of a rule to add are retagged, they are deleted first
+	adding := map[string]bool{}
+	for _, rule := range plan.Add {
+		adding[rule.Value] = true
+	}
+	retagged := []*TweetSearchStreamRuleEntity{}
+	deleting := []*TweetSearchStreamRuleEntity{}
+	for _, rule := range plan.Delete {
+		if adding[rule.Value] {
+			retagged = append(retagged, rule)
+			continue
+		}
+		deleting = append(deleting, rule)
+	}
+
+	add := plan.Add
+	if dryRun {
+		// the values of the retagged rules are already valid and twitter would report them as duplicates
+		add = []TweetSearchStreamRule{}
+		retaggedValues := map[string]bool{}
+		for _, rule := range retagged {
+			retaggedValues[rule.Value] = true
+		}
+		for _, rule := range plan.Add {
+			if !retaggedValues[rule.Value] {
+				add = append(add, rule)
+			}
+		}
+	} else if err := c.syncDeleteRules(ctx, plan, retagged); err != nil {
+		return plan, err
+	}
+
+	for start := 0; start < len(add); start += tweetSearchStreamRuleBatchSize {
+		end := start + tweetSearchStreamRuleBatchSize
+		if end > len(add) {
+			end = len(add)
+		}
+		resp, err := c.TweetSearchStreamAddRule(ctx, add[start:end], dryRun)
+		if err != nil {
+			return plan, fmt.Errorf("tweet search stream sync rules: %w", err)
+		}
+		plan.Errors = append(plan.Errors, resp.Errors...)
+		if !dryRun {
+			plan.Created = append(plan.Created, resp.Rules...)
+		}
+	}
+
+	if !dryRun {
+		if err := c.syncDeleteRules(ctx, plan, deleting); err != nil {
+			return plan, err
+		}
+	}
+
+	if len(plan.Errors) > 0 {
+		return plan, fmt.Errorf("tweet search stream sync rules: %w", &PartialError{
+			Name:   "tweet search stream sync rules",
+			Errors: plan.Errors,
+		})
+	}
+	return plan, nil
+}
+
+// syncDeleteRules will delete the rules in batches and record the deleted count and the errors in the plan
+func (c *Client) syncDeleteRules(ctx context.Context, plan *TweetSearchStreamSyncResponse, rules []*TweetSearchStreamRuleEntity) error {
+	for start := 0; start < len(rules); start += tweetSearchStreamRuleBatchSize {
+		end := start + tweetSearchStreamRuleBatchSize
+		if end > len(rules) {
+			end = len(rules)
+		}
+		ids := make([]TweetSearchStreamRuleID, 0, end-start)
+		for _, rule := range rules[start:end] {
+			ids = append(ids, rule.ID)
+		}
+		resp, err := c.TweetSearchStreamDeleteRuleByID(ctx, ids, false)
+		if err != nil {
+			return fmt.Errorf("tweet search stream sync rules: %w", err)
+		}
+		plan.Errors = append(plan.Errors, resp.Errors...)
+		if resp.Meta != nil {
+			plan.Deleted += resp.Meta.Summary.Deleted
+		}
+	}
+	return nil
+}
@@ -0,0 +1,309 @@
+package twitter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// mockRuleServer keeps the search stream rules in memory
+type mockRuleServer struct {
+	rules   []*TweetSearchStreamRuleEntity
+	nextID  int
+	adds    int
+	deletes int
+	dryRuns int
+	// reject are the rule values that are returned as partial errors
+	reject map[string]bool
+	calls  []string
+}
+
+func (m *mockRuleServer) handle(req *http.Request) *http.Response {
+	resp := &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{},
+		Request:    req,
+	}
+	var body interface{}
+	switch req.Method {
+	case http.MethodGet:
+		body = map[string]interface{}{
+			"data": m.rules,
+			"meta": map[string]interface{}{},
+		}
+	case http.MethodPost:
+		request := struct {
+			Add    []TweetSearchStreamRule `json:"add"`
+			Delete struct {
+				IDs []TweetSearchStreamRuleID `json:"ids"`
+			} `json:"delete"`
+		}{}
+		json.NewDecoder(req.Body).Decode(&request)
+		dryRun := req.URL.Query().Get("dry_run") == "true"
+		if dryRun {
+			m.dryRuns++
+		}
+		switch {
+		case len(request.Add) > 0:
+			resp.StatusCode = http.StatusCreated
+			created := []*TweetSearchStreamRuleEntity{}
+			errs := []*ErrorObj{}
+			for _, rule := range request.Add {
+				if m.reject[rule.Value] {
+					errs = append(errs, &ErrorObj{Title: "UnprocessableEntity", Detail: rule.Value, Type: "https://api.twitter.com/2/problems/invalid-rules"})
+					continue
+				}
+				if m.exists(rule.Value) {
+					errs = append(errs, &ErrorObj{Title: "DuplicateRule", Detail: rule.Value, Type: "https://api.twitter.com/2/problems/duplicate-rules"})
+					continue
+				}
+				m.nextID++
+				created = append(created, &TweetSearchStreamRuleEntity{
+					ID:                    TweetSearchStreamRuleID(fmt.Sprintf("%d", m.nextID)),
+					TweetSearchStreamRule: rule,
+				})
+			}
+			if !dryRun {
+				m.adds++
+				m.calls = append(m.calls, "add")
+				m.rules = append(m.rules, created...)
+			}
+			body = map[string]interface{}{
+				"data":   created,
+				"errors": errs,
+				"meta":   map[string]interface{}{"summary": map[string]int{"created": len(created)}},
+			}
+		default:
+			if !dryRun {
+				m.deletes++
+				m.calls = append(m.calls, "delete")
+			}
+			remove := map[TweetSearchStreamRuleID]bool{}
+			for _, id := range request.Delete.IDs {
+				remove[id] = true
+			}
+			kept := []*TweetSearchStreamRuleEntity{}
+			for _, rule := range m.rules {
+				if !remove[rule.ID] || dryRun {
+					kept = append(kept, rule)
+				}
+			}
+			deleted := len(m.rules) - len(kept)
+			m.rules = kept
+			body = map[string]interface{}{
+				"meta": map[string]interface{}{"summary": map[string]int{"deleted": deleted}},
+			}
+		}
+	}
+	enc, _ := json.Marshal(body)
+	resp.Body = io.NopCloser(strings.NewReader(string(enc)))
+	return resp
+}
+
+func (m *mockRuleServer) exists(value string) bool {
+	for _, rule := range m.rules {
+		if rule.Value == value {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *mockRuleServer) values() []string {
+	values := []string{}
+	for _, rule := range m.rules {
+		values = append(values, tweetSearchStreamRuleString(rule.TweetSearchStreamRule))
+	}
+	sort.Strings(values)
+	return values
+}
+
+func mockRuleEntity(id, value, tag string) *TweetSearchStreamRuleEntity {
+	return &TweetSearchStreamRuleEntity{
+		ID: TweetSearchStreamRuleID(id),
+		TweetSearchStreamRule: TweetSearchStreamRule{
+			Value: value,
+			Tag:   tag,
+		},
+	}
+}
+
+func TestClient_SyncRules(t *testing.T) {
+	server := &mockRuleServer{
+		nextID: 10,
+		rules: []*TweetSearchStreamRuleEntity{
+			mockRuleEntity("1", "cat has:images", "cats"),
+			mockRuleEntity("2", "dog", ""),
+			mockRuleEntity("3", "cat has:images", "cats"),
+			mockRuleEntity("4", "old", ""),
+		},
+	}
+	c := &Client{
+		Authorizer: &mockAuth{},
+		Host:       "https://www.test.com",
+		Client:     mockHTTPClient(server.handle),
+	}
+	desired := []TweetSearchStreamRule{
+		{Value: "cat has:images", Tag: "cats"},
+		{Value: "dog", Tag: "dogs"},
+		{Value: "new"},
+		{Value: "new"},
+	}
+
+	plan, err := c.SyncRules(context.Background(), desired, true)
+	if err != nil {
+		t.Fatalf("Client.SyncRules() dry run error = %v", err)
+	}
+	wantPlan := "- 2 \"dog\"\n- 3 \"cat has:images\" tag \"cats\"\n- 4 \"old\"\n+ \"dog\" tag \"dogs\"\n+ \"new\"\n"
+	if plan.Plan() != wantPlan {
+		t.Errorf("Client.SyncRules() plan = %q, want %q", plan.Plan(), wantPlan)
+	}
+	if server.adds != 0 || server.deletes != 0 || server.dryRuns != 1 || len(server.rules) != 4 {
+		t.Errorf("Client.SyncRules() dry run changed the rules adds %d deletes %d", server.adds, server.deletes)
+	}
+
+	plan, err = c.SyncRules(context.Background(), desired, false)
+	if err != nil {
+		t.Fatalf("Client.SyncRules() error = %v", err)
+	}
+	if !plan.Changed() || plan.Deleted != 3 || len(plan.Created) != 2 || len(plan.Keep) != 1 {
+		t.Errorf("Client.SyncRules() = %+v", plan)
+	}
+	want := []string{`"cat has:images" tag "cats"`, `"dog" tag "dogs"`, `"new"`}
+	if !reflect.DeepEqual(server.values(), want) {
+		t.Errorf("Client.SyncRules() rules = %v, want %v", server.values(), want)
+	}
+	if want := []string{"delete", "add", "delete"}; !reflect.DeepEqual(server.calls, want) {
+		t.Errorf("Client.SyncRules() calls = %v, want %v", server.calls, want)
+	}
+
+	adds, deletes := server.adds, server.deletes
+	plan, err = c.SyncRules(context.Background(), desired, false)
+	if err != nil {
+		t.Fatalf("Client.SyncRules() again error = %v", err)
+	}
+	if plan.Changed() || plan.Plan() != "" || server.adds != adds || server.deletes != deletes {
+		t.Errorf("Client.SyncRules() again is not idempotent %+v", plan)
+	}
+}
+
+func TestClient_SyncRules_Tag(t *testing.T) {
+	server := &mockRuleServer{
+		nextID: 10,
+		rules: []*TweetSearchStreamRuleEntity{
+			mockRuleEntity("1", "cat", "cats"),
+		},
+	}
+	c := &Client{
+		Authorizer: &mockAuth{},
+		Host:       "https://www.test.com",
+		Client:     mockHTTPClient(server.handle),
+	}
+	desired := []TweetSearchStreamRule{
+		{Value: "cat", Tag: "kittens"},
+	}
+
+	plan, err := c.SyncRules(context.Background(), desired, true)
+	if err != nil {
+		t.Fatalf("Client.SyncRules() dry run error = %v", err)
+	}
+	if want := "- 1 \"cat\" tag \"cats\"\n+ \"cat\" tag \"kittens\"\n"; plan.Plan() != want {
+		t.Errorf("Client.SyncRules() plan = %q, want %q", plan.Plan(), want)
+	}
+
+	plan, err = c.SyncRules(context.Background(), desired, false)
+	if err != nil {
+		t.Fatalf("Client.SyncRules() error = %v", err)
+	}
+	if plan.Deleted != 1 || len(plan.Created) != 1 {
+		t.Errorf("Client.SyncRules() deleted %d created %d", plan.Deleted, len(plan.Created))
+	}
+	if want := []string{`"cat" tag "kittens"`}; !reflect.DeepEqual(server.values(), want) {
+		t.Errorf("Client.SyncRules() rules = %v, want %v", server.values(), want)
+	}
+	if want := []string{"delete", "add"}; !reflect.DeepEqual(server.calls, want) {
+		t.Errorf("Client.SyncRules() calls = %v, want %v", server.calls, want)
+	}
+}
+
+func TestClient_SyncRules_Batches(t *testing.T) {
+	server := &mockRuleServer{}
+	for i := 0; i < 150; i++ {
+		server.rules = append(server.rules, mockRuleEntity(fmt.Sprintf("old-%d", i), fmt.Sprintf("old %d", i), ""))
+	}
+	c := &Client{
+		Authorizer: &mockAuth{},
+		Host:       "https://www.test.com",
+		Client:     mockHTTPClient(server.handle),
+	}
+	desired := []TweetSearchStreamRule{}
+	for i := 0; i < 250; i++ {
+		desired = append(desired, TweetSearchStreamRule{Value: fmt.Sprintf("new %d", i)})
+	}
+
+	plan, err := c.SyncRules(context.Background(), desired, false)
+	if err != nil {
+		t.Fatalf("Client.SyncRules() error = %v", err)
+	}
+	if server.deletes != 2 || server.adds != 3 {
+		t.Errorf("Client.SyncRules() deletes %d adds %d", server.deletes, server.adds)
+	}
+	if plan.Deleted != 150 || len(plan.Created) != 250 || len(server.rules) != 250 {
+		t.Errorf("Client.SyncRules() deleted %d created %d rules %d", plan.Deleted, len(plan.Created), len(server.rules))
+	}
+}
+
+func TestClient_SyncRules_Invalid(t *testing.T) {
+	c := &Client{
+		Authorizer: &mockAuth{},
+		Host:       "https://www.test.com",
+		Client: mockHTTPClient(func(req *http.Request) *http.Response {
+			t.Errorf("Client.SyncRules() made a callout with an invalid rule")
+			return nil
+		}),
+	}
+	if _, err := c.SyncRules(context.Background(), []TweetSearchStreamRule{{Tag: "empty"}}, false); !errors.Is(err, ErrParameter) {
+		t.Errorf("Client.SyncRules() error = %v, want ErrParameter", err)
+	}
+}
+
+func TestClient_SyncRules_PartialErrors(t *testing.T) {
+	server := &mockRuleServer{
+		nextID: 10,
+		rules: []*TweetSearchStreamRuleEntity{
+			mockRuleEntity("1", "old", ""),
+		},
+		reject: map[string]bool{"bad": true},
+	}
+	c := &Client{
+		Authorizer: &mockAuth{},
+		Host:       "https://www.test.com",
+		Client:     mockHTTPClient(server.handle),
+	}
+	desired := []TweetSearchStreamRule{
+		{Value: "bad"},
+		{Value: "good"},
+	}
+
+	plan, err := c.SyncRules(context.Background(), desired, false)
+	partialErr := &PartialError{}
+	if !errors.As(err, &partialErr) {
+		t.Fatalf("Client.SyncRules() error = %v, want PartialError", err)
+	}
+	if len(partialErr.Errors) != 1 || partialErr.Errors[0].Detail != "bad" {
+		t.Errorf("Client.SyncRules() partial errors = %v", partialErr.Errors)
+	}
+	if plan == nil || len(plan.Errors) != 1 || plan.Deleted != 1 || len(plan.Created) != 1 {
+		t.Fatalf("Client.SyncRules() = %+v", plan)
+	}
+	if want := []string{`"good"`}; !reflect.DeepEqual(server.values(), want) {
+		t.Errorf("Client.SyncRules() rules = %v, want %v", server.values(), want)
+	}
+}
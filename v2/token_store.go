@@ -0,0 +1,251 @@
+package twitter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	fileTokenStoreLockRetry = 50 * time.Millisecond
+	fileTokenStoreStaleLock = time.Minute
+)
+
+// OAuth2Token is the OAuth2 token that is kept in a token store
+type OAuth2Token struct {
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token,omitempty"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	Scope        string    `json:"scope,omitempty"`
+}
+
+// empty returns if there is no token, a revoked token is stored as an empty token
+func (t *OAuth2Token) empty() bool {
+	return t == nil || (len(t.AccessToken) == 0 && len(t.RefreshToken) == 0)
+}
+
+// TokenStore is used by the AuthorizerV2 to share the tokens between processes.
+//
+// Refresh tokens can only be used once, so the authorizer will lock the store before a refresh.  Once
+// locked, the authorizer will load the token and use it if another process has already refreshed it,
+// otherwise it will refresh and compare and swap the new token into the store.  When the tokens are
+// revoked, an empty token is swapped into the store and it is treated as no token.
+type TokenStore interface {
+	// Load will return the stored token, or nil if there is no token
+	Load(ctx context.Context) (*OAuth2Token, error)
+	// CompareAndSwap will store the token if the stored refresh token is still the old refresh token
+	CompareAndSwap(ctx context.Context, oldRefreshToken string, token *OAuth2Token) (bool, error)
+	// Lock will block until the refresh lock is acquired or the context is done
+	Lock(ctx context.Context) (unlock func(), err error)
+}
+
+// MemoryTokenStore is a token store for a single process
+type MemoryTokenStore struct {
+	mutex sync.Mutex
+	token *OAuth2Token
+	lock  chan struct{}
+}
+
+// NewMemoryTokenStore creates a memory token store with the initial token, which can be nil
+func NewMemoryTokenStore(token *OAuth2Token) *MemoryTokenStore {
+	return &MemoryTokenStore{
+		token: copyOAuth2Token(token),
+		lock:  make(chan struct{}, 1),
+	}
+}
+
+// Load will return the stored token
+func (m *MemoryTokenStore) Load(_ context.Context) (*OAuth2Token, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return copyOAuth2Token(m.token), nil
+}
+
+// CompareAndSwap will store the token if the stored refresh token is still the old refresh token
+func (m *MemoryTokenStore) CompareAndSwap(_ context.Context, oldRefreshToken string, token *OAuth2Token) (bool, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.token != nil && m.token.RefreshToken != oldRefreshToken {
+		return false, nil
+	}
+	m.token = copyOAuth2Token(token)
+	return true, nil
+}
+
+// Lock will acquire the refresh lock
+func (m *MemoryTokenStore) Lock(ctx context.Context) (func(), error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case m.lock <- struct{}{}:
+	}
+	var once sync.Once
+	return func() {
+		once.Do(func() { <-m.lock })
+	}, nil
+}
+
+// FileTokenStore is a token store that keeps the token in a JSON file, it can be shared by processes
+// on the same host.
+//
+// The refresh lock is a lock file next to the token file with the nonce of its owner, so it is only removed by
+// its owner.  A lock file older than StaleLock is assumed to be from a process that has died and is removed.
+type FileTokenStore struct {
+	// Path is the token file
+	Path string
+	// StaleLock is optional and defaults to one minute
+	StaleLock time.Duration
+	mutex     sync.Mutex
+}
+
+// NewFileTokenStore creates a file token store
+func NewFileTokenStore(path string) *FileTokenStore {
+	return &FileTokenStore{
+		Path: path,
+	}
+}
+
+// Load will read the token from the file
+func (f *FileTokenStore) Load(_ context.Context) (*OAuth2Token, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.read()
+}
+
+// CompareAndSwap will write the token to the file if the stored refresh token is still the old refresh token
+func (f *FileTokenStore) CompareAndSwap(_ context.Context, oldRefreshToken string, token *OAuth2Token) (bool, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	stored, err := f.read()
+	if err != nil {
+		return false, err
+	}
+	if stored != nil && stored.RefreshToken != oldRefreshToken {
+		return false, nil
+	}
+	if err := f.write(token); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Lock will create the lock file with an owner nonce, waiting while another process holds it
+func (f *FileTokenStore) Lock(ctx context.Context) (func(), error) {
+	lockPath := f.Path + ".lock"
+	stale := f.StaleLock
+	if stale <= 0 {
+		stale = fileTokenStoreStaleLock
+	}
+	nonce := oauth1Nonce()
+	for {
+		file, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+		if err == nil {
+			_, err = file.WriteString(nonce)
+			if closeErr := file.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				os.Remove(lockPath)
+				return nil, fmt.Errorf("token store lock: %w", err)
+			}
+			var once sync.Once
+			return func() {
+				once.Do(func() {
+					removeLock(lockPath, func(moved string) bool {
+						owner, err := os.ReadFile(moved)
+						return err == nil && string(owner) == nonce
+					})
+				})
+			}, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("token store lock: %w", err)
+		}
+		if info, err := os.Stat(lockPath); err == nil && time.Since(info.ModTime()) > stale {
+			removeLock(lockPath, func(moved string) bool {
+				info, err := os.Stat(moved)
+				return err == nil && time.Since(info.ModTime()) > stale
+			})
+			continue
+		}
+
+		timer := time.NewTimer(fileTokenStoreLockRetry)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// removeLock will remove the lock file if it is still the lock that is expected.  The lock is renamed away before
+// it is checked, so a lock that another process has created in the meantime is linked back instead of removed.
+func removeLock(lockPath string, expected func(moved string) bool) {
+	moved := lockPath + "." + oauth1Nonce()
+	if err := os.Rename(lockPath, moved); err != nil {
+		return
+	}
+	defer os.Remove(moved)
+	if !expected(moved) {
+		os.Link(moved, lockPath)
+	}
+}
+
+func (f *FileTokenStore) read() (*OAuth2Token, error) {
+	data, err := os.ReadFile(f.Path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return nil, nil
+	case err != nil:
+		return nil, fmt.Errorf("token store read: %w", err)
+	}
+	token := &OAuth2Token{}
+	if err := json.Unmarshal(data, token); err != nil {
+		return nil, fmt.Errorf("token store decode: %w", err)
+	}
+	return token, nil
+}
+
+// write will write the token atomically, so a reader never sees a partial token
+func (f *FileTokenStore) write(token *OAuth2Token) error {
+	data, err := json.Marshal(token)
+	if err != nil {
+		return fmt.Errorf("token store encode: %w", err)
+	}
+	if err := writeFileAtomic(f.Path, data); err != nil {
+		return fmt.Errorf("token store write: %w", err)
+	}
+	return nil
+}
+
+// writeFileAtomic will write the data to a temp file and rename it over the path
+func writeFileAtomic(path string, data []byte) error {
+	temp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+	if _, err := temp.Write(data); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(temp.Name(), path)
+}
+
+func copyOAuth2Token(token *OAuth2Token) *OAuth2Token {
+	if token == nil {
+		return nil
+	}
+	c := *token
+	return &c
+}
@@ -0,0 +1,217 @@
+package twitter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func tokenStoreTests(t *testing.T) map[string]func(token *OAuth2Token) TokenStore {
+	dir := t.TempDir()
+	count := 0
+	return map[string]func(token *OAuth2Token) TokenStore{
+		"memory": func(token *OAuth2Token) TokenStore {
+			return NewMemoryTokenStore(token)
+		},
+		"file": func(token *OAuth2Token) TokenStore {
+			count++
+			store := NewFileTokenStore(filepath.Join(dir, fmt.Sprintf("token%d.json", count)))
+			if token != nil {
+				if err := store.write(token); err != nil {
+					t.Fatalf("file token store write error %v", err)
+				}
+			}
+			return store
+		},
+	}
+}
+
+func TestTokenStore_CompareAndSwap(t *testing.T) {
+	for name, create := range tokenStoreTests(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			store := create(nil)
+			token, err := store.Load(ctx)
+			if err != nil || token != nil {
+				t.Fatalf("Load() empty = %v, %v", token, err)
+			}
+
+			first := &OAuth2Token{AccessToken: "access1", RefreshToken: "refresh1", ExpiresAt: time.Now().Add(time.Hour).Round(time.Second)}
+			if swapped, err := store.CompareAndSwap(ctx, "", first); err != nil || !swapped {
+				t.Fatalf("CompareAndSwap() empty = %v, %v", swapped, err)
+			}
+			second := &OAuth2Token{AccessToken: "access2", RefreshToken: "refresh2"}
+			if swapped, err := store.CompareAndSwap(ctx, "refresh1", second); err != nil || !swapped {
+				t.Fatalf("CompareAndSwap() = %v, %v", swapped, err)
+			}
+			stale := &OAuth2Token{AccessToken: "access3", RefreshToken: "refresh3"}
+			if swapped, err := store.CompareAndSwap(ctx, "refresh1", stale); err != nil || swapped {
+				t.Fatalf("CompareAndSwap() stale = %v, %v", swapped, err)
+			}
+			token, err = store.Load(ctx)
+			if err != nil || token.AccessToken != "access2" || token.RefreshToken != "refresh2" {
+				t.Errorf("Load() = %+v, %v", token, err)
+			}
+		})
+	}
+}
+
+func TestTokenStore_Lock(t *testing.T) {
+	for name, create := range tokenStoreTests(t) {
+		t.Run(name, func(t *testing.T) {
+			store := create(nil)
+			unlock, err := store.Lock(context.Background())
+			if err != nil {
+				t.Fatalf("Lock() error = %v", err)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+			if _, err := store.Lock(ctx); !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("Lock() held error = %v, want %v", err, context.DeadlineExceeded)
+			}
+
+			unlock()
+			unlock()
+			unlock, err = store.Lock(context.Background())
+			if err != nil {
+				t.Fatalf("Lock() after unlock error = %v", err)
+			}
+			unlock()
+		})
+	}
+}
+
+func TestFileTokenStore_StaleLock(t *testing.T) {
+	store := NewFileTokenStore(filepath.Join(t.TempDir(), "token.json"))
+	store.StaleLock = time.Second
+	lockPath := store.Path + ".lock"
+	if err := os.WriteFile(lockPath, []byte("1"), 0o600); err != nil {
+		t.Fatalf("write lock error %v", err)
+	}
+	old := time.Now().Add(-time.Minute)
+	if err := os.Chtimes(lockPath, old, old); err != nil {
+		t.Fatalf("chtimes error %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	unlock, err := store.Lock(ctx)
+	if err != nil {
+		t.Fatalf("Lock() stale error = %v", err)
+	}
+	unlock()
+}
+
+func TestFileTokenStore_TakenOverLock(t *testing.T) {
+	store := NewFileTokenStore(filepath.Join(t.TempDir(), "token.json"))
+	store.StaleLock = time.Second
+	lockPath := store.Path + ".lock"
+	staleUnlock, err := store.Lock(context.Background())
+	if err != nil {
+		t.Fatalf("Lock() error = %v", err)
+	}
+	old := time.Now().Add(-time.Minute)
+	if err := os.Chtimes(lockPath, old, old); err != nil {
+		t.Fatalf("chtimes error %v", err)
+	}
+	unlock, err := store.Lock(context.Background())
+	if err != nil {
+		t.Fatalf("Lock() stale error = %v", err)
+	}
+
+	// the unlock of the stale owner must leave the lock of the new owner
+	staleUnlock()
+	if _, err := os.Stat(lockPath); err != nil {
+		t.Fatalf("Lock() taken over lock removed by the stale owner %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := store.Lock(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Lock() held error = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	unlock()
+	if _, err := os.Stat(lockPath); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Lock() unlock left the lock %v", err)
+	}
+}
+
+func TestAuthorizerV2_TokenStore(t *testing.T) {
+	for name, create := range tokenStoreTests(t) {
+		t.Run(name, func(t *testing.T) {
+			var refreshes int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				r.ParseForm()
+				n := atomic.AddInt32(&refreshes, 1)
+				if r.PostForm.Get("refresh_token") != "refresh0" {
+					w.WriteHeader(http.StatusBadRequest)
+					fmt.Fprint(w, `{"error":"invalid_request","error_description":"Value passed for the token was invalid."}`)
+					return
+				}
+				json.NewEncoder(w).Encode(OAuth2TokenResponse{
+					AccessToken:  fmt.Sprintf("access%d", n),
+					RefreshToken: fmt.Sprintf("refresh%d", n),
+					TokenType:    "bearer",
+					ExpiresIn:    7200,
+					Scope:        "tweet.read offline.access",
+				})
+			}))
+			defer server.Close()
+
+			store := create(&OAuth2Token{
+				AccessToken:  "access0",
+				RefreshToken: "refresh0",
+				ExpiresAt:    time.Now().Add(-time.Minute),
+			})
+
+			// replicas sharing the store
+			auths := make([]*AuthorizerV2, 5)
+			for i := range auths {
+				auth, err := NewAuthorizerV2WithStore(context.Background(), store, "client_id", "client_secret", nil)
+				if err != nil {
+					t.Fatalf("NewAuthorizerV2WithStore() error = %v", err)
+				}
+				auth.tokenURL = server.URL
+				auths[i] = auth
+			}
+
+			wg := sync.WaitGroup{}
+			for _, auth := range auths {
+				wg.Add(1)
+				go func(auth *AuthorizerV2) {
+					defer wg.Done()
+					req, _ := http.NewRequest(http.MethodGet, "https://api.twitter.com/2/users/me", nil)
+					auth.Add(req)
+					if got := req.Header.Get("Authorization"); got != "Bearer access1" {
+						t.Errorf("AuthorizerV2.Add() = %v", got)
+					}
+				}(auth)
+			}
+			wg.Wait()
+
+			if refreshes != 1 {
+				t.Errorf("AuthorizerV2 refreshes = %d, want 1", refreshes)
+			}
+			token, err := store.Load(context.Background())
+			if err != nil || token.AccessToken != "access1" || token.RefreshToken != "refresh1" || token.Scope != "tweet.read offline.access" {
+				t.Errorf("TokenStore.Load() = %+v, %v", token, err)
+			}
+		})
+	}
+}
+
+func TestNewAuthorizerV2WithStore_Empty(t *testing.T) {
+	_, err := NewAuthorizerV2WithStore(context.Background(), NewMemoryTokenStore(nil), "client_id", "client_secret", nil)
+	if !errors.Is(err, ErrParameter) {
+		t.Errorf("NewAuthorizerV2WithStore() error = %v, want %v", err, ErrParameter)
+	}
+}
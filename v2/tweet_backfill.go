@@ -0,0 +1,613 @@
+package twitter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	tweetBackfillTweetsPerWindow = 10000
+	tweetBackfillRequestInterval = time.Second
+	tweetBackfillDedupSize       = 100000
+)
+
+// TweetBackfillWindow is a slice of the backfill time range and the progress of its search
+type TweetBackfillWindow struct {
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+	// Count is the number of tweets the counts endpoint reported for the window
+	Count int `json:"count"`
+	// NextToken is the token of the next page, empty if the window has not been started or is done
+	NextToken string `json:"next_token,omitempty"`
+	// LastIDs are the ids of the last page written, so the duplicates of the next page are dropped after a resume
+	LastIDs []string `json:"last_ids,omitempty"`
+	Done    bool     `json:"done"`
+}
+
+// TweetBackfillCheckpoint is the saved progress of a backfill job
+type TweetBackfillCheckpoint struct {
+	Query     string                 `json:"query"`
+	StartTime time.Time              `json:"start_time"`
+	EndTime   time.Time              `json:"end_time"`
+	Windows   []*TweetBackfillWindow `json:"windows"`
+}
+
+func (t *TweetBackfillCheckpoint) copy() *TweetBackfillCheckpoint {
+	if t == nil {
+		return nil
+	}
+	c := *t
+	c.Windows = make([]*TweetBackfillWindow, len(t.Windows))
+	for i, window := range t.Windows {
+		w := *window
+		w.LastIDs = append([]string(nil), window.LastIDs...)
+		c.Windows[i] = &w
+	}
+	return &c
+}
+
+// TweetBackfillStore keeps the checkpoint of a backfill job so an interrupted job can be resumed
+type TweetBackfillStore interface {
+	// Load will return the checkpoint of the job, or nil if the job has not been started
+	Load(ctx context.Context, job string) (*TweetBackfillCheckpoint, error)
+	// Save will store the checkpoint of the job
+	Save(ctx context.Context, job string, checkpoint *TweetBackfillCheckpoint) error
+}
+
+// MemoryTweetBackfillStore is a backfill store for a single process
+type MemoryTweetBackfillStore struct {
+	mutex       sync.Mutex
+	checkpoints map[string]*TweetBackfillCheckpoint
+}
+
+// NewMemoryTweetBackfillStore creates an empty memory backfill store
+func NewMemoryTweetBackfillStore() *MemoryTweetBackfillStore {
+	return &MemoryTweetBackfillStore{
+		checkpoints: map[string]*TweetBackfillCheckpoint{},
+	}
+}
+
+// Load will return the checkpoint of the job
+func (m *MemoryTweetBackfillStore) Load(_ context.Context, job string) (*TweetBackfillCheckpoint, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.checkpoints[job].copy(), nil
+}
+
+// Save will store the checkpoint of the job
+func (m *MemoryTweetBackfillStore) Save(_ context.Context, job string, checkpoint *TweetBackfillCheckpoint) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.checkpoints[job] = checkpoint.copy()
+	return nil
+}
+
+// FileTweetBackfillStore is a backfill store that keeps each job as a JSON file in a directory
+type FileTweetBackfillStore struct {
+	// Dir is the directory of the checkpoint files
+	Dir   string
+	mutex sync.Mutex
+}
+
+// NewFileTweetBackfillStore creates a file backfill store
+func NewFileTweetBackfillStore(dir string) *FileTweetBackfillStore {
+	return &FileTweetBackfillStore{
+		Dir: dir,
+	}
+}
+
+// storeFilePath returns the JSON file of the name in the directory, the name is limited to safe characters
+func storeFilePath(dir, name string) string {
+	return filepath.Join(dir, storeFileName(name)+".json")
+}
+
+// storeFileName replaces the characters of the name that are not safe in a file name, a replaced name has a hash of
+// the name appended so that it does not collide with the other names
+func storeFileName(name string) string {
+	safe := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+	if safe == name {
+		return name
+	}
+	hash := fnv.New32a()
+	hash.Write([]byte(name))
+	return fmt.Sprintf("%s-%08x", safe, hash.Sum32())
+}
+
+// Load will read the checkpoint of the job from its file
+func (f *FileTweetBackfillStore) Load(_ context.Context, job string) (*TweetBackfillCheckpoint, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	data, err := os.ReadFile(storeFilePath(f.Dir, job))
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return nil, nil
+	case err != nil:
+		return nil, fmt.Errorf("backfill store read: %w", err)
+	}
+	checkpoint := &TweetBackfillCheckpoint{}
+	if err := json.Unmarshal(data, checkpoint); err != nil {
+		return nil, fmt.Errorf("backfill store decode: %w", err)
+	}
+	return checkpoint, nil
+}
+
+// Save will write the checkpoint to a temp file and rename it, so a crash never leaves a partial checkpoint
+func (f *FileTweetBackfillStore) Save(_ context.Context, job string, checkpoint *TweetBackfillCheckpoint) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	data, err := json.Marshal(checkpoint)
+	if err != nil {
+		return fmt.Errorf("backfill store encode: %w", err)
+	}
+	if err := writeFileAtomic(storeFilePath(f.Dir, job), data); err != nil {
+		return fmt.Errorf("backfill store write: %w", err)
+	}
+	return nil
+}
+
+// TweetBackfillSink receives the pages of the backfill, the calls are never concurrent
+type TweetBackfillSink interface {
+	// WriteTweets is given a page with the tweets that have not already been written
+	WriteTweets(ctx context.Context, raw *TweetRaw) error
+}
+
+// TweetBackfillSinkFunc is a function that is a backfill sink
+type TweetBackfillSinkFunc func(ctx context.Context, raw *TweetRaw) error
+
+// WriteTweets will call the function
+func (f TweetBackfillSinkFunc) WriteTweets(ctx context.Context, raw *TweetRaw) error {
+	return f(ctx, raw)
+}
+
+// TweetBackfillOpts are the options of a full-archive backfill
+type TweetBackfillOpts struct {
+	// Job is the key of the checkpoint, defaults to the query
+	Job       string
+	StartTime time.Time
+	EndTime   time.Time
+	// Search are the fields and expansions of the search, the times and tokens are set by the backfill
+	Search TweetSearchOpts
+	// Granularity of the counts used to size the windows, defaults to day
+	Granularity Granularity
+	// TweetsPerWindow is the number of tweets the windows are sized to, defaults to 10000
+	TweetsPerWindow int
+	// Concurrency is the number of windows searched at a time, defaults to one
+	Concurrency int
+	// RequestInterval is the minimum time between the callouts, defaults to the one second full-archive limit
+	RequestInterval time.Duration
+	// DedupSize is the number of recent tweet ids used to drop the duplicates, defaults to 100000
+	DedupSize int
+	// Store keeps the checkpoints, defaults to a memory store which can not resume after the process exits
+	Store TweetBackfillStore
+}
+
+func (t TweetBackfillOpts) validate() error {
+	switch {
+	case t.StartTime.IsZero() || t.EndTime.IsZero():
+		return fmt.Errorf("tweet backfill: a start and end time are required: %w", ErrParameter)
+	case !t.StartTime.Before(t.EndTime):
+		return fmt.Errorf("tweet backfill: the start time [%v] is not before the end time [%v]: %w", t.StartTime, t.EndTime, ErrParameter)
+	case t.TweetsPerWindow < 0:
+		return fmt.Errorf("tweet backfill: tweets per window [%d] is negative: %w", t.TweetsPerWindow, ErrParameter)
+	case t.Concurrency < 0:
+		return fmt.Errorf("tweet backfill: concurrency [%d] is negative: %w", t.Concurrency, ErrParameter)
+	case t.RequestInterval < 0:
+		return fmt.Errorf("tweet backfill: request interval [%v] is negative: %w", t.RequestInterval, ErrParameter)
+	}
+	switch t.Granularity {
+	case "", GranularityMinute, GranularityHour, GranularityDay:
+	default:
+		return fmt.Errorf("tweet backfill: granularity [%s] is not supported: %w", t.Granularity, ErrParameter)
+	}
+	return nil
+}
+
+// TweetBackfillResponse is the result of a backfill run
+type TweetBackfillResponse struct {
+	Windows []*TweetBackfillWindow
+	// Resumed is true when the run continued from a saved checkpoint
+	Resumed bool
+	// Pages is the number of search pages fetched by this run
+	Pages int
+	// Tweets is the number of tweets written to the sink by this run
+	Tweets int
+	// Duplicates is the number of tweets that were dropped because they had already been written
+	Duplicates int
+}
+
+// Done returns if all of the windows have been searched
+func (t *TweetBackfillResponse) Done() bool {
+	for _, window := range t.Windows {
+		if !window.Done {
+			return false
+		}
+	}
+	return true
+}
+
+// TweetBackfill will search the full archive from the start to the end time and write the tweets to the sink.
+//
+// The time range is split into windows sized with the full-archive counts, and the windows are searched
+// with bounded concurrency while keeping to the full-archive request rate.  After each page, the window and
+// next token are saved to the store, so running the same job again resumes where it stopped.  The tweets that
+// have already been written are dropped by id, and the ids of the last page of each window are kept in the
+// checkpoint so the duplicates are also dropped after a resume.  The checkpoint is saved after the page is
+// written, so the sink should be idempotent for a page that was being written when the process stopped.
+func (c *Client) TweetBackfill(ctx context.Context, query string, opts TweetBackfillOpts, sink TweetBackfillSink) (*TweetBackfillResponse, error) {
+	switch {
+	case len(query) == 0:
+		return nil, fmt.Errorf("tweet backfill: a query is required: %w", ErrParameter)
+	case utf8.RuneCountInString(query) > tweetSearchQueryLength:
+		return nil, fmt.Errorf("tweet backfill: the query over the length (%d): %w", tweetSearchQueryLength, ErrParameter)
+	case sink == nil:
+		return nil, fmt.Errorf("tweet backfill: a sink is required: %w", ErrParameter)
+	default:
+	}
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+
+	b := &tweetBackfill{
+		client: c,
+		query:  query,
+		opts:   opts,
+		sink:   sink,
+		job:    opts.Job,
+		store:  opts.Store,
+		pacer:  &callPacer{interval: opts.RequestInterval},
+	}
+	if len(b.job) == 0 {
+		b.job = query
+	}
+	if b.store == nil {
+		b.store = NewMemoryTweetBackfillStore()
+	}
+	if opts.RequestInterval == 0 {
+		b.pacer.interval = tweetBackfillRequestInterval
+	}
+	dedupSize := opts.DedupSize
+	if dedupSize <= 0 {
+		dedupSize = tweetBackfillDedupSize
+	}
+	b.seen = newTweetIDSet(dedupSize)
+
+	resp, err := b.run(ctx)
+	if err != nil {
+		return resp, fmt.Errorf("tweet backfill: %w", err)
+	}
+	return resp, nil
+}
+
+type tweetBackfill struct {
+	client     *Client
+	query      string
+	opts       TweetBackfillOpts
+	sink       TweetBackfillSink
+	job        string
+	store      TweetBackfillStore
+	pacer      *callPacer
+	seen       *tweetIDSet
+	checkpoint *TweetBackfillCheckpoint
+	resp       *TweetBackfillResponse
+	mutex      sync.Mutex
+	sinkMutex  sync.Mutex
+}
+
+func (b *tweetBackfill) run(ctx context.Context) (*TweetBackfillResponse, error) {
+	checkpoint, err := b.store.Load(ctx, b.job)
+	if err != nil {
+		return nil, err
+	}
+	b.resp = &TweetBackfillResponse{}
+	switch {
+	case checkpoint == nil:
+		windows, err := b.partition(ctx)
+		if err != nil {
+			return nil, err
+		}
+		checkpoint = &TweetBackfillCheckpoint{
+			Query:     b.query,
+			StartTime: b.opts.StartTime,
+			EndTime:   b.opts.EndTime,
+			Windows:   windows,
+		}
+		if err := b.store.Save(ctx, b.job, checkpoint); err != nil {
+			return nil, err
+		}
+	case checkpoint.Query != b.query || !checkpoint.StartTime.Equal(b.opts.StartTime) || !checkpoint.EndTime.Equal(b.opts.EndTime):
+		return nil, fmt.Errorf("the checkpoint of job [%s] is for a different query or time range: %w", b.job, ErrParameter)
+	default:
+		b.resp.Resumed = true
+	}
+	b.checkpoint = checkpoint
+	b.resp.Windows = checkpoint.Windows
+
+	pending := make(chan *TweetBackfillWindow, len(checkpoint.Windows))
+	for _, window := range checkpoint.Windows {
+		if window.Done {
+			continue
+		}
+		for _, id := range window.LastIDs {
+			b.seen.add(id)
+		}
+		pending <- window
+	}
+	close(pending)
+
+	concurrency := b.opts.Concurrency
+	if concurrency == 0 {
+		concurrency = 1
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for window := range pending {
+				if err := b.search(ctx, window); err != nil {
+					errOnce.Do(func() {
+						firstErr = err
+						cancel()
+					})
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return b.resp, firstErr
+	}
+	return b.resp, nil
+}
+
+// allTweetCounts will fetch the counts of every page, wait is optional and called before each callout
+func (c *Client) allTweetCounts(ctx context.Context, query string, opts TweetAllCountsOpts, wait func(ctx context.Context) error) ([]*TweetCount, error) {
+	counts := []*TweetCount{}
+	for {
+		if wait != nil {
+			if err := wait(ctx); err != nil {
+				return nil, err
+			}
+		}
+		resp, err := c.TweetAllCounts(ctx, query, opts)
+		if err != nil {
+			return nil, err
+		}
+		counts = append(counts, resp.TweetCounts...)
+		if resp.Meta == nil || len(resp.Meta.NextToken) == 0 {
+			return counts, nil
+		}
+		opts.NextToken = resp.Meta.NextToken
+	}
+}
+
+// parseTweetCountTime will parse the start and end of a tweet count, which are RFC3339 with milliseconds
+func parseTweetCountTime(value string) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339, strings.ToUpper(value))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("tweet count time [%s]: %w", value, err)
+	}
+	return t.UTC(), nil
+}
+
+// partition will split the time range into windows of about the tweets per window using the counts
+func (b *tweetBackfill) partition(ctx context.Context) ([]*TweetBackfillWindow, error) {
+	granularity := b.opts.Granularity
+	if len(granularity) == 0 {
+		granularity = GranularityDay
+	}
+	perWindow := b.opts.TweetsPerWindow
+	if perWindow == 0 {
+		perWindow = tweetBackfillTweetsPerWindow
+	}
+
+	counts, err := b.client.allTweetCounts(ctx, b.query, TweetAllCountsOpts{
+		StartTime:   b.opts.StartTime,
+		EndTime:     b.opts.EndTime,
+		Granularity: granularity,
+	}, b.pacer.wait)
+	if err != nil {
+		return nil, err
+	}
+
+	windows := []*TweetBackfillWindow{}
+	var current *TweetBackfillWindow
+	for _, count := range counts {
+		start, err := parseTweetCountTime(count.Start)
+		if err != nil {
+			return nil, err
+		}
+		end, err := parseTweetCountTime(count.End)
+		if err != nil {
+			return nil, err
+		}
+		if start.Before(b.opts.StartTime) {
+			start = b.opts.StartTime
+		}
+		if end.After(b.opts.EndTime) {
+			end = b.opts.EndTime
+		}
+		if !start.Before(end) {
+			continue
+		}
+		if current != nil && current.Count > 0 && current.Count+count.TweetCount > perWindow {
+			windows = append(windows, current)
+			current = nil
+		}
+		if current == nil {
+			current = &TweetBackfillWindow{
+				StartTime: start,
+			}
+		}
+		current.EndTime = end
+		current.Count += count.TweetCount
+	}
+	if current != nil {
+		windows = append(windows, current)
+	}
+
+	// the counts may not cover the whole range, so the windows are stretched to the start and end
+	if len(windows) == 0 {
+		return []*TweetBackfillWindow{
+			{
+				StartTime: b.opts.StartTime,
+				EndTime:   b.opts.EndTime,
+			},
+		}, nil
+	}
+	windows[0].StartTime = b.opts.StartTime
+	for i := 1; i < len(windows); i++ {
+		windows[i].StartTime = windows[i-1].EndTime
+	}
+	windows[len(windows)-1].EndTime = b.opts.EndTime
+	return windows, nil
+}
+
+// search will fetch the pages of the window, saving the checkpoint after each page
+func (b *tweetBackfill) search(ctx context.Context, window *TweetBackfillWindow) error {
+	opts := b.opts.Search
+	opts.StartTime = window.StartTime
+	opts.EndTime = window.EndTime
+	opts.SinceID = ""
+	opts.UntilID = ""
+
+	b.mutex.Lock()
+	opts.NextToken = window.NextToken
+	b.mutex.Unlock()
+
+	for {
+		if err := b.pacer.wait(ctx); err != nil {
+			return err
+		}
+		resp, err := b.client.TweetSearch(ctx, b.query, opts)
+		if err != nil {
+			return err
+		}
+		nextToken := ""
+		if resp.Meta != nil {
+			nextToken = resp.Meta.NextToken
+		}
+
+		ids, err := b.write(ctx, resp.Raw)
+		if err != nil {
+			return err
+		}
+
+		b.mutex.Lock()
+		b.resp.Pages++
+		window.NextToken = nextToken
+		window.LastIDs = ids
+		window.Done = len(nextToken) == 0
+		if window.Done {
+			window.LastIDs = nil
+		}
+		err = b.store.Save(ctx, b.job, b.checkpoint)
+		b.mutex.Unlock()
+		if err != nil {
+			return err
+		}
+
+		if len(nextToken) == 0 {
+			return nil
+		}
+		opts.NextToken = nextToken
+	}
+}
+
+// write will send the tweets that have not been written to the sink and return the ids of the page
+func (b *tweetBackfill) write(ctx context.Context, raw *TweetRaw) ([]string, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	b.sinkMutex.Lock()
+	defer b.sinkMutex.Unlock()
+
+	ids := make([]string, 0, len(raw.Tweets))
+	tweets := make([]*TweetObj, 0, len(raw.Tweets))
+	duplicates := 0
+	for _, tweet := range raw.Tweets {
+		if tweet == nil {
+			continue
+		}
+		ids = append(ids, tweet.ID)
+		if !b.seen.add(tweet.ID) {
+			duplicates++
+			continue
+		}
+		tweets = append(tweets, tweet)
+	}
+
+	b.mutex.Lock()
+	b.resp.Duplicates += duplicates
+	b.mutex.Unlock()
+
+	if len(tweets) == 0 {
+		return ids, nil
+	}
+	page := &TweetRaw{
+		Tweets:   tweets,
+		Includes: raw.Includes,
+		Errors:   raw.Errors,
+	}
+	if err := b.sink.WriteTweets(ctx, page); err != nil {
+		return nil, fmt.Errorf("sink write: %w", err)
+	}
+
+	b.mutex.Lock()
+	b.resp.Tweets += len(tweets)
+	b.mutex.Unlock()
+	return ids, nil
+}
+
+// callPacer will space the callouts by the interval across goroutines
+type callPacer struct {
+	interval time.Duration
+	mutex    sync.Mutex
+	next     time.Time
+}
+
+// wait will block until the next callout is allowed or the context is done
+func (p *callPacer) wait(ctx context.Context) error {
+	p.mutex.Lock()
+	now := time.Now()
+	at := p.next
+	if at.Before(now) {
+		at = now
+	}
+	p.next = at.Add(p.interval)
+	p.mutex.Unlock()
+
+	if delay := time.Until(at); delay > 0 {
+		if !sleepContext(ctx, delay) {
+			return ctx.Err()
+		}
+	}
+	return ctx.Err()
+}
@@ -0,0 +1,195 @@
+package twitter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// mockBackfillServer serves three days of counts and two pages of search for each window
+type mockBackfillServer struct {
+	mutex    sync.Mutex
+	counts   int
+	searches []string
+}
+
+func (m *mockBackfillServer) handle(req *http.Request) *http.Response {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	q := req.URL.Query()
+	body := ""
+	switch {
+	case strings.HasSuffix(req.URL.Path, "counts/all"):
+		m.counts++
+		switch q.Get("next_token") {
+		case "":
+			body = `{"data":[{"start":"2021-06-01T00:00:00.000Z","end":"2021-06-02T00:00:00.000Z","tweet_count":6},{"start":"2021-06-02T00:00:00.000Z","end":"2021-06-03T00:00:00.000Z","tweet_count":3}],"meta":{"total_tweet_count":9,"next_token":"counts"}}`
+		default:
+			body = `{"data":[{"start":"2021-06-03T00:00:00.000Z","end":"2021-06-04T00:00:00.000Z","tweet_count":8}],"meta":{"total_tweet_count":8}}`
+		}
+	default:
+		start := q.Get("start_time")[:10]
+		token := q.Get("next_token")
+		m.searches = append(m.searches, start+"/"+token)
+		switch token {
+		case "":
+			body = fmt.Sprintf(`{"data":[{"id":"%s-1","text":"one"},{"id":"%s-2","text":"two"}],"meta":{"result_count":2,"next_token":"page2"}}`, start, start)
+		default:
+			body = fmt.Sprintf(`{"data":[{"id":"%s-2","text":"two"},{"id":"%s-3","text":"three"}],"meta":{"result_count":2}}`, start, start)
+		}
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Header:     http.Header{},
+		Request:    req,
+	}
+}
+
+func TestClient_TweetBackfill(t *testing.T) {
+	start := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2021, 6, 4, 0, 0, 0, 0, time.UTC)
+
+	t.Run("partition and dedup", func(t *testing.T) {
+		server := &mockBackfillServer{}
+		c := &Client{
+			Authorizer: &mockAuth{},
+			Host:       "https://www.test.com",
+			Client:     mockHTTPClient(server.handle),
+		}
+		got := []string{}
+		resp, err := c.TweetBackfill(context.Background(), "golang", TweetBackfillOpts{
+			StartTime:       start,
+			EndTime:         end,
+			TweetsPerWindow: 10,
+			Concurrency:     2,
+			RequestInterval: time.Millisecond,
+		}, TweetBackfillSinkFunc(func(_ context.Context, raw *TweetRaw) error {
+			for _, tweet := range raw.Tweets {
+				got = append(got, tweet.ID)
+			}
+			return nil
+		}))
+		if err != nil {
+			t.Fatalf("TweetBackfill() error = %v", err)
+		}
+		if len(resp.Windows) != 2 || !resp.Done() {
+			t.Fatalf("TweetBackfill() windows = %v, done %v", resp.Windows, resp.Done())
+		}
+		if !resp.Windows[0].EndTime.Equal(start.AddDate(0, 0, 2)) || resp.Windows[0].Count != 9 || resp.Windows[1].Count != 8 {
+			t.Errorf("TweetBackfill() windows = %+v, %+v", resp.Windows[0], resp.Windows[1])
+		}
+		if len(got) != 6 || resp.Tweets != 6 || resp.Duplicates != 2 || resp.Pages != 4 {
+			t.Errorf("TweetBackfill() tweets = %v, response %+v", got, resp)
+		}
+		if server.counts != 2 {
+			t.Errorf("TweetBackfill() counts callouts = %d, want 2", server.counts)
+		}
+	})
+
+	t.Run("resume", func(t *testing.T) {
+		server := &mockBackfillServer{}
+		c := &Client{
+			Authorizer: &mockAuth{},
+			Host:       "https://www.test.com",
+			Client:     mockHTTPClient(server.handle),
+		}
+		store := NewFileTweetBackfillStore(t.TempDir())
+		opts := TweetBackfillOpts{
+			Job:             "golang backfill",
+			StartTime:       start,
+			EndTime:         end,
+			TweetsPerWindow: 10,
+			RequestInterval: time.Millisecond,
+			Store:           store,
+		}
+		errSink := errors.New("sink down")
+		writes := 0
+		got := []string{}
+		sink := TweetBackfillSinkFunc(func(_ context.Context, raw *TweetRaw) error {
+			writes++
+			if writes == 2 {
+				return errSink
+			}
+			for _, tweet := range raw.Tweets {
+				got = append(got, tweet.ID)
+			}
+			return nil
+		})
+
+		resp, err := c.TweetBackfill(context.Background(), "golang", opts, sink)
+		if !errors.Is(err, errSink) {
+			t.Fatalf("TweetBackfill() error = %v, want %v", err, errSink)
+		}
+		if resp.Done() || resp.Windows[0].NextToken != "page2" || len(resp.Windows[0].LastIDs) != 2 {
+			t.Errorf("TweetBackfill() windows = %+v", resp.Windows[0])
+		}
+		if _, err := os.Stat(storeFilePath(store.Dir, "golang backfill")); err != nil {
+			t.Errorf("TweetBackfill() checkpoint file error = %v", err)
+		}
+
+		resp, err = c.TweetBackfill(context.Background(), "golang", opts, sink)
+		if err != nil {
+			t.Fatalf("TweetBackfill() resume error = %v", err)
+		}
+		if !resp.Resumed || !resp.Done() {
+			t.Errorf("TweetBackfill() resume = %+v", resp)
+		}
+		want := []string{"2021-06-01-1", "2021-06-01-2", "2021-06-01-3", "2021-06-03-1", "2021-06-03-2", "2021-06-03-3"}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("TweetBackfill() tweets = %v, want %v", got, want)
+		}
+		wantSearches := []string{"2021-06-01/", "2021-06-01/page2", "2021-06-01/page2", "2021-06-03/", "2021-06-03/page2"}
+		if !reflect.DeepEqual(server.searches, wantSearches) {
+			t.Errorf("TweetBackfill() searches = %v, want %v", server.searches, wantSearches)
+		}
+		if server.counts != 2 {
+			t.Errorf("TweetBackfill() counts callouts = %d, want 2", server.counts)
+		}
+
+		_, err = c.TweetBackfill(context.Background(), "rust", opts, sink)
+		if !errors.Is(err, ErrParameter) {
+			t.Errorf("TweetBackfill() different query error = %v", err)
+		}
+	})
+
+	t.Run("parameters", func(t *testing.T) {
+		c := &Client{}
+		sink := TweetBackfillSinkFunc(func(context.Context, *TweetRaw) error { return nil })
+		if _, err := c.TweetBackfill(context.Background(), "", TweetBackfillOpts{StartTime: start, EndTime: end}, sink); !errors.Is(err, ErrParameter) {
+			t.Errorf("TweetBackfill() no query error = %v", err)
+		}
+		if _, err := c.TweetBackfill(context.Background(), "golang", TweetBackfillOpts{StartTime: end, EndTime: start}, sink); !errors.Is(err, ErrParameter) {
+			t.Errorf("TweetBackfill() time range error = %v", err)
+		}
+		if _, err := c.TweetBackfill(context.Background(), "golang", TweetBackfillOpts{StartTime: start, EndTime: end}, nil); !errors.Is(err, ErrParameter) {
+			t.Errorf("TweetBackfill() no sink error = %v", err)
+		}
+	})
+}
+
+func TestStoreFileName(t *testing.T) {
+	names := map[string]string{}
+	for _, name := range []string{"from_a", "from:a", "from/a", "job-1.v2"} {
+		got := storeFileName(name)
+		if strings.ContainsAny(got, ":/") {
+			t.Errorf("storeFileName(%q) = %q is not safe", name, got)
+		}
+		if other, has := names[got]; has {
+			t.Errorf("storeFileName(%q) = %q, the same as %q", name, got, other)
+		}
+		names[got] = name
+	}
+	if got := storeFileName("job-1.v2"); got != "job-1.v2" {
+		t.Errorf("storeFileName() = %q, want the safe name as is", got)
+	}
+}
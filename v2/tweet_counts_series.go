@@ -0,0 +1,249 @@
+package twitter
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"time"
+)
+
+// TweetCountBucket is a bucket of a tweet count series
+type TweetCountBucket struct {
+	Start time.Time
+	End   time.Time
+	Count int
+}
+
+// TweetCountSeries is a continuous series of tweet counts at a granularity, the buckets without counts are zero
+type TweetCountSeries struct {
+	Granularity Granularity
+	Buckets     []TweetCountBucket
+}
+
+// TweetCountSpike is a bucket that is over the moving average of the buckets before it
+type TweetCountSpike struct {
+	TweetCountBucket
+	// Average is the average count of the buckets before the spike
+	Average float64
+	// Score is the number of standard deviations the count is over the average
+	Score float64
+}
+
+func granularityStep(granularity Granularity) (time.Duration, error) {
+	switch granularity {
+	case GranularityMinute:
+		return time.Minute, nil
+	case "", GranularityHour:
+		return time.Hour, nil
+	case GranularityDay:
+		return 24 * time.Hour, nil
+	default:
+		return 0, fmt.Errorf("granularity [%s] is not supported: %w", granularity, ErrParameter)
+	}
+}
+
+// NewTweetCountSeries will merge the counts into a continuous series at the granularity from the start to the
+// end time, filling the gaps with zeros.  When the start or end time is zero, the first or last count is used.
+func NewTweetCountSeries(counts []*TweetCount, granularity Granularity, start, end time.Time) (*TweetCountSeries, error) {
+	step, err := granularityStep(granularity)
+	if err != nil {
+		return nil, fmt.Errorf("tweet count series: %w", err)
+	}
+	if len(granularity) == 0 {
+		granularity = GranularityHour
+	}
+
+	firstStart := start.IsZero()
+	lastEnd := end.IsZero()
+	totals := map[time.Time]int{}
+	for _, count := range counts {
+		if count == nil {
+			continue
+		}
+		countStart, err := parseTweetCountTime(count.Start)
+		if err != nil {
+			return nil, fmt.Errorf("tweet count series: %w", err)
+		}
+		countEnd, err := parseTweetCountTime(count.End)
+		if err != nil {
+			return nil, fmt.Errorf("tweet count series: %w", err)
+		}
+		if firstStart && (start.IsZero() || countStart.Before(start)) {
+			start = countStart
+		}
+		if lastEnd && countEnd.After(end) {
+			end = countEnd
+		}
+		totals[countStart.Truncate(step)] += count.TweetCount
+	}
+
+	series := &TweetCountSeries{
+		Granularity: granularity,
+		Buckets:     []TweetCountBucket{},
+	}
+	if start.IsZero() || !start.Before(end) {
+		return series, nil
+	}
+	start = start.UTC()
+	end = end.UTC()
+	for slot := start.Truncate(step); slot.Before(end); slot = slot.Add(step) {
+		bucket := TweetCountBucket{
+			Start: slot,
+			End:   slot.Add(step),
+			Count: totals[slot],
+		}
+		if bucket.Start.Before(start) {
+			bucket.Start = start
+		}
+		if bucket.End.After(end) {
+			bucket.End = end
+		}
+		series.Buckets = append(series.Buckets, bucket)
+	}
+	return series, nil
+}
+
+// TweetRecentCountsSeries will return the recent counts as a continuous series
+func (c *Client) TweetRecentCountsSeries(ctx context.Context, query string, opts TweetRecentCountsOpts) (*TweetCountSeries, error) {
+	resp, err := c.TweetRecentCounts(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+	return NewTweetCountSeries(resp.TweetCounts, opts.Granularity, opts.StartTime, opts.EndTime)
+}
+
+// TweetAllCountsSeries will fetch all of the pages of the full-archive counts and return them as a continuous series
+func (c *Client) TweetAllCountsSeries(ctx context.Context, query string, opts TweetAllCountsOpts) (*TweetCountSeries, error) {
+	counts, err := c.allTweetCounts(ctx, query, opts, nil)
+	if err != nil {
+		return nil, err
+	}
+	return NewTweetCountSeries(counts, opts.Granularity, opts.StartTime, opts.EndTime)
+}
+
+// Rebucket will sum the buckets into a coarser granularity, minute to hour or day and hour to day
+func (t *TweetCountSeries) Rebucket(granularity Granularity) (*TweetCountSeries, error) {
+	from, err := granularityStep(t.Granularity)
+	if err != nil {
+		return nil, fmt.Errorf("tweet count series rebucket: %w", err)
+	}
+	to, err := granularityStep(granularity)
+	if err != nil {
+		return nil, fmt.Errorf("tweet count series rebucket: %w", err)
+	}
+	if to < from {
+		return nil, fmt.Errorf("tweet count series rebucket: granularity [%s] is finer than [%s]: %w", granularity, t.Granularity, ErrParameter)
+	}
+
+	series := &TweetCountSeries{
+		Granularity: granularity,
+		Buckets:     []TweetCountBucket{},
+	}
+	for _, bucket := range t.Buckets {
+		slot := bucket.Start.Truncate(to)
+		last := len(series.Buckets) - 1
+		if last >= 0 && series.Buckets[last].Start.Truncate(to).Equal(slot) {
+			series.Buckets[last].End = bucket.End
+			series.Buckets[last].Count += bucket.Count
+			continue
+		}
+		series.Buckets = append(series.Buckets, bucket)
+	}
+	return series, nil
+}
+
+// Total returns the sum of the counts
+func (t *TweetCountSeries) Total() int {
+	total := 0
+	for _, bucket := range t.Buckets {
+		total += bucket.Count
+	}
+	return total
+}
+
+// MovingAverage returns the average of each bucket and the window minus one buckets before it, the first
+// buckets are averaged over the buckets that are available
+func (t *TweetCountSeries) MovingAverage(window int) []float64 {
+	if window < 1 {
+		window = 1
+	}
+	averages := make([]float64, len(t.Buckets))
+	sum := 0
+	for i, bucket := range t.Buckets {
+		sum += bucket.Count
+		if i >= window {
+			sum -= t.Buckets[i-window].Count
+		}
+		size := window
+		if i+1 < window {
+			size = i + 1
+		}
+		averages[i] = float64(sum) / float64(size)
+	}
+	return averages
+}
+
+// Spikes returns the buckets whose count is more than threshold standard deviations over the average of the
+// window buckets before it.  The first window buckets are not scored.
+func (t *TweetCountSeries) Spikes(window int, threshold float64) []TweetCountSpike {
+	spikes := []TweetCountSpike{}
+	if window < 1 {
+		return spikes
+	}
+	for i := window; i < len(t.Buckets); i++ {
+		mean := 0.0
+		for _, bucket := range t.Buckets[i-window : i] {
+			mean += float64(bucket.Count)
+		}
+		mean /= float64(window)
+		variance := 0.0
+		for _, bucket := range t.Buckets[i-window : i] {
+			diff := float64(bucket.Count) - mean
+			variance += diff * diff
+		}
+		stddev := math.Sqrt(variance / float64(window))
+
+		count := float64(t.Buckets[i].Count)
+		if count <= mean {
+			continue
+		}
+		score := math.Inf(1)
+		if stddev > 0 {
+			score = (count - mean) / stddev
+		}
+		if score > threshold {
+			spikes = append(spikes, TweetCountSpike{
+				TweetCountBucket: t.Buckets[i],
+				Average:          mean,
+				Score:            score,
+			})
+		}
+	}
+	return spikes
+}
+
+// WriteCSV will write the series as start, end and tweet_count rows with a header, the times are RFC3339
+func (t *TweetCountSeries) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"start", "end", "tweet_count"}); err != nil {
+		return fmt.Errorf("tweet count series csv: %w", err)
+	}
+	for _, bucket := range t.Buckets {
+		record := []string{
+			bucket.Start.Format(time.RFC3339),
+			bucket.End.Format(time.RFC3339),
+			strconv.Itoa(bucket.Count),
+		}
+		if err := writer.Write(record); err != nil {
+			return fmt.Errorf("tweet count series csv: %w", err)
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("tweet count series csv: %w", err)
+	}
+	return nil
+}
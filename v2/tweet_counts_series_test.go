@@ -0,0 +1,160 @@
+package twitter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func tweetCountSeriesCounts(series *TweetCountSeries) []int {
+	counts := make([]int, len(series.Buckets))
+	for i, bucket := range series.Buckets {
+		counts[i] = bucket.Count
+	}
+	return counts
+}
+
+func TestNewTweetCountSeries(t *testing.T) {
+	counts := []*TweetCount{
+		{Start: "2021-05-26T23:30:00.000Z", End: "2021-05-27T00:00:00.000Z", TweetCount: 2},
+		{Start: "2021-05-27t01:00:00.000z", End: "2021-05-27t02:00:00.000z", TweetCount: 5},
+		{Start: "2021-05-27T03:00:00.000Z", End: "2021-05-27T03:15:00.000Z", TweetCount: 1},
+	}
+	start := time.Date(2021, 5, 26, 23, 30, 0, 0, time.UTC)
+	end := time.Date(2021, 5, 27, 3, 15, 0, 0, time.UTC)
+
+	series, err := NewTweetCountSeries(counts, GranularityHour, start, end)
+	if err != nil {
+		t.Fatalf("NewTweetCountSeries() error = %v", err)
+	}
+	if got, want := tweetCountSeriesCounts(series), []int{2, 0, 5, 0, 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("NewTweetCountSeries() counts = %v, want %v", got, want)
+	}
+	if !series.Buckets[0].Start.Equal(start) || !series.Buckets[4].End.Equal(end) {
+		t.Errorf("NewTweetCountSeries() range = %v - %v", series.Buckets[0].Start, series.Buckets[4].End)
+	}
+	if series.Total() != 8 {
+		t.Errorf("NewTweetCountSeries() total = %d, want 8", series.Total())
+	}
+
+	implicit, err := NewTweetCountSeries(counts, GranularityHour, time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatalf("NewTweetCountSeries() implicit error = %v", err)
+	}
+	if !reflect.DeepEqual(implicit.Buckets, series.Buckets) {
+		t.Errorf("NewTweetCountSeries() implicit = %v, want %v", implicit.Buckets, series.Buckets)
+	}
+
+	if _, err := NewTweetCountSeries(counts, Granularity("week"), start, end); !errors.Is(err, ErrParameter) {
+		t.Errorf("NewTweetCountSeries() granularity error = %v", err)
+	}
+}
+
+func TestTweetCountSeries_Rebucket(t *testing.T) {
+	start := time.Date(2021, 5, 26, 22, 58, 0, 0, time.UTC)
+	end := time.Date(2021, 5, 27, 0, 2, 0, 0, time.UTC)
+	counts := []*TweetCount{
+		{Start: "2021-05-26T22:58:00.000Z", End: "2021-05-26T22:59:00.000Z", TweetCount: 1},
+		{Start: "2021-05-26T22:59:00.000Z", End: "2021-05-26T23:00:00.000Z", TweetCount: 2},
+		{Start: "2021-05-26T23:30:00.000Z", End: "2021-05-26T23:31:00.000Z", TweetCount: 3},
+		{Start: "2021-05-27T00:01:00.000Z", End: "2021-05-27T00:02:00.000Z", TweetCount: 4},
+	}
+	minutes, err := NewTweetCountSeries(counts, GranularityMinute, start, end)
+	if err != nil {
+		t.Fatalf("NewTweetCountSeries() error = %v", err)
+	}
+	if len(minutes.Buckets) != 64 {
+		t.Fatalf("NewTweetCountSeries() buckets = %d, want 64", len(minutes.Buckets))
+	}
+
+	hours, err := minutes.Rebucket(GranularityHour)
+	if err != nil {
+		t.Fatalf("Rebucket() error = %v", err)
+	}
+	if got, want := tweetCountSeriesCounts(hours), []int{3, 3, 4}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Rebucket() hour counts = %v, want %v", got, want)
+	}
+	days, err := hours.Rebucket(GranularityDay)
+	if err != nil {
+		t.Fatalf("Rebucket() error = %v", err)
+	}
+	if got, want := tweetCountSeriesCounts(days), []int{6, 4}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Rebucket() day counts = %v, want %v", got, want)
+	}
+	if !days.Buckets[0].Start.Equal(start) || !days.Buckets[1].End.Equal(end) {
+		t.Errorf("Rebucket() day range = %v - %v", days.Buckets[0].Start, days.Buckets[1].End)
+	}
+	if _, err := days.Rebucket(GranularityMinute); !errors.Is(err, ErrParameter) {
+		t.Errorf("Rebucket() finer error = %v", err)
+	}
+}
+
+func TestTweetCountSeries_Stats(t *testing.T) {
+	start := time.Date(2021, 5, 26, 0, 0, 0, 0, time.UTC)
+	series := &TweetCountSeries{Granularity: GranularityHour}
+	for i, count := range []int{10, 12, 10, 12, 50, 11} {
+		series.Buckets = append(series.Buckets, TweetCountBucket{
+			Start: start.Add(time.Duration(i) * time.Hour),
+			End:   start.Add(time.Duration(i+1) * time.Hour),
+			Count: count,
+		})
+	}
+
+	if got, want := series.MovingAverage(2), []float64{10, 11, 11, 11, 31, 30.5}; !reflect.DeepEqual(got, want) {
+		t.Errorf("MovingAverage() = %v, want %v", got, want)
+	}
+
+	spikes := series.Spikes(4, 3)
+	if len(spikes) != 1 || spikes[0].Count != 50 || spikes[0].Average != 11 || spikes[0].Score != 39 {
+		t.Errorf("Spikes() = %+v", spikes)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := series.WriteCSV(buf); err != nil {
+		t.Fatalf("WriteCSV() error = %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 7 || lines[0] != "start,end,tweet_count" || lines[5] != "2021-05-26T04:00:00Z,2021-05-26T05:00:00Z,50" {
+		t.Errorf("WriteCSV() = %v", lines)
+	}
+}
+
+func TestClient_TweetAllCountsSeries(t *testing.T) {
+	tokens := []string{}
+	c := &Client{
+		Authorizer: &mockAuth{},
+		Host:       "https://www.test.com",
+		Client: mockHTTPClient(func(req *http.Request) *http.Response {
+			token := req.URL.Query().Get("next_token")
+			tokens = append(tokens, token)
+			body := `{"data":[{"start":"2021-05-26T00:00:00.000Z","end":"2021-05-27T00:00:00.000Z","tweet_count":4}],"meta":{"total_tweet_count":4,"next_token":"page2"}}`
+			if token == "page2" {
+				body = `{"data":[{"start":"2021-05-28T00:00:00.000Z","end":"2021-05-29T00:00:00.000Z","tweet_count":6}],"meta":{"total_tweet_count":6}}`
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(strings.NewReader(body)),
+				Header:     http.Header{},
+				Request:    req,
+			}
+		}),
+	}
+	series, err := c.TweetAllCountsSeries(context.Background(), "golang", TweetAllCountsOpts{
+		Granularity: GranularityDay,
+	})
+	if err != nil {
+		t.Fatalf("TweetAllCountsSeries() error = %v", err)
+	}
+	if got, want := tweetCountSeriesCounts(series), []int{4, 0, 6}; !reflect.DeepEqual(got, want) {
+		t.Errorf("TweetAllCountsSeries() counts = %v, want %v", got, want)
+	}
+	if !reflect.DeepEqual(tokens, []string{"", "page2"}) {
+		t.Errorf("TweetAllCountsSeries() tokens = %v", tokens)
+	}
+}
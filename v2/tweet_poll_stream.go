@@ -0,0 +1,308 @@
+package twitter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	tweetPollStreamMaxRequests = 180
+	tweetPollStreamRateWindow  = 15 * time.Minute
+	tweetPollStreamMaxResults  = 100
+)
+
+// TweetPollStreamOpts are the options of a filtered stream emulated by polling the recent search
+type TweetPollStreamOpts struct {
+	// Interval is the time between the polls of the rules, it is raised to keep within the max requests
+	Interval time.Duration
+	// MaxRequests is the number of recent search requests allowed in the 15 minute window, defaults to 180
+	MaxRequests int
+	// StartTime is when the first poll of a rule searches from, defaults to when the stream is started
+	StartTime time.Time
+	// Search are the fields and expansions of the search, the times, ids and tokens are set by the stream
+	Search TweetRecentSearchOpts
+}
+
+func (t TweetPollStreamOpts) validate() error {
+	switch {
+	case t.Interval < 0:
+		return fmt.Errorf("tweet poll stream: interval [%v] is negative: %w", t.Interval, ErrParameter)
+	case t.MaxRequests < 0:
+		return fmt.Errorf("tweet poll stream: max requests [%d] is negative: %w", t.MaxRequests, ErrParameter)
+	default:
+		return nil
+	}
+}
+
+// interval returns the poll interval that keeps the requests of the rules within the max requests, each poll is one
+// request of each rule
+func (t TweetPollStreamOpts) interval(rules int) time.Duration {
+	maxRequests := t.MaxRequests
+	if maxRequests == 0 {
+		maxRequests = tweetPollStreamMaxRequests
+	}
+	minimum := tweetPollStreamRateWindow * time.Duration(rules) / time.Duration(maxRequests)
+	if t.Interval < minimum {
+		return minimum
+	}
+	return t.Interval
+}
+
+// TweetPollStreamRuleID returns the id of a rule in a poll stream, which is stable for the value and tag
+func TweetPollStreamRuleID(rule TweetSearchStreamRule) TweetSearchStreamRuleID {
+	hash := fnv.New64a()
+	hash.Write([]byte(tweetSearchStreamRuleKey(rule)))
+	return TweetSearchStreamRuleID(strconv.FormatUint(hash.Sum64(), 10))
+}
+
+// TweetPollStream will emulate the filtered stream, for access levels without it, by polling the recent search
+// with each rule.
+//
+// Each rule is searched from its last since id at the interval, which is raised to keep the requests of all of
+// the rules within the max requests.  A poll is one page of each rule, when a search has more pages the next page is
+// fetched at the next poll and the since id moves to the newest tweet once the last page has been fetched.  The
+// tweets of a poll are delivered oldest first, once for all of the rules that matched them, with the matching rules
+// set as the filtered stream would.  A tweet that a rule matches in a later poll is delivered again with only the
+// rules that had not matched it.  The rule ids are from TweetPollStreamRuleID.  Keep alives are sent between the
+// polls, so a poll that hangs is reported as a stall.  The errors that can be retried are sent to Err and the page
+// of the rule is fetched again at the next interval, other errors end the stream.
+func (c *Client) TweetPollStream(ctx context.Context, rules []TweetSearchStreamRule, opts TweetPollStreamOpts) (*TweetStream, error) {
+	switch {
+	case len(rules) == 0:
+		return nil, fmt.Errorf("tweet poll stream: rules are required: %w", ErrParameter)
+	default:
+	}
+	if err := tweetSearchStreamRules(rules).validate(); err != nil {
+		return nil, fmt.Errorf("tweet poll stream: %w", err)
+	}
+	for _, rule := range rules {
+		if utf8.RuneCountInString(rule.Value) > tweetRecentSearchQueryLength {
+			return nil, fmt.Errorf("tweet poll stream: the rule [%s] over the length (%d): %w", rule.Value, tweetRecentSearchQueryLength, ErrParameter)
+		}
+	}
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	if err := c.StreamOpts.validate(); err != nil {
+		return nil, fmt.Errorf("tweet poll stream: %w", err)
+	}
+
+	poller := &tweetPoller{
+		client:    c,
+		opts:      opts,
+		interval:  opts.interval(len(rules)),
+		keepAlive: c.StreamOpts.keepAlive() / 2,
+		rules:     make([]*tweetPollRule, 0, len(rules)),
+		seen:      newTweetIDSet(managedStreamDedupSize * len(rules)),
+	}
+	startTime := opts.StartTime
+	if startTime.IsZero() {
+		startTime = time.Now()
+	}
+	for _, rule := range rules {
+		poller.rules = append(poller.rules, &tweetPollRule{
+			entity: &TweetSearchStreamRuleEntity{
+				ID:                    TweetPollStreamRuleID(rule),
+				TweetSearchStreamRule: rule,
+			},
+			startTime: startTime,
+		})
+	}
+
+	return startTweetStream(ctx, c.StreamOpts, nil, poller.start)
+}
+
+type tweetPollRule struct {
+	entity    *TweetSearchStreamRuleEntity
+	startTime time.Time
+	sinceID   string
+	// nextToken is the next page of the search and newestID is the newest tweet of its pages
+	nextToken string
+	newestID  string
+}
+
+type tweetPoller struct {
+	client    *Client
+	opts      TweetPollStreamOpts
+	interval  time.Duration
+	keepAlive time.Duration
+	rules     []*tweetPollRule
+	seen      *tweetIDSet
+}
+
+// tweetPollMatch is encoded as a filtered stream message, without the errors key that is a disconnect message
+type tweetPollMatch struct {
+	Tweet         *TweetObj                      `json:"data"`
+	Includes      *TweetRawIncludes              `json:"includes,omitempty"`
+	MatchingRules []*TweetSearchStreamRuleEntity `json:"matching_rules"`
+}
+
+func (p *tweetPoller) start(ctx context.Context) streamSource {
+	lines := make(chan []byte)
+	errs := make(chan error)
+	readErr := new(error)
+	go func() {
+		defer close(lines)
+		*readErr = p.run(ctx, lines, errs)
+	}()
+	return streamSource{
+		lines:   lines,
+		readErr: readErr,
+		errs:    errs,
+	}
+}
+
+// run will poll the rules until the context is done or there is an error that can not be retried
+func (p *tweetPoller) run(ctx context.Context, lines chan<- []byte, errs chan<- error) error {
+	for {
+		matches, err := p.poll(ctx, errs)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		for _, match := range matches {
+			// the pages of a rule are fetched over many polls, so a tweet can be seen again with another rule and is
+			// sent again with only the rules that have not matched it yet
+			rules := make([]*TweetSearchStreamRuleEntity, 0, len(match.MatchingRules))
+			for _, rule := range match.MatchingRules {
+				if p.seen.add(match.Tweet.ID + "/" + string(rule.ID)) {
+					rules = append(rules, rule)
+				}
+			}
+			if len(rules) == 0 {
+				continue
+			}
+			match.MatchingRules = rules
+			msg, err := json.Marshal(match)
+			if err != nil {
+				return fmt.Errorf("tweet poll stream encode: %w", err)
+			}
+			select {
+			case lines <- msg:
+			case <-ctx.Done():
+				return nil
+			}
+		}
+		if !p.wait(ctx, lines) {
+			return nil
+		}
+	}
+}
+
+// wait will send keep alives until the next poll, returns false if the context is done
+func (p *tweetPoller) wait(ctx context.Context, lines chan<- []byte) bool {
+	next := time.NewTimer(p.interval)
+	defer next.Stop()
+	keepAlive := time.NewTicker(p.keepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-next.C:
+			return true
+		case <-keepAlive.C:
+			select {
+			case lines <- []byte{}:
+			case <-ctx.Done():
+				return false
+			}
+		}
+	}
+}
+
+// poll will search each rule since its last tweet and merge the tweets of the rules
+func (p *tweetPoller) poll(ctx context.Context, errs chan<- error) ([]*tweetPollMatch, error) {
+	merged := map[string]*tweetPollMatch{}
+	for _, rule := range p.rules {
+		err := p.search(ctx, rule, merged)
+		if err == nil {
+			continue
+		}
+		if _, retry := streamErrorClass(err); !retry {
+			return nil, err
+		}
+		select {
+		case errs <- fmt.Errorf("tweet poll stream rule [%s]: %w", rule.entity.ID, err):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	matches := make([]*tweetPollMatch, 0, len(merged))
+	for _, match := range merged {
+		matches = append(matches, match)
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		return tweetIDLess(matches[i].Tweet.ID, matches[j].Tweet.ID)
+	})
+	return matches, nil
+}
+
+// search will fetch the next page of the rule since its last tweet, the since id is moved to the newest tweet of the
+// search after its last page
+func (p *tweetPoller) search(ctx context.Context, rule *tweetPollRule, merged map[string]*tweetPollMatch) error {
+	opts := p.opts.Search
+	opts.StartTime = time.Time{}
+	opts.EndTime = time.Time{}
+	opts.UntilID = ""
+	opts.SortOrder = ""
+	opts.SinceID = rule.sinceID
+	opts.NextToken = rule.nextToken
+	if len(rule.sinceID) == 0 {
+		opts.StartTime = rule.startTime
+	}
+	if opts.MaxResults == 0 {
+		opts.MaxResults = tweetPollStreamMaxResults
+	}
+
+	resp, err := p.client.TweetRecentSearch(ctx, rule.entity.Value, opts)
+	if err != nil {
+		return err
+	}
+	if resp.Raw != nil {
+		for _, tweet := range resp.Raw.Tweets {
+			if tweet == nil {
+				continue
+			}
+			if tweetIDLess(rule.newestID, tweet.ID) {
+				rule.newestID = tweet.ID
+			}
+			match, has := merged[tweet.ID]
+			if !has {
+				match = &tweetPollMatch{
+					Tweet:    tweet,
+					Includes: resp.Raw.Includes,
+				}
+				merged[tweet.ID] = match
+			}
+			match.MatchingRules = append(match.MatchingRules, rule.entity)
+		}
+	}
+	if resp.Meta != nil && len(resp.Meta.NextToken) > 0 {
+		rule.nextToken = resp.Meta.NextToken
+		return nil
+	}
+	rule.nextToken = ""
+	if len(rule.newestID) > 0 {
+		rule.sinceID = rule.newestID
+		rule.newestID = ""
+	}
+	return nil
+}
+
+// tweetIDLess compares the numeric tweet ids, a shorter id is older
+func tweetIDLess(a, b string) bool {
+	if len(a) != len(b) {
+		return len(a) < len(b)
+	}
+	return a < b
+}
@@ -0,0 +1,265 @@
+package twitter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestClient_TweetPollStream(t *testing.T) {
+	rules := []TweetSearchStreamRule{
+		{Value: "golang", Tag: "go"},
+		{Value: "rust", Tag: "rust"},
+	}
+	var mutex sync.Mutex
+	polls := map[string][]string{}
+	c := &Client{
+		Authorizer: &mockAuth{},
+		Host:       "https://www.test.com",
+		Client: mockHTTPClient(func(req *http.Request) *http.Response {
+			mutex.Lock()
+			defer mutex.Unlock()
+			q := req.URL.Query()
+			query := q.Get("query")
+			sinceID := q.Get("since_id")
+			if sinceID == "" && q.Get("start_time") == "" {
+				panic("the first poll is missing the start time")
+			}
+			polls[query] = append(polls[query], sinceID)
+
+			resp := &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{},
+				Request:    req,
+			}
+			body := `{"meta":{"result_count":0}}`
+			switch {
+			case query == "golang" && sinceID == "":
+				body = `{"data":[{"id":"12","text":"golang and rust"},{"id":"10","text":"golang"}],"meta":{"newest_id":"12","result_count":2}}`
+			case query == "rust" && sinceID == "":
+				body = `{"data":[{"id":"12","text":"golang and rust"},{"id":"9","text":"rust"}],"meta":{"newest_id":"12","result_count":2}}`
+			case query == "rust" && len(polls[query]) == 2:
+				resp.StatusCode = http.StatusServiceUnavailable
+				body = `{"title":"Service Unavailable"}`
+			case query == "rust" && sinceID == "12":
+				body = `{"data":[{"id":"100","text":"rust"}],"meta":{"newest_id":"100","result_count":1}}`
+			}
+			resp.Status = http.StatusText(resp.StatusCode)
+			resp.Body = io.NopCloser(strings.NewReader(body))
+			return resp
+		}),
+	}
+
+	stream, err := c.TweetPollStream(context.Background(), rules, TweetPollStreamOpts{
+		MaxRequests: 1000000,
+		Interval:    5 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("TweetPollStream() error = %v", err)
+	}
+	defer stream.Close()
+
+	got := []string{}
+	errs := []error{}
+	timer := time.NewTimer(5 * time.Second)
+	defer timer.Stop()
+	for len(got) < 4 {
+		select {
+		case msg := <-stream.Tweets():
+			tags := []string{}
+			for _, rule := range msg.Raw.MatchingRules {
+				if rule.ID != TweetPollStreamRuleID(rule.TweetSearchStreamRule) {
+					t.Errorf("TweetPollStream() rule id = %s", rule.ID)
+				}
+				tags = append(tags, rule.Tag)
+			}
+			got = append(got, fmt.Sprintf("%s:%s", msg.Raw.Tweets[0].ID, strings.Join(tags, ",")))
+		case err := <-stream.Err():
+			errs = append(errs, err)
+		case <-timer.C:
+			t.Fatalf("TweetPollStream() timeout with %v", got)
+		}
+	}
+
+	want := []string{"9:rust", "10:go", "12:go,rust", "100:rust"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("TweetPollStream() tweets = %v, want %v", got, want)
+	}
+	errResp := &ErrorResponse{}
+	if len(errs) != 1 || !errors.As(errs[0], &errResp) || errResp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("TweetPollStream() errors = %v", errs)
+	}
+	mutex.Lock()
+	if polls["golang"][1] != "12" {
+		t.Errorf("TweetPollStream() golang since ids = %v", polls["golang"])
+	}
+	mutex.Unlock()
+}
+
+func TestClient_TweetPollStream_Pages(t *testing.T) {
+	var mutex sync.Mutex
+	requests := []string{}
+	c := &Client{
+		Authorizer: &mockAuth{},
+		Host:       "https://www.test.com",
+		Client: mockHTTPClient(func(req *http.Request) *http.Response {
+			mutex.Lock()
+			defer mutex.Unlock()
+			q := req.URL.Query()
+			sinceID, token := q.Get("since_id"), q.Get("next_token")
+			if sinceID == "" && q.Get("start_time") == "" {
+				panic("the next page is missing the start time")
+			}
+			requests = append(requests, sinceID+"/"+token)
+
+			body := `{"meta":{"result_count":0}}`
+			switch {
+			case sinceID == "" && token == "":
+				body = `{"data":[{"id":"20","text":"golang"},{"id":"19","text":"golang"}],"meta":{"result_count":2,"next_token":"page2"}}`
+			case sinceID == "" && token == "page2":
+				body = `{"data":[{"id":"18","text":"golang"}],"meta":{"result_count":1}}`
+			case sinceID == "20" && token == "":
+				body = `{"data":[{"id":"21","text":"golang"}],"meta":{"result_count":1}}`
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{},
+				Request:    req,
+				Body:       io.NopCloser(strings.NewReader(body)),
+			}
+		}),
+	}
+
+	stream, err := c.TweetPollStream(context.Background(), []TweetSearchStreamRule{{Value: "golang"}}, TweetPollStreamOpts{
+		MaxRequests: 1000000,
+		Interval:    5 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("TweetPollStream() error = %v", err)
+	}
+	defer stream.Close()
+
+	got := []string{}
+	timer := time.NewTimer(5 * time.Second)
+	defer timer.Stop()
+	for len(got) < 4 {
+		select {
+		case msg := <-stream.Tweets():
+			got = append(got, msg.Raw.Tweets[0].ID)
+		case <-timer.C:
+			t.Fatalf("TweetPollStream() timeout with %v", got)
+		}
+	}
+	stream.Close()
+
+	if want := []string{"19", "20", "18", "21"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("TweetPollStream() tweets = %v, want %v", got, want)
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+	if want := []string{"/", "/page2", "20/"}; !reflect.DeepEqual(requests[:3], want) {
+		t.Errorf("TweetPollStream() requests = %v, want one page a poll %v", requests, want)
+	}
+}
+
+func TestClient_TweetPollStream_LaterRule(t *testing.T) {
+	rules := []TweetSearchStreamRule{
+		{Value: "golang", Tag: "go"},
+		{Value: "gopher", Tag: "gopher"},
+	}
+	c := &Client{
+		Authorizer: &mockAuth{},
+		Host:       "https://www.test.com",
+		Client: mockHTTPClient(func(req *http.Request) *http.Response {
+			q := req.URL.Query()
+			query, sinceID, token := q.Get("query"), q.Get("since_id"), q.Get("next_token")
+			body := `{"meta":{"result_count":0}}`
+			switch {
+			case query == "golang" && sinceID == "":
+				body = `{"data":[{"id":"10","text":"golang gopher"}],"meta":{"result_count":1}}`
+			case query == "gopher" && sinceID == "" && token == "":
+				body = `{"data":[{"id":"11","text":"gopher"}],"meta":{"result_count":1,"next_token":"page2"}}`
+			case query == "gopher" && token == "page2":
+				body = `{"data":[{"id":"10","text":"golang gopher"}],"meta":{"result_count":1}}`
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{},
+				Request:    req,
+				Body:       io.NopCloser(strings.NewReader(body)),
+			}
+		}),
+	}
+
+	stream, err := c.TweetPollStream(context.Background(), rules, TweetPollStreamOpts{
+		MaxRequests: 1000000,
+		Interval:    5 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("TweetPollStream() error = %v", err)
+	}
+	defer stream.Close()
+
+	got := []string{}
+	timer := time.NewTimer(5 * time.Second)
+	defer timer.Stop()
+	for len(got) < 3 {
+		select {
+		case msg := <-stream.Tweets():
+			tags := []string{}
+			for _, rule := range msg.Raw.MatchingRules {
+				tags = append(tags, rule.Tag)
+			}
+			got = append(got, fmt.Sprintf("%s:%s", msg.Raw.Tweets[0].ID, strings.Join(tags, ",")))
+		case <-timer.C:
+			t.Fatalf("TweetPollStream() timeout with %v", got)
+		}
+	}
+
+	if want := []string{"10:go", "11:gopher", "10:gopher"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("TweetPollStream() tweets = %v, want %v", got, want)
+	}
+}
+
+func TestClient_TweetPollStream_Fatal(t *testing.T) {
+	c := &Client{
+		Authorizer: &mockAuth{},
+		Host:       "https://www.test.com",
+		Client: mockHTTPClient(func(req *http.Request) *http.Response {
+			return &http.Response{
+				StatusCode: http.StatusUnauthorized,
+				Status:     http.StatusText(http.StatusUnauthorized),
+				Body:       io.NopCloser(strings.NewReader(`{"title":"Unauthorized"}`)),
+				Header:     http.Header{},
+				Request:    req,
+			}
+		}),
+	}
+	if _, err := c.TweetPollStream(context.Background(), nil, TweetPollStreamOpts{}); !errors.Is(err, ErrParameter) {
+		t.Errorf("TweetPollStream() no rules error = %v", err)
+	}
+
+	stream, err := c.TweetPollStream(context.Background(), []TweetSearchStreamRule{{Value: "golang"}}, TweetPollStreamOpts{})
+	if err != nil {
+		t.Fatalf("TweetPollStream() error = %v", err)
+	}
+	var last error
+	_, _, _, errs := tweetStreamReceive(t, stream)
+	for _, err := range errs {
+		last = err
+	}
+	errResp := &ErrorResponse{}
+	if !errors.As(last, &errResp) || errResp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("TweetPollStream() error = %v", last)
+	}
+	if stream.Connection() {
+		t.Errorf("TweetPollStream() connection is alive")
+	}
+}
@@ -80,6 +80,9 @@ func (t *TweetRaw) TweetDictionaries() map[string]*TweetDictionary {
 
 	t.dictionaries = map[string]*TweetDictionary{}
 	for _, tweet := range t.Tweets {
+		if tweet == nil {
+			continue
+		}
 		t.dictionaries[tweet.ID] = CreateTweetDictionary(*tweet, t.Includes)
 	}
 	return t.dictionaries
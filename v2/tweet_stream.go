@@ -3,6 +3,7 @@ package twitter
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
@@ -11,6 +12,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -33,12 +35,17 @@ const (
 	tweetStart  = "data"
 	keepAliveTO = 21 * time.Second
 
+	streamScanBufferSize = 64 * 1024
+	streamMaxMessageSize = 16 * 1024 * 1024
+
 	// TweetErrorType represents the tweet stream errors
 	TweetErrorType StreamErrorType = "tweet"
 	// SystemErrorType represents the system stream errors
 	SystemErrorType StreamErrorType = "system"
 	// DisconnectErrorType represents the disconnection errors
 	DisconnectErrorType StreamErrorType = "disconnect"
+	// StallErrorType represents a stream that has not received a message or keep alive within the keep alive timeout
+	StallErrorType StreamErrorType = "stall"
 
 	disconnectionErrorsKey = "errors"
 	disconnectionTitleKey  = "title"
@@ -212,6 +219,8 @@ func (d disconnection) toConnection() *Connection {
 // TweetMessage is the tweet stream message
 type TweetMessage struct {
 	Raw *TweetRaw
+	// Compliance is the event of a compliance stream message, the raw is nil
+	Compliance *ComplianceEvent
 }
 
 // SystemMessage is the system stream message
@@ -220,122 +229,467 @@ type SystemMessage struct {
 	Sent    time.Time `json:"sent"`
 }
 
-// TweetStream is the stream handler
+// TweetStream is the stream handler.
+//
+// The stream is read by its own goroutine and the messages are delivered to the channels.  When the stream
+// ends, fails or the context is done, the Done channel is closed and the channels are left open with any
+// buffered messages.  The channels are closed by Close.  A stall error is sent when no message or keep alive
+// has been received within the keep alive timeout.
 type TweetStream struct {
 	tweets        chan *TweetMessage
 	system        chan map[SystemMessageType]SystemMessage
 	disconnection chan *DisconnectionError
-	close         chan bool
 	err           chan error
+	cancel        context.CancelFunc
+	finished      chan struct{}
 	alive         bool
+	lastBeat      time.Time
+	done          chan struct{}
+	doneErr       error
+	closeOnce     sync.Once
+	outlets       streamOutlets
 	mutex         sync.RWMutex
 	RateLimit     *RateLimit
 }
 
+type streamOutlets struct {
+	tweets        *streamOutlet
+	system        *streamOutlet
+	disconnection *streamOutlet
+	err           *streamOutlet
+}
+
 // StartTweetStream will start the tweet streaming
 func StartTweetStream(stream io.ReadCloser) *TweetStream {
+	ts, _ := StartTweetStreamWithOpts(context.Background(), stream, StreamOpts{})
+	return ts
+}
+
+// StartTweetStreamWithOpts will start the tweet streaming with the buffering, overflow and keep alive options.  The
+// stream is stopped when the context is done.
+func StartTweetStreamWithOpts(ctx context.Context, stream io.ReadCloser, opts StreamOpts) (*TweetStream, error) {
+	if err := opts.validate(); err != nil {
+		stream.Close()
+		return nil, fmt.Errorf("start tweet stream: %w", err)
+	}
+	return startTweetStream(ctx, opts, stream, func(ctx context.Context) streamSource {
+		lines, readErr := readStream(ctx, stream)
+		return streamSource{
+			lines:   lines,
+			readErr: readErr,
+		}
+	})
+}
+
+// streamSource is where the stream messages are read from
+type streamSource struct {
+	// lines are the messages, the channel is closed when the source has ended
+	lines <-chan []byte
+	// readErr is set before the lines channel is closed
+	readErr *error
+	// errs is optional and are errors that are sent to the consumer without ending the stream
+	errs <-chan error
+}
+
+// startTweetStream will start the stream handler, the source is started with the context of the stream and the
+// closer, which is optional, is closed when the stream stops to unblock the source
+func startTweetStream(ctx context.Context, opts StreamOpts, closer io.Closer, source func(ctx context.Context) streamSource) (*TweetStream, error) {
+	size := opts.bufferSize()
+	ctx, cancel := context.WithCancel(ctx)
 	ts := &TweetStream{
-		tweets:        make(chan *TweetMessage, 10),
-		system:        make(chan map[SystemMessageType]SystemMessage, 10),
-		disconnection: make(chan *DisconnectionError, 10),
-		close:         make(chan bool),
-		err:           make(chan error),
+		tweets:        make(chan *TweetMessage, size),
+		system:        make(chan map[SystemMessageType]SystemMessage, size),
+		disconnection: make(chan *DisconnectionError, size),
+		err:           make(chan error, size),
+		cancel:        cancel,
+		finished:      make(chan struct{}),
+		done:          make(chan struct{}),
 		mutex:         sync.RWMutex{},
 		alive:         true,
+		lastBeat:      time.Now(),
+	}
+	if err := ts.startOutlets(opts); err != nil {
+		cancel()
+		if closer != nil {
+			closer.Close()
+		}
+		return nil, fmt.Errorf("start tweet stream: %w", err)
+	}
+
+	go ts.handle(ctx, source(ctx), closer, opts.keepAlive())
+
+	return ts, nil
+}
+
+func (ts *TweetStream) startOutlets(opts StreamOpts) error {
+	policy := opts.policy()
+	ts.outlets = streamOutlets{
+		tweets: newTweetMessageOutlet(ts.tweets, policy),
+		system: &streamOutlet{
+			policy: policy,
+			offer: func(msg interface{}) bool {
+				select {
+				case ts.system <- msg.(map[SystemMessageType]SystemMessage):
+					return true
+				default:
+					return false
+				}
+			},
+			wait: func(msg interface{}, stop <-chan struct{}) bool {
+				select {
+				case ts.system <- msg.(map[SystemMessageType]SystemMessage):
+					return true
+				case <-stop:
+					return false
+				}
+			},
+			evict: func() bool {
+				select {
+				case <-ts.system:
+					return true
+				default:
+					return false
+				}
+			},
+		},
+		disconnection: &streamOutlet{
+			policy: policy,
+			offer: func(msg interface{}) bool {
+				select {
+				case ts.disconnection <- msg.(*DisconnectionError):
+					return true
+				default:
+					return false
+				}
+			},
+			wait: func(msg interface{}, stop <-chan struct{}) bool {
+				select {
+				case ts.disconnection <- msg.(*DisconnectionError):
+					return true
+				case <-stop:
+					return false
+				}
+			},
+			evict: func() bool {
+				select {
+				case <-ts.disconnection:
+					return true
+				default:
+					return false
+				}
+			},
+		},
+		err: &streamOutlet{
+			policy: policy,
+			offer: func(msg interface{}) bool {
+				select {
+				case ts.err <- msg.(error):
+					return true
+				default:
+					return false
+				}
+			},
+			wait: func(msg interface{}, stop <-chan struct{}) bool {
+				select {
+				case ts.err <- msg.(error):
+					return true
+				case <-stop:
+					return false
+				}
+			},
+			evict: func() bool {
+				select {
+				case <-ts.err:
+					return true
+				default:
+					return false
+				}
+			},
+		},
+	}
+	if policy != OverflowSpill {
+		return nil
+	}
+
+	// the errors are not spilled, they block the reader the same as the other messages
+	ts.outlets.err.policy = OverflowBlock
+
+	var err error
+	if ts.outlets.tweets.spill, err = newTweetMessageSpill(opts.SpillDir, "tweets"); err != nil {
+		return err
+	}
+	if ts.outlets.system.spill, err = newStreamSpill(opts.SpillDir, "system", func(decoder *json.Decoder) (interface{}, error) {
+		msg := map[SystemMessageType]SystemMessage{}
+		err := decoder.Decode(&msg)
+		return msg, err
+	}); err != nil {
+		ts.outlets.tweets.spill.stop()
+		return err
+	}
+	if ts.outlets.disconnection.spill, err = newStreamSpill(opts.SpillDir, "disconnection", func(decoder *json.Decoder) (interface{}, error) {
+		msg := &DisconnectionError{}
+		err := decoder.Decode(msg)
+		return msg, err
+	}); err != nil {
+		ts.outlets.tweets.spill.stop()
+		ts.outlets.system.spill.stop()
+		return err
+	}
+	return nil
+}
+
+// newTweetMessageOutlet creates the outlet of a tweet message channel
+func newTweetMessageOutlet(tweets chan *TweetMessage, policy StreamOverflowPolicy) *streamOutlet {
+	return &streamOutlet{
+		policy: policy,
+		offer: func(msg interface{}) bool {
+			select {
+			case tweets <- msg.(*TweetMessage):
+				return true
+			default:
+				return false
+			}
+		},
+		wait: func(msg interface{}, stop <-chan struct{}) bool {
+			select {
+			case tweets <- msg.(*TweetMessage):
+				return true
+			case <-stop:
+				return false
+			}
+		},
+		evict: func() bool {
+			select {
+			case <-tweets:
+				return true
+			default:
+				return false
+			}
+		},
 	}
+}
 
-	go ts.handle(stream)
+func newTweetMessageSpill(dir, name string) (*streamSpill, error) {
+	return newStreamSpill(dir, name, func(decoder *json.Decoder) (interface{}, error) {
+		msg := &TweetMessage{}
+		err := decoder.Decode(msg)
+		return msg, err
+	})
+}
 
-	return ts
+func (o streamOutlets) all() []*streamOutlet {
+	return []*streamOutlet{o.tweets, o.system, o.disconnection, o.err}
+}
+
+// send will deliver the message with the overflow policy, returns false if the stream was stopped while blocked
+func (ts *TweetStream) send(ctx context.Context, outlet *streamOutlet, msg interface{}) bool {
+	return outlet.send(msg, ctx.Done())
+}
+
+// Dropped returns the number of messages of each type dropped by the overflow policy
+func (ts *TweetStream) Dropped() StreamDrops {
+	return StreamDrops{
+		Tweets:         ts.outlets.tweets.drops(),
+		SystemMessages: ts.outlets.system.drops(),
+		Disconnections: ts.outlets.disconnection.drops(),
+		Errors:         ts.outlets.err.drops(),
+	}
 }
 
-func (ts *TweetStream) heartbeat(beat bool) {
+func (ts *TweetStream) heartbeat() {
 	ts.mutex.Lock()
 	defer ts.mutex.Unlock()
-	ts.alive = beat
+	ts.lastBeat = time.Now()
+}
+
+// lastHeartbeat returns when the last message or keep alive was received
+func (ts *TweetStream) lastHeartbeat() time.Time {
+	ts.mutex.RLock()
+	defer ts.mutex.RUnlock()
+	return ts.lastBeat
+}
+
+// end will mark that the stream has stopped reading with the cause, io.EOF if the stream ended
+func (ts *TweetStream) end(err error) {
+	if err == nil {
+		err = io.EOF
+	}
+	ts.mutex.Lock()
+	ts.alive = false
+	ts.doneErr = err
+	ts.mutex.Unlock()
+	close(ts.done)
+}
+
+// endErr returns why the stream stopped reading
+func (ts *TweetStream) endErr() error {
+	ts.mutex.RLock()
+	defer ts.mutex.RUnlock()
+	return ts.doneErr
 }
 
-// Connection returns if the connect is still alive
+// Connection returns if the connection is still being read, a stall is only reported with Err
 func (ts *TweetStream) Connection() bool {
 	ts.mutex.RLock()
 	defer ts.mutex.RUnlock()
 	return ts.alive
 }
 
-func (ts *TweetStream) handle(stream io.ReadCloser) {
-	defer stream.Close()
-	defer close(ts.tweets)
-	defer close(ts.system)
-	defer close(ts.close)
-	defer close(ts.err)
+func (ts *TweetStream) handle(ctx context.Context, source streamSource, closer io.Closer, keepAlive time.Duration) {
+	lines, readErr, errs := source.lines, source.readErr, source.errs
 
-	scanner := bufio.NewScanner(stream)
-	scanner.Split(streamSeparator)
-	timer := time.NewTimer(keepAliveTO)
-	for {
-		select {
-		case <-ts.close:
-			return
-		case <-timer.C:
-			ts.heartbeat(false)
-		default:
+	defer close(ts.finished)
+	// the reader is joined once it has been unblocked by closing the stream
+	defer func() {
+		for range lines {
 		}
+	}()
+	if closer != nil {
+		defer closer.Close()
+	}
+	defer ts.cancel()
 
-		if !scanner.Scan() {
+	pumps := sync.WaitGroup{}
+	for _, outlet := range ts.outlets.all() {
+		if outlet.spill == nil {
 			continue
 		}
-
-		timer.Stop()
-		timer.Reset(keepAliveTO)
-		ts.heartbeat(true)
-
-		msg := scanner.Bytes()
-
-		if len(msg) == 0 {
-			continue
+		pumps.Add(1)
+		go func(outlet *streamOutlet) {
+			defer pumps.Done()
+			outlet.spill.pump(outlet, ctx.Done())
+		}(outlet)
+	}
+	defer func() {
+		ts.cancel()
+		for _, outlet := range ts.outlets.all() {
+			if outlet.spill != nil {
+				atomic.AddUint64(&outlet.dropped, uint64(outlet.spill.stop()))
+			}
 		}
+		pumps.Wait()
+	}()
 
-		reader, err := normalizeStream(msg)
-		if err != nil {
-			select {
-			case ts.err <- fmt.Errorf("stream error: normalize error %w", err):
-			default:
+	watchdog := time.NewTimer(keepAlive)
+	defer watchdog.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			ts.end(ctx.Err())
+			return
+		case <-watchdog.C:
+			sErr := &StreamError{
+				Type: StallErrorType,
+				Msg:  fmt.Sprintf("no message or keep alive for %v", keepAlive),
+				Err:  ErrStreamStall,
 			}
-			continue
-		}
+			if !ts.send(ctx, ts.outlets.err, sErr) {
+				ts.end(ctx.Err())
+				return
+			}
+		case err := <-errs:
+			if !ts.send(ctx, ts.outlets.err, err) {
+				ts.end(ctx.Err())
+				return
+			}
+		case msg, ok := <-lines:
+			if !ok {
+				ts.finish(ctx, *readErr)
+				return
+			}
+			if !watchdog.Stop() {
+				select {
+				case <-watchdog.C:
+				default:
+				}
+			}
+			watchdog.Reset(keepAlive)
+			ts.heartbeat()
 
-		sType, err := decodeStreamType(reader)
-		if err != nil {
-			select {
-			case ts.err <- fmt.Errorf("stream error: unmarshal error %w", err):
-			default:
+			if !ts.dispatch(ctx, msg) {
+				ts.end(ctx.Err())
+				return
 			}
-			continue
 		}
-		if _, err := reader.Seek(0, io.SeekStart); err != nil {
+	}
+}
+
+// readStream will read the stream messages until the stream ends or the context is done.  The read error is set
+// before the messages channel is closed.
+func readStream(ctx context.Context, stream io.Reader) (<-chan []byte, *error) {
+	lines := make(chan []byte)
+	readErr := new(error)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(stream)
+		scanner.Buffer(make([]byte, streamScanBufferSize), streamMaxMessageSize)
+		scanner.Split(streamSeparator)
+		for scanner.Scan() {
+			msg := make([]byte, len(scanner.Bytes()))
+			copy(msg, scanner.Bytes())
 			select {
-			case ts.err <- fmt.Errorf("stream error: seek error %w", err):
-			default:
+			case lines <- msg:
+			case <-ctx.Done():
+				return
 			}
+		}
+		*readErr = scanner.Err()
+	}()
+	return lines, readErr
+}
+
+// finish will deliver the spilled messages and any read error after the stream has ended
+func (ts *TweetStream) finish(ctx context.Context, readErr error) {
+	for _, outlet := range ts.outlets.all() {
+		if outlet.spill == nil {
 			continue
 		}
-		decoder := json.NewDecoder(reader)
-
-		switch sType {
-		case tweetStream:
-			ts.handleTweet(decoder)
-		case systemMsgStream:
-			ts.handleSystemMessage(decoder)
-		case disconnectionErrs:
-			ts.handleDisconnectErrors(decoder)
-		case disconnectionErr:
-			ts.handleDisconnectError(decoder)
-		default:
+		select {
+		case <-outlet.spill.drained():
+		case <-ctx.Done():
 		}
 	}
+	if readErr != nil {
+		ts.send(ctx, ts.outlets.err, fmt.Errorf("stream error: read error %w", readErr))
+	}
+	ts.end(readErr)
+}
+
+// dispatch will decode the message and deliver it, returns false if the stream was stopped while blocked
+func (ts *TweetStream) dispatch(ctx context.Context, msg []byte) bool {
+	if len(msg) == 0 {
+		return true
+	}
+
+	reader, err := normalizeStream(msg)
+	if err != nil {
+		return ts.send(ctx, ts.outlets.err, fmt.Errorf("stream error: normalize error %w", err))
+	}
+
+	sType, err := decodeStreamType(reader)
+	if err != nil {
+		return ts.send(ctx, ts.outlets.err, fmt.Errorf("stream error: unmarshal error %w", err))
+	}
+	if _, err := reader.Seek(0, io.SeekStart); err != nil {
+		return ts.send(ctx, ts.outlets.err, fmt.Errorf("stream error: seek error %w", err))
+	}
+	decoder := json.NewDecoder(reader)
+
+	switch sType {
+	case tweetStream:
+		return ts.handleTweet(ctx, reader, decoder)
+	case systemMsgStream:
+		return ts.handleSystemMessage(ctx, decoder)
+	case disconnectionErrs:
+		return ts.handleDisconnectErrors(ctx, decoder)
+	case disconnectionErr:
+		return ts.handleDisconnectError(ctx, decoder)
+	default:
+		return true
+	}
 }
 
-func (ts *TweetStream) handleTweet(decoder *json.Decoder) {
+func (ts *TweetStream) handleTweet(ctx context.Context, reader *bytes.Reader, decoder *json.Decoder) bool {
 	single := &tweetraw{}
 	if err := decoder.Decode(single); err != nil {
 		sErr := &StreamError{
@@ -343,11 +697,15 @@ func (ts *TweetStream) handleTweet(decoder *json.Decoder) {
 			Msg:  "unmarshal tweet stream",
 			Err:  err,
 		}
-		select {
-		case ts.err <- sErr:
-		default:
+		return ts.send(ctx, ts.outlets.err, sErr)
+	}
+	// the data of a compliance stream message is an event, which does not have a tweet id
+	if single.Tweet == nil || len(single.Tweet.ID) == 0 {
+		if event := decodeComplianceEvent(reader); event != nil {
+			return ts.send(ctx, ts.outlets.tweets, &TweetMessage{
+				Compliance: event,
+			})
 		}
-		return
 	}
 	raw := &TweetRaw{}
 	raw.Tweets = make([]*TweetObj, 1)
@@ -360,13 +718,10 @@ func (ts *TweetStream) handleTweet(decoder *json.Decoder) {
 		Raw: raw,
 	}
 
-	select {
-	case ts.tweets <- tweetMsg:
-	default:
-	}
+	return ts.send(ctx, ts.outlets.tweets, tweetMsg)
 }
 
-func (ts *TweetStream) handleSystemMessage(decoder *json.Decoder) {
+func (ts *TweetStream) handleSystemMessage(ctx context.Context, decoder *json.Decoder) bool {
 	sysMsg := map[SystemMessageType]SystemMessage{}
 	if err := decoder.Decode(&sysMsg); err != nil {
 		sErr := &StreamError{
@@ -374,20 +729,12 @@ func (ts *TweetStream) handleSystemMessage(decoder *json.Decoder) {
 			Msg:  "unmarshal system stream",
 			Err:  err,
 		}
-		select {
-		case ts.err <- sErr:
-		default:
-		}
-		return
-	}
-	select {
-	case ts.system <- sysMsg:
-	default:
+		return ts.send(ctx, ts.outlets.err, sErr)
 	}
-
+	return ts.send(ctx, ts.outlets.system, sysMsg)
 }
 
-func (ts *TweetStream) handleDisconnectErrors(decoder *json.Decoder) {
+func (ts *TweetStream) handleDisconnectErrors(ctx context.Context, decoder *json.Decoder) bool {
 	disErrs := struct {
 		Errors []disconnection `json:"errors"`
 	}{}
@@ -397,11 +744,7 @@ func (ts *TweetStream) handleDisconnectErrors(decoder *json.Decoder) {
 			Msg:  "unmarshal disconnect stream",
 			Err:  err,
 		}
-		select {
-		case ts.err <- sErr:
-		default:
-		}
-		return
+		return ts.send(ctx, ts.outlets.err, sErr)
 	}
 
 	ds := &DisconnectionError{
@@ -417,13 +760,10 @@ func (ts *TweetStream) handleDisconnectErrors(decoder *json.Decoder) {
 		}
 	}
 
-	select {
-	case ts.disconnection <- ds:
-	default:
-	}
+	return ts.send(ctx, ts.outlets.disconnection, ds)
 }
 
-func (ts *TweetStream) handleDisconnectError(decoder *json.Decoder) {
+func (ts *TweetStream) handleDisconnectError(ctx context.Context, decoder *json.Decoder) bool {
 	d := disconnection{}
 	if err := decoder.Decode(&d); err != nil {
 		sErr := &StreamError{
@@ -431,11 +771,7 @@ func (ts *TweetStream) handleDisconnectError(decoder *json.Decoder) {
 			Msg:  "unmarshal disconnect stream",
 			Err:  err,
 		}
-		select {
-		case ts.err <- sErr:
-		default:
-		}
-		return
+		return ts.send(ctx, ts.outlets.err, sErr)
 	}
 
 	ds := &DisconnectionError{
@@ -449,11 +785,7 @@ func (ts *TweetStream) handleDisconnectError(decoder *json.Decoder) {
 		ds.Connections = append(ds.Connections, d.toConnection())
 	}
 
-	select {
-	case ts.disconnection <- ds:
-	default:
-	}
-
+	return ts.send(ctx, ts.outlets.disconnection, ds)
 }
 
 // Tweets will return the channel to receive tweet stream messages
@@ -476,9 +808,23 @@ func (ts *TweetStream) Err() <-chan error {
 	return ts.err
 }
 
-// Close will close the stream and all channels
+// Done will return a channel that is closed when the stream has stopped reading, the messages that are still
+// buffered can be received from the channels
+func (ts *TweetStream) Done() <-chan struct{} {
+	return ts.finished
+}
+
+// Close will stop reading the stream and return once the stream and all channels are closed, it is safe to
+// call more than once
 func (ts *TweetStream) Close() {
-	ts.close <- true
+	ts.cancel()
+	<-ts.finished
+	ts.closeOnce.Do(func() {
+		close(ts.tweets)
+		close(ts.system)
+		close(ts.disconnection)
+		close(ts.err)
+	})
 }
 
 func streamSeparator(data []byte, atEOF bool) (int, []byte, error) {
@@ -1,6 +1,7 @@
 package twitter
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -576,3 +577,165 @@ func TestStreamError_Wrap(t *testing.T) {
 	}
 
 }
+
+func TestStartTweetStreamWithOpts_End(t *testing.T) {
+	errRead := errors.New("connection reset")
+	tests := []struct {
+		name       string
+		stream     io.Reader
+		wantTweets int
+		wantErr    error
+		wantEnd    error
+	}{
+		{
+			name:       "end of stream",
+			stream:     strings.NewReader(`{"data":{"id":"1","text":"hello"}}` + "\r\n" + `{"data":{"id":"2","text":"world"}}`),
+			wantTweets: 2,
+			wantEnd:    io.EOF,
+		},
+		{
+			name:       "read error",
+			stream:     io.MultiReader(strings.NewReader(`{"data":{"id":"1","text":"hello"}}`+"\r\n"), &errorReader{err: errRead}),
+			wantTweets: 1,
+			wantErr:    errRead,
+			wantEnd:    errRead,
+		},
+		{
+			name:       "large tweet",
+			stream:     strings.NewReader(`{"data":{"id":"1","text":"` + strings.Repeat("a", 1024*1024) + `"}}` + "\r\n"),
+			wantTweets: 1,
+			wantEnd:    io.EOF,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stream, err := StartTweetStreamWithOpts(context.Background(), io.NopCloser(tt.stream), StreamOpts{})
+			if err != nil {
+				t.Fatalf("StartTweetStreamWithOpts() error = %v", err)
+			}
+			defer stream.Close()
+
+			msgs, _, _, errs := tweetStreamReceive(t, stream)
+			tweets := len(msgs)
+			var gotErr error
+			for _, err := range errs {
+				gotErr = err
+			}
+			if tweets != tt.wantTweets {
+				t.Errorf("StartTweetStreamWithOpts() tweets = %d, want %d", tweets, tt.wantTweets)
+			}
+			if (tt.wantErr == nil && gotErr != nil) || !errors.Is(gotErr, tt.wantErr) {
+				t.Errorf("StartTweetStreamWithOpts() error = %v, want %v", gotErr, tt.wantErr)
+			}
+			if !errors.Is(stream.endErr(), tt.wantEnd) {
+				t.Errorf("StartTweetStreamWithOpts() end = %v, want %v", stream.endErr(), tt.wantEnd)
+			}
+			if stream.Connection() {
+				t.Errorf("StartTweetStreamWithOpts() connection is alive after the end")
+			}
+
+			// the channels are only closed by close
+			select {
+			case _, ok := <-stream.Tweets():
+				t.Errorf("StartTweetStreamWithOpts() tweets channel received after the end, open %v", ok)
+			default:
+			}
+			stream.Close()
+			if _, ok := <-stream.Tweets(); ok {
+				t.Errorf("StartTweetStreamWithOpts() tweets channel is open after close")
+			}
+		})
+	}
+}
+
+func TestStartTweetStreamWithOpts_Stall(t *testing.T) {
+	reader, writer := io.Pipe()
+	stream, err := StartTweetStreamWithOpts(context.Background(), reader, StreamOpts{KeepAlive: 50 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("StartTweetStreamWithOpts() error = %v", err)
+	}
+	defer stream.Close()
+
+	select {
+	case err := <-stream.Err():
+		if !errors.Is(err, ErrStreamStall) || !errors.Is(err, &StreamError{Type: StallErrorType}) {
+			t.Errorf("StartTweetStreamWithOpts() stall error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("StartTweetStreamWithOpts() no stall error")
+	}
+	if !stream.Connection() {
+		t.Errorf("StartTweetStreamWithOpts() connection is not alive after the stall")
+	}
+
+	if _, err := writer.Write([]byte("\r\n")); err != nil {
+		t.Fatalf("keep alive write error = %v", err)
+	}
+	if _, err := writer.Write([]byte(`{"data":{"id":"1","text":"hello"}}` + "\r\n")); err != nil {
+		t.Fatalf("tweet write error = %v", err)
+	}
+	if msg := <-stream.Tweets(); msg == nil || msg.Raw.Tweets[0].ID != "1" {
+		t.Errorf("StartTweetStreamWithOpts() tweet = %v", msg)
+	}
+	if !stream.Connection() {
+		t.Errorf("StartTweetStreamWithOpts() connection is not alive after the keep alive")
+	}
+}
+
+func TestStartTweetStreamWithOpts_Stop(t *testing.T) {
+	tests := []struct {
+		name    string
+		stop    func(stream *TweetStream, cancel context.CancelFunc)
+		wantEnd error
+	}{
+		{
+			name: "close",
+			stop: func(stream *TweetStream, cancel context.CancelFunc) {
+				stream.Close()
+				stream.Close()
+			},
+			wantEnd: context.Canceled,
+		},
+		{
+			name: "context",
+			stop: func(stream *TweetStream, cancel context.CancelFunc) {
+				cancel()
+			},
+			wantEnd: context.Canceled,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			reader, writer := io.Pipe()
+			stream, err := StartTweetStreamWithOpts(ctx, reader, StreamOpts{})
+			if err != nil {
+				t.Fatalf("StartTweetStreamWithOpts() error = %v", err)
+			}
+
+			tt.stop(stream, cancel)
+
+			select {
+			case <-stream.Done():
+			case <-time.After(5 * time.Second):
+				t.Fatalf("StartTweetStreamWithOpts() did not stop")
+			}
+			stream.Close()
+			if _, err := writer.Write([]byte("\r\n")); !errors.Is(err, io.ErrClosedPipe) {
+				t.Errorf("StartTweetStreamWithOpts() stream was not closed, write error = %v", err)
+			}
+			if !errors.Is(stream.endErr(), tt.wantEnd) {
+				t.Errorf("StartTweetStreamWithOpts() end = %v, want %v", stream.endErr(), tt.wantEnd)
+			}
+		})
+	}
+}
+
+type errorReader struct {
+	err error
+}
+
+func (e *errorReader) Read(p []byte) (int, error) {
+	return 0, e.err
+}
@@ -0,0 +1,268 @@
+package twitter
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	tweetFirehoseStreamMaxPartition = 20
+	tweetSample10StreamMaxPartition = 2
+)
+
+// TweetVolumeStreamOpts are the options of the partitioned firehose and 10% sample streams
+type TweetVolumeStreamOpts struct {
+	// Partition is the partition of the stream, from 1 to 20 for the firehose and 1 to 2 for the sample, and is required
+	Partition       int
+	BackfillMinutes int
+	StartTime       time.Time
+	EndTime         time.Time
+	Expansions      []Expansion
+	MediaFields     []MediaField
+	PlaceFields     []PlaceField
+	PollFields      []PollField
+	TweetFields     []TweetField
+	UserFields      []UserField
+}
+
+func (t TweetVolumeStreamOpts) validate(name string, maxPartition int) error {
+	switch {
+	case t.Partition < 1 || t.Partition > maxPartition:
+		return fmt.Errorf("%s: partition [%d] is not between 1 and %d: %w", name, t.Partition, maxPartition, ErrParameter)
+	case t.BackfillMinutes < 0 || t.BackfillMinutes > sampleStreamMaxBackOffMin:
+		return fmt.Errorf("%s: a max back off minutes [%d] is [current: %d]: %w", name, sampleStreamMaxBackOffMin, t.BackfillMinutes, ErrParameter)
+	default:
+		return nil
+	}
+}
+
+func (t TweetVolumeStreamOpts) addQuery(req *http.Request) {
+	TweetSampleStreamOpts{
+		BackfillMinutes: t.BackfillMinutes,
+		Expansions:      t.Expansions,
+		MediaFields:     t.MediaFields,
+		PlaceFields:     t.PlaceFields,
+		PollFields:      t.PollFields,
+		TweetFields:     t.TweetFields,
+		UserFields:      t.UserFields,
+	}.addQuery(req)
+	q := req.URL.Query()
+	q.Add("partition", strconv.Itoa(t.Partition))
+	if !t.StartTime.IsZero() {
+		q.Add("start_time", t.StartTime.Format(time.RFC3339))
+	}
+	if !t.EndTime.IsZero() {
+		q.Add("end_time", t.EndTime.Format(time.RFC3339))
+	}
+	req.URL.RawQuery = q.Encode()
+}
+
+// TweetFirehoseStream will stream all of the public tweets for a partition of the firehose
+func (c *Client) TweetFirehoseStream(ctx context.Context, opts TweetVolumeStreamOpts) (*TweetStream, error) {
+	return c.partitionStream(ctx, func(ctx context.Context) (io.ReadCloser, *RateLimit, error) {
+		if err := opts.validate("tweet firehose stream", tweetFirehoseStreamMaxPartition); err != nil {
+			return nil, nil, err
+		}
+		return c.streamConnect(ctx, "tweet firehose stream", "TweetFirehoseStream", tweetFirehoseStreamEndpoint, opts.addQuery)
+	})
+}
+
+// TweetSample10Stream will stream a 10% sample of the public tweets for a partition
+func (c *Client) TweetSample10Stream(ctx context.Context, opts TweetVolumeStreamOpts) (*TweetStream, error) {
+	return c.partitionStream(ctx, func(ctx context.Context) (io.ReadCloser, *RateLimit, error) {
+		if err := opts.validate("tweet sample10 stream", tweetSample10StreamMaxPartition); err != nil {
+			return nil, nil, err
+		}
+		return c.streamConnect(ctx, "tweet sample10 stream", "TweetSample10Stream", tweetSample10StreamEndpoint, opts.addQuery)
+	})
+}
+
+// ManagedTweetFirehoseStream will start a firehose partition stream that reconnects, the first connection is made
+// before returning
+func (c *Client) ManagedTweetFirehoseStream(ctx context.Context, opts TweetVolumeStreamOpts, managed ManagedStreamOpts) (*ManagedTweetStream, error) {
+	return startManagedTweetStream(ctx, opts.BackfillMinutes, managed, func(ctx context.Context, backfillMinutes int) (*TweetStream, error) {
+		connectOpts := opts
+		connectOpts.BackfillMinutes = backfillMinutes
+		return c.TweetFirehoseStream(ctx, connectOpts)
+	})
+}
+
+// ManagedTweetSample10Stream will start a 10% sample partition stream that reconnects, the first connection is made
+// before returning
+func (c *Client) ManagedTweetSample10Stream(ctx context.Context, opts TweetVolumeStreamOpts, managed ManagedStreamOpts) (*ManagedTweetStream, error) {
+	return startManagedTweetStream(ctx, opts.BackfillMinutes, managed, func(ctx context.Context, backfillMinutes int) (*TweetStream, error) {
+		connectOpts := opts
+		connectOpts.BackfillMinutes = backfillMinutes
+		return c.TweetSample10Stream(ctx, connectOpts)
+	})
+}
+
+// partitionStream will connect and start the tweet stream of a partition
+func (c *Client) partitionStream(ctx context.Context, connect func(ctx context.Context) (io.ReadCloser, *RateLimit, error)) (*TweetStream, error) {
+	if err := c.StreamOpts.validate(); err != nil {
+		return nil, fmt.Errorf("partition stream: %w", err)
+	}
+	body, rl, err := connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	stream, err := StartTweetStreamWithOpts(ctx, body, c.StreamOpts)
+	if err != nil {
+		return nil, err
+	}
+	stream.RateLimit = rl
+	return stream, nil
+}
+
+// MergedTweetStream is the messages of many streams, like the partitions of a firehose, on one set of channels.
+//
+// Each stream is read by its own goroutine, so the partitions are consumed concurrently and the order is only
+// kept within a stream.  A stream is blocked while the tweets channel is full, the system messages, disconnections
+// and errors are dropped when their channels are full, so a stream is never blocked by the channels that are not
+// read.  The channels are closed when all of the streams have ended.
+type MergedTweetStream struct {
+	streams       []TweetStreamSource
+	tweets        chan *TweetMessage
+	system        chan map[SystemMessageType]SystemMessage
+	disconnection chan *DisconnectionError
+	err           chan error
+	closing       chan struct{}
+	closeOnce     sync.Once
+	done          chan struct{}
+	mutex         sync.Mutex
+	dropped       StreamDrops
+}
+
+// MergeTweetStreams will merge the messages of the streams, the channels are buffered the same as the largest
+// tweets buffer of the streams
+func MergeTweetStreams(streams ...TweetStreamSource) *MergedTweetStream {
+	size := 0
+	for _, stream := range streams {
+		if buffer := cap(stream.Tweets()); buffer > size {
+			size = buffer
+		}
+	}
+	if size == 0 {
+		size = streamBufferSize
+	}
+	m := &MergedTweetStream{
+		streams:       streams,
+		tweets:        make(chan *TweetMessage, size),
+		system:        make(chan map[SystemMessageType]SystemMessage, size),
+		disconnection: make(chan *DisconnectionError, size),
+		err:           make(chan error, size),
+		closing:       make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+	forwarders := sync.WaitGroup{}
+	for _, stream := range streams {
+		forwarders.Add(1)
+		go func(stream TweetStreamSource) {
+			defer forwarders.Done()
+			m.forward(stream)
+		}(stream)
+	}
+	go func() {
+		forwarders.Wait()
+		close(m.tweets)
+		close(m.system)
+		close(m.disconnection)
+		close(m.err)
+		close(m.done)
+	}()
+	return m
+}
+
+// forward will send the messages of the stream until it has ended
+func (m *MergedTweetStream) forward(stream TweetStreamSource) {
+	streamReceiver{
+		tweet: func(msg *TweetMessage) bool {
+			select {
+			case m.tweets <- msg:
+				return true
+			case <-m.closing:
+				return false
+			}
+		},
+		system: func(msgs map[SystemMessageType]SystemMessage) bool {
+			select {
+			case m.system <- msgs:
+			default:
+				m.drop(func(dropped *StreamDrops) { dropped.SystemMessages++ })
+			}
+			return true
+		},
+		disconnect: func(disconnect *DisconnectionError) bool {
+			select {
+			case m.disconnection <- disconnect:
+			default:
+				m.drop(func(dropped *StreamDrops) { dropped.Disconnections++ })
+			}
+			return true
+		},
+		err: func(err error) bool {
+			select {
+			case m.err <- err:
+			default:
+				m.drop(func(dropped *StreamDrops) { dropped.Errors++ })
+			}
+			return true
+		},
+	}.receive(stream, m.closing)
+}
+
+// drop will count a message that did not fit in the merged channels
+func (m *MergedTweetStream) drop(count func(dropped *StreamDrops)) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	count(&m.dropped)
+}
+
+// Dropped returns the number of system messages, disconnections and errors dropped because the channels were full
+func (m *MergedTweetStream) Dropped() StreamDrops {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.dropped
+}
+
+// Tweets will return the channel to receive the tweet messages of all of the streams
+func (m *MergedTweetStream) Tweets() <-chan *TweetMessage {
+	return m.tweets
+}
+
+// SystemMessages will return the channel to receive the system messages of all of the streams
+func (m *MergedTweetStream) SystemMessages() <-chan map[SystemMessageType]SystemMessage {
+	return m.system
+}
+
+// DisconnectionError will return the channel to receive the disconnect messages of all of the streams
+func (m *MergedTweetStream) DisconnectionError() <-chan *DisconnectionError {
+	return m.disconnection
+}
+
+// Err will return the channel to receive the errors of all of the streams
+func (m *MergedTweetStream) Err() <-chan error {
+	return m.err
+}
+
+// Done will return a channel that is closed when all of the streams have ended and the channels are closed
+func (m *MergedTweetStream) Done() <-chan struct{} {
+	return m.done
+}
+
+// Close will close all of the streams and return once the channels are closed, it is safe to call more than once
+func (m *MergedTweetStream) Close() {
+	m.closeOnce.Do(func() {
+		close(m.closing)
+		for _, stream := range m.streams {
+			stream.Close()
+		}
+	})
+	<-m.done
+}
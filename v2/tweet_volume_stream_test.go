@@ -0,0 +1,110 @@
+package twitter
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestClient_TweetFirehoseStream(t *testing.T) {
+	mutex := sync.Mutex{}
+	queries := map[string]string{}
+	c := &Client{
+		Authorizer: &mockAuth{},
+		Host:       "https://www.test.com",
+		Client: mockHTTPClient(func(req *http.Request) *http.Response {
+			partition := req.URL.Query().Get("partition")
+			mutex.Lock()
+			queries[partition] = req.URL.RawQuery
+			mutex.Unlock()
+			if req.URL.Path != "/2/tweets/firehose/stream" {
+				t.Errorf("TweetFirehoseStream() path = %v", req.URL.Path)
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(strings.NewReader(managedStreamTweets(partition+"1", partition+"2"))),
+				Header:     http.Header{},
+				Request:    req,
+			}
+		}),
+	}
+	if _, err := c.TweetFirehoseStream(context.Background(), TweetVolumeStreamOpts{}); !errors.Is(err, ErrParameter) {
+		t.Errorf("TweetFirehoseStream() no partition error = %v", err)
+	}
+	if _, err := c.TweetSample10Stream(context.Background(), TweetVolumeStreamOpts{Partition: 3}); !errors.Is(err, ErrParameter) {
+		t.Errorf("TweetSample10Stream() partition error = %v", err)
+	}
+
+	streams := []TweetStreamSource{}
+	for _, partition := range []int{1, 2} {
+		stream, err := c.TweetFirehoseStream(context.Background(), TweetVolumeStreamOpts{
+			Partition:   partition,
+			StartTime:   time.Date(2021, 5, 26, 0, 0, 0, 0, time.UTC),
+			TweetFields: []TweetField{TweetFieldCreatedAt},
+		})
+		if err != nil {
+			t.Fatalf("TweetFirehoseStream() error = %v", err)
+		}
+		streams = append(streams, stream)
+	}
+	merged := MergeTweetStreams(streams...)
+	defer merged.Close()
+
+	got := []string{}
+	for msg := range merged.Tweets() {
+		got = append(got, msg.Raw.Tweets[0].ID)
+	}
+	sort.Strings(got)
+	if strings.Join(got, ",") != "11,12,21,22" {
+		t.Errorf("MergeTweetStreams() tweets = %v", got)
+	}
+	if want := "partition=1&start_time=2021-05-26T00%3A00%3A00Z&tweet.fields=created_at"; queries["1"] != want {
+		t.Errorf("TweetFirehoseStream() query = %v, want %v", queries["1"], want)
+	}
+}
+
+func TestMergeTweetStreams_BufferSize(t *testing.T) {
+	stream, err := StartTweetStreamWithOpts(context.Background(), io.NopCloser(strings.NewReader("")), StreamOpts{BufferSize: 25})
+	if err != nil {
+		t.Fatalf("StartTweetStreamWithOpts() error = %v", err)
+	}
+	merged := MergeTweetStreams(newMockTweetStreamSource(), stream)
+	defer merged.Close()
+	if cap(merged.Tweets()) != 25 || cap(merged.SystemMessages()) != 25 || cap(merged.DisconnectionError()) != 25 || cap(merged.Err()) != 25 {
+		t.Errorf("MergeTweetStreams() buffer = %d, want 25", cap(merged.Tweets()))
+	}
+}
+
+func TestMergeTweetStreams_Drops(t *testing.T) {
+	source := newMockTweetStreamSource()
+	merged := MergeTweetStreams(source)
+	defer merged.Close()
+
+	// the errors are not read, so the ones that do not fit are dropped and the tweets are still forwarded
+	for i := 0; i < 11; i++ {
+		source.err <- errors.New("stream error")
+	}
+	source.tweets <- streamHubTweet("1")
+	if msg := <-merged.Tweets(); msg.Raw.Tweets[0].ID != "1" {
+		t.Errorf("MergeTweetStreams() tweet = %v", msg.Raw.Tweets[0].ID)
+	}
+	source.Close()
+	<-merged.Done()
+
+	if want := (StreamDrops{Errors: 1}); merged.Dropped() != want {
+		t.Errorf("MergedTweetStream.Dropped() = %+v, want %+v", merged.Dropped(), want)
+	}
+	errs := 0
+	for range merged.Err() {
+		errs++
+	}
+	if errs != 10 {
+		t.Errorf("MergedTweetStream.Err() = %d errors, want 10", errs)
+	}
+}